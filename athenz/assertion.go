@@ -2,6 +2,7 @@ package athenz
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
@@ -72,8 +73,12 @@ func expandPolicyAssertions(dn string, configured []interface{}) []*zms.Assertio
 func flattenPolicyAssertion(list []*zms.Assertion) []interface{} {
 	policyAssertions := make([]interface{}, 0, len(list))
 	for _, a := range list {
-		role := strings.Split(a.Role, ROLE_SEPARATOR)[1]
-		resource := strings.Split(a.Resource, RESOURCE_SEPARATOR)[1]
+		role := nameAfterSeparator(a.Role, ROLE_SEPARATOR)
+		resource := nameAfterSeparator(a.Resource, RESOURCE_SEPARATOR)
+		if strings.Contains(resource, RESOURCE_SEPARATOR) {
+			// e.g. some_domain:data:reader, it's configured with the domain as the name has a separator
+			resource = a.Resource
+		}
 		effect := a.Effect.String()
 		action := a.Action
 
@@ -87,3 +92,61 @@ func flattenPolicyAssertion(list []*zms.Assertion) []interface{} {
 	}
 	return policyAssertions
 }
+
+// assertionKeys returns the keys of the assertions in lowercase, as ZMS keeps the actions and the resources
+func assertionKeys(assertions []*zms.Assertion) map[string]bool {
+	keys := make(map[string]bool, len(assertions))
+	for _, a := range assertions {
+		keys[strings.ToLower(assertionKey(a))] = true
+	}
+	return keys
+}
+
+// filterAssertions returns the assertions whose key in lowercase is kept
+func filterAssertions(assertions []*zms.Assertion, keep func(key string) bool) []*zms.Assertion {
+	filtered := make([]*zms.Assertion, 0, len(assertions))
+	for _, a := range assertions {
+		if keep(strings.ToLower(assertionKey(a))) {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// addAssertions returns the assertions with the added ones that aren't already there
+func addAssertions(assertions []*zms.Assertion, added []*zms.Assertion) []*zms.Assertion {
+	existing := assertionKeys(assertions)
+	for _, a := range filterAssertions(added, func(key string) bool { return !existing[key] }) {
+		assertions = append(assertions, a)
+	}
+	return assertions
+}
+
+func nameAfterSeparator(name, separator string) string {
+	if i := strings.Index(name, separator); i >= 0 {
+		return name[i+len(separator):]
+	}
+	return name
+}
+
+// globMatch returns true when the value matches the pattern of an assertion action or resource, where * matches
+// any sequence of characters and ? matches a single character, as in the assertion evaluation of ZPE
+func globMatch(pattern string, value string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == value
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()).MatchString(value)
+}
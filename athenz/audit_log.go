@@ -0,0 +1,200 @@
+package athenz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	AUDIT_ACTION_CREATE = "create"
+	AUDIT_ACTION_UPDATE = "update"
+	AUDIT_ACTION_DELETE = "delete"
+)
+
+// the time to send a record to the webhook of the audit log
+const auditLogWebhookTimeout = 10 * time.Second
+
+// auditRecord is a change applied by terraform, the values of the resource are hashed so the log doesn't
+// have them, e.g. the members of a role
+type auditRecord struct {
+	Timestamp string `json:"timestamp"`
+	Principal string `json:"principal"`
+	Action    string `json:"action"`
+	// Resource is the type of the resource, e.g. athenz_role
+	Resource string `json:"resource"`
+	Id       string `json:"id"`
+	AuditRef string `json:"audit_ref,omitempty"`
+	// OldHash and NewHash are the SHA-256 of the values of the resource before and after the change, empty
+	// before a creation and after a deletion
+	OldHash string `json:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty"`
+}
+
+// auditLog appends a JSON record of every change to a local file, one per line, or posts it to a webhook
+type auditLog struct {
+	destination string
+	webhook     bool
+	mu          sync.Mutex
+	httpClient  *http.Client
+}
+
+// newAuditLog returns the audit log of the destination, the path of a file or an http(s) URL of a webhook
+func newAuditLog(destination string) (*auditLog, error) {
+	if u, err := url.Parse(destination); err == nil && (u.Scheme == "https" || u.Scheme == "http") {
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid audit log webhook %q, the host is missing", destination)
+		}
+		return &auditLog{destination: destination, webhook: true, httpClient: &http.Client{Timeout: auditLogWebhookTimeout}}, nil
+	}
+	// the file is created now, so a destination that can't be written fails the configuration and not
+	// the first change
+	file, err := os.OpenFile(destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("can't open the audit log: %s", err)
+	}
+	_ = file.Close()
+	return &auditLog{destination: destination}, nil
+}
+
+func (l *auditLog) write(ctx context.Context, record auditRecord) error {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if l.webhook {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.destination, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := l.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("the webhook returned %s", resp.Status)
+		}
+		return nil
+	}
+
+	// the changes are applied in parallel, a record is written at once
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(content, '\n'))
+	return err
+}
+
+func auditLogOf(meta interface{}) *auditLog {
+	if config, ok := meta.(*providerConfig); ok {
+		return config.auditLog
+	}
+	return nil
+}
+
+// withAuditLog records the successful creations, updates and deletions of the resource in the audit log of
+// the provider, if any. a record that can't be written is a warning, the change itself was applied
+func withAuditLog(name string, resource *schema.Resource) *schema.Resource {
+	wrap := func(action string, apply func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if apply == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			changeLog := auditLogOf(meta)
+			if changeLog == nil {
+				return apply(ctx, d, meta)
+			}
+			record := auditRecord{Action: action, Resource: name, Id: d.Id()}
+			if action != AUDIT_ACTION_CREATE {
+				record.OldHash = hashResourceValues(resource, d, true)
+			}
+			diags := apply(ctx, d, meta)
+			if diags.HasError() {
+				return diags
+			}
+			if action != AUDIT_ACTION_DELETE {
+				record.Id = d.Id()
+				record.NewHash = hashResourceValues(resource, d, false)
+			}
+			record.Timestamp = time.Now().UTC().Format(time.RFC3339)
+			config := meta.(*providerConfig)
+			record.Principal = config.principal
+			if auditRef, ok := d.Get("audit_ref").(string); ok {
+				// the audit_ref as ZMS has it
+				record.AuditRef = strings.TrimSpace(auditRef + " " + config.auditRefSuffix)
+			}
+			if err := changeLog.write(ctx, record); err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  fmt.Sprintf("the %s of %s %s isn't in the audit log", action, name, record.Id),
+					Detail:   err.Error(),
+				})
+			}
+			return diags
+		}
+	}
+	resource.CreateContext = wrap(AUDIT_ACTION_CREATE, resource.CreateContext)
+	resource.UpdateContext = wrap(AUDIT_ACTION_UPDATE, resource.UpdateContext)
+	resource.DeleteContext = wrap(AUDIT_ACTION_DELETE, resource.DeleteContext)
+	return resource
+}
+
+// hashResourceValues returns the SHA-256 of the values of the resource before the change (old) or after it,
+// without the audit_ref, which is in the record
+func hashResourceValues(resource *schema.Resource, d *schema.ResourceData, old bool) string {
+	values := make(map[string]interface{}, len(resource.Schema))
+	for key := range resource.Schema {
+		if key == "audit_ref" {
+			continue
+		}
+		before, after := d.GetChange(key)
+		if old {
+			values[key] = normalizeValue(before)
+		} else {
+			values[key] = normalizeValue(after)
+		}
+	}
+	// the keys of a map are sorted by json
+	content, _ := json.Marshal(values)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeValue returns the value with the sets as lists, as a set has no exported fields for json
+func normalizeValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case *schema.Set:
+		return normalizeValue(value.List())
+	case []interface{}:
+		list := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			list = append(list, normalizeValue(item))
+		}
+		return list
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[key] = normalizeValue(item)
+		}
+		return normalized
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+package athenz
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func testAuditedResource() *schema.Resource {
+	return withAuditLog("athenz_test", &schema.Resource{
+		CreateContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			d.SetId(d.Get("name").(string))
+			return nil
+		},
+		ReadContext: func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics { return nil },
+		UpdateContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			if d.Get("members").(*schema.Set).Contains("user.fail") {
+				return diag.Errorf("the update failed")
+			}
+			return nil
+		},
+		DeleteContext: func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics { return nil },
+		Schema: map[string]*schema.Schema{
+			"name":      {Type: schema.TypeString, Required: true, ForceNew: true},
+			"members":   {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+			"audit_ref": {Type: schema.TypeString, Optional: true, Default: AUDIT_REF},
+		},
+	})
+}
+
+func readAuditRecords(t *testing.T, path string) []auditRecord {
+	content, err := ioutil.ReadFile(path)
+	ast.NilError(t, err)
+	var records []auditRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var record auditRecord
+		ast.NilError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func Test_withAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	changeLog, err := newAuditLog(path)
+	ast.NilError(t, err)
+	meta := &providerConfig{principal: "some_domain.terraform", auditLog: changeLog}
+	resource := testAuditedResource()
+	apply := func(state *terraform.InstanceState, config map[string]interface{}) (*terraform.InstanceState, diag.Diagnostics) {
+		var diff *terraform.InstanceDiff
+		if config == nil {
+			diff = &terraform.InstanceDiff{Destroy: true}
+		} else {
+			diff, err = resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), meta)
+			ast.NilError(t, err)
+		}
+		return resource.Apply(context.Background(), state, diff, meta)
+	}
+
+	// case: the creation, the update and the deletion are recorded, the update with the hashes of the values
+	state, diags := apply(nil, map[string]interface{}{"name": "readers", "members": []interface{}{"user.jane"}})
+	ast.Assert(t, !diags.HasError())
+	state, diags = apply(state, map[string]interface{}{"name": "readers", "members": []interface{}{"user.joe"}, "audit_ref": "JIRA-1"})
+	ast.Assert(t, !diags.HasError())
+	// case: a failed update isn't recorded
+	_, diags = apply(state, map[string]interface{}{"name": "readers", "members": []interface{}{"user.fail"}})
+	ast.Assert(t, diags.HasError())
+	_, diags = apply(state, nil)
+	ast.Assert(t, !diags.HasError())
+
+	records := readAuditRecords(t, path)
+	ast.Equal(t, len(records), 3)
+	created, updated, deleted := records[0], records[1], records[2]
+	ast.Equal(t, created.Action, AUDIT_ACTION_CREATE)
+	ast.Equal(t, created.Resource, "athenz_test")
+	ast.Equal(t, created.Id, "readers")
+	ast.Equal(t, created.Principal, "some_domain.terraform")
+	ast.Equal(t, created.AuditRef, AUDIT_REF)
+	ast.Equal(t, created.OldHash, "")
+	ast.Assert(t, created.Timestamp != "")
+	ast.Equal(t, updated.Action, AUDIT_ACTION_UPDATE)
+	ast.Equal(t, updated.AuditRef, "JIRA-1")
+	ast.Equal(t, updated.OldHash, created.NewHash)
+	ast.Assert(t, updated.NewHash != updated.OldHash)
+	ast.Equal(t, deleted.Action, AUDIT_ACTION_DELETE)
+	ast.Equal(t, deleted.Id, "readers")
+	ast.Equal(t, deleted.OldHash, updated.NewHash)
+	ast.Equal(t, deleted.NewHash, "")
+}
+
+func Test_auditLogWebhook(t *testing.T) {
+	var received []auditRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record auditRecord
+		ast.NilError(t, json.NewDecoder(r.Body).Decode(&record))
+		received = append(received, record)
+		if record.Id == "writers" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	changeLog, err := newAuditLog(server.URL + "/athenz")
+	ast.NilError(t, err)
+	meta := &providerConfig{principal: "some_domain.terraform", auditLog: changeLog}
+	resource := testAuditedResource()
+
+	d := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{"name": "readers"})
+	ast.Assert(t, !resource.CreateContext(context.Background(), d, meta).HasError())
+	ast.Equal(t, len(received), 1)
+	ast.Equal(t, received[0].Id, "readers")
+
+	// case: the change was applied, the record that the webhook didn't accept is a warning
+	d = schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{"name": "writers"})
+	diags := resource.CreateContext(context.Background(), d, meta)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, diags[0].Severity, diag.Warning)
+	ast.Equal(t, d.Id(), "writers")
+}
+
+func Test_newAuditLog(t *testing.T) {
+	_, err := newAuditLog(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+	ast.ErrorContains(t, err, "can't open the audit log")
+	_, err = newAuditLog("https:///athenz")
+	ast.ErrorContains(t, err, "the host is missing")
+}
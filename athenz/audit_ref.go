@@ -0,0 +1,56 @@
+package athenz
+
+import (
+	"strings"
+)
+
+// the environment variables of the commit of the configuration, by CI system
+var commitEnvVars = []string{
+	"TFC_CONFIGURATION_VERSION_GIT_COMMIT_SHA", // Terraform Cloud
+	"GITHUB_SHA",          // GitHub Actions
+	"CI_COMMIT_SHA",       // GitLab CI
+	"BUILD_SOURCEVERSION", // Azure Pipelines
+	"CIRCLE_SHA1",         // CircleCI
+	"BITBUCKET_COMMIT",    // Bitbucket Pipelines
+	"GIT_COMMIT",          // Jenkins
+}
+
+// the environment variables of the URL of the pipeline, GitHub Actions has none and its URL is built
+var pipelineEnvVars = []string{
+	"CI_PIPELINE_URL",  // GitLab CI
+	"CIRCLE_BUILD_URL", // CircleCI
+	"BUILD_URL",        // Jenkins
+}
+
+// runMetadata returns the commit, the Terraform Cloud run and the pipeline of the run found in the environment,
+// e.g. [commit=0a1b2c3 run=run-CZcmD7eagjhyX0vN pipeline=https://gitlab.com/org/repo/-/pipelines/42], or an empty
+// string when there's none
+func runMetadata(getenv func(string) string) string {
+	var metadata []string
+	if commit := firstEnv(getenv, commitEnvVars); commit != "" {
+		metadata = append(metadata, "commit="+commit)
+	}
+	if run := getenv("TFC_RUN_ID"); run != "" {
+		metadata = append(metadata, "run="+run)
+	}
+	pipeline := firstEnv(getenv, pipelineEnvVars)
+	if pipeline == "" && getenv("GITHUB_RUN_ID") != "" {
+		pipeline = getenv("GITHUB_SERVER_URL") + "/" + getenv("GITHUB_REPOSITORY") + "/actions/runs/" + getenv("GITHUB_RUN_ID")
+	}
+	if pipeline != "" {
+		metadata = append(metadata, "pipeline="+pipeline)
+	}
+	if len(metadata) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(metadata, " ") + "]"
+}
+
+func firstEnv(getenv func(string) string, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(getenv(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
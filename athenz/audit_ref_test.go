@@ -0,0 +1,34 @@
+package athenz
+
+import (
+	"testing"
+
+	ast "gotest.tools/assert"
+)
+
+func Test_runMetadata(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	ast.Equal(t, runMetadata(env(nil)), "")
+
+	// case: Terraform Cloud, with the commit of the configuration version
+	ast.Equal(t, runMetadata(env(map[string]string{
+		"TFC_RUN_ID": "run-CZcmD7eagjhyX0vN",
+		"TFC_CONFIGURATION_VERSION_GIT_COMMIT_SHA": "0a1b2c3",
+	})), "[commit=0a1b2c3 run=run-CZcmD7eagjhyX0vN]")
+
+	// case: GitHub Actions, the URL of the run is built
+	ast.Equal(t, runMetadata(env(map[string]string{
+		"GITHUB_SHA":        "4d5e6f7",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "org/repo",
+		"GITHUB_RUN_ID":     "1234",
+	})), "[commit=4d5e6f7 pipeline=https://github.com/org/repo/actions/runs/1234]")
+
+	// case: GitLab CI
+	ast.Equal(t, runMetadata(env(map[string]string{
+		"CI_COMMIT_SHA":   "8a9b0c1",
+		"CI_PIPELINE_URL": "https://gitlab.com/org/repo/-/pipelines/42",
+	})), "[commit=8a9b0c1 pipeline=https://gitlab.com/org/repo/-/pipelines/42]")
+}
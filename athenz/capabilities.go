@@ -0,0 +1,155 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// serverCapabilities are the types, the fields and the resources of the RDL schema of ZMS, read by the
+// first plan of an attribute that needs one of them. the attributes that a ZMS of an older version doesn't support fail the plan
+// with the feature that's missing, instead of a 400 of ZMS in the apply
+type serverCapabilities struct {
+	// the fields of the struct types, by type name
+	fields map[string]map[string]bool
+	// the resources, e.g. PUT /domain/{domainName}/policy/{policyName}/version/create
+	resources map[string]bool
+}
+
+func newServerCapabilities(rdlSchema *rdl.Schema) *serverCapabilities {
+	capabilities := &serverCapabilities{fields: map[string]map[string]bool{}, resources: map[string]bool{}}
+	for _, t := range rdlSchema.Types {
+		if t == nil || t.StructTypeDef == nil {
+			continue
+		}
+		fields := map[string]bool{}
+		for _, field := range t.StructTypeDef.Fields {
+			fields[string(field.Name)] = true
+		}
+		capabilities.fields[string(t.StructTypeDef.Name)] = fields
+	}
+	for _, r := range rdlSchema.Resources {
+		if r != nil {
+			capabilities.resources[strings.ToUpper(r.Method)+" "+r.Path] = true
+		}
+	}
+	return capabilities
+}
+
+// detectServerCapabilities reads the schema of ZMS. the capabilities are unknown when it can't be read,
+// e.g. the principal isn't allowed to, and all the attributes are sent to ZMS as before
+func detectServerCapabilities(zmsClient client.ZmsClient) *serverCapabilities {
+	rdlSchema, err := zmsClient.GetRdlSchema()
+	if err != nil || rdlSchema == nil {
+		log.Printf("[WARN] can't read the schema of ZMS, the attributes aren't verified against its version: %v", err)
+		return nil
+	}
+	return newServerCapabilities(rdlSchema)
+}
+
+// capability is a feature of ZMS used by some attributes. the schema either has a field of a type, a type
+// or a resource
+type capability struct {
+	feature  string
+	typeName string
+	field    string
+	resource string
+}
+
+var (
+	capabilityRoleTags       = capability{feature: "the tags of a role", typeName: "Role", field: "tags"}
+	capabilityMemberReview   = capability{feature: "the review reminder of a role member", typeName: "RoleMember", field: "reviewReminder"}
+	capabilityGroups         = capability{feature: "the groups", typeName: "Group"}
+	capabilityPolicyVersions = capability{feature: "the policy versions", resource: "PUT /domain/{domainName}/policy/{policyName}/version/create"}
+)
+
+// supports returns true when the capabilities are unknown, as before the detection
+func (c *serverCapabilities) supports(feature capability) bool {
+	if c == nil {
+		return true
+	}
+	if feature.resource != "" {
+		return c.resources[feature.resource]
+	}
+	fields, ok := c.fields[feature.typeName]
+	return ok && (feature.field == "" || fields[feature.field])
+}
+
+func (feature capability) missing() string {
+	switch {
+	case feature.resource != "":
+		return "resource " + feature.resource
+	case feature.field != "":
+		return fmt.Sprintf("field %s in the type %s", feature.field, feature.typeName)
+	}
+	return "type " + feature.typeName
+}
+
+// capabilitiesOf detects the capabilities once, so the configs that don't use the gated attributes don't
+// read the schema of ZMS
+func capabilitiesOf(ctx context.Context, meta interface{}) *serverCapabilities {
+	config, ok := meta.(*providerConfig)
+	if !ok {
+		return nil
+	}
+	config.detectCapability.Do(func() {
+		if config.capabilities == nil && config.ZmsClient != nil {
+			config.capabilities = detectServerCapabilities(config.ZmsClient.WithContext(ctx))
+		}
+	})
+	return config.capabilities
+}
+
+// requireCapability fails the plan of a change of the attribute that uses the feature, when ZMS doesn't
+// support it. the attributes already in the state aren't verified, they were accepted by ZMS
+func requireCapability(feature capability, attribute string, used func(d *schema.ResourceDiff) bool) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() != "" && !d.HasChange(attribute) {
+			return nil
+		}
+		if !d.NewValueKnown(attribute) || !used(d) || capabilitiesOf(ctx, meta).supports(feature) {
+			return nil
+		}
+		return fmt.Errorf("%s: not supported by your ZMS version (%s, its schema has no %s). upgrade ZMS or remove %s", attribute, feature.feature, feature.missing(), attribute)
+	}
+}
+
+// isNotEmpty returns true when the set, the list or the string of the attribute isn't empty
+func isNotEmpty(attribute string) func(d *schema.ResourceDiff) bool {
+	return func(d *schema.ResourceDiff) bool {
+		switch v := d.Get(attribute).(type) {
+		case *schema.Set:
+			return v.Len() > 0
+		case []interface{}:
+			return len(v) > 0
+		case string:
+			return v != ""
+		}
+		return false
+	}
+}
+
+// hasMemberReview returns true when a member of the role has a review reminder
+func hasMemberReview(d *schema.ResourceDiff) bool {
+	for _, m := range d.Get("member").(*schema.Set).List() {
+		if review, _ := m.(map[string]interface{})["review"].(string); review != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// requireResourceCapability fails the plan of a new resource that is the feature itself, e.g. a group
+func requireResourceCapability(feature capability) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() != "" || capabilitiesOf(ctx, meta).supports(feature) {
+			return nil
+		}
+		return fmt.Errorf("not supported by your ZMS version (%s, its schema has no %s). upgrade ZMS or remove the resource", feature.feature, feature.missing())
+	}
+}
@@ -0,0 +1,103 @@
+package athenz
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+// the schema of a ZMS with the roles and the members without a review reminder, and without the groups
+// and the policy versions
+const oldZmsSchema = `{
+  "name": "ZMS",
+  "version": 1,
+  "types": [
+    {"StringTypeDef": {"type": "String", "name": "SimpleName", "pattern": "[a-zA-Z0-9_][a-zA-Z0-9_-]*"}},
+    {"StructTypeDef": {"type": "Struct", "name": "Role", "fields": [{"name": "name", "type": "ResourceName"}, {"name": "roleMembers", "type": "Array", "optional": true, "items": "RoleMember"}]}},
+    {"StructTypeDef": {"type": "Struct", "name": "RoleMember", "fields": [{"name": "memberName", "type": "MemberName"}, {"name": "expiration", "type": "Timestamp", "optional": true}]}}
+  ],
+  "resources": [
+    {"type": "Role", "method": "GET", "path": "/domain/{domainName}/role/{roleName}"}
+  ]
+}`
+
+func Test_serverCapabilities(t *testing.T) {
+	var rdlSchema rdl.Schema
+	ast.NilError(t, json.Unmarshal([]byte(oldZmsSchema), &rdlSchema))
+	capabilities := newServerCapabilities(&rdlSchema)
+	ast.Assert(t, capabilities.supports(capability{typeName: "Role", field: "roleMembers"}))
+	ast.Assert(t, capabilities.supports(capability{resource: "GET /domain/{domainName}/role/{roleName}"}))
+	ast.Assert(t, !capabilities.supports(capabilityRoleTags))
+	ast.Assert(t, !capabilities.supports(capabilityMemberReview))
+	ast.Assert(t, !capabilities.supports(capabilityGroups))
+	ast.Assert(t, !capabilities.supports(capabilityPolicyVersions))
+
+	// case: the capabilities are unknown, everything is supported
+	var unknown *serverCapabilities
+	ast.Assert(t, unknown.supports(capabilityGroups))
+}
+
+func Test_detectServerCapabilities(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().GetRdlSchema().Return(nil, rdl.ResourceError{Code: 403, Message: "forbidden"})
+	ast.Assert(t, detectServerCapabilities(clientMock) == nil)
+}
+
+func Test_capabilitiesOf(t *testing.T) {
+	var rdlSchema rdl.Schema
+	ast.NilError(t, json.Unmarshal([]byte(oldZmsSchema), &rdlSchema))
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock}
+	config := map[string]interface{}{"domain": "some_domain", "name": "readers"}
+
+	// case: the schema isn't read when the role doesn't use a gated attribute
+	_, err := ResourceRole().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), meta)
+	ast.NilError(t, err)
+
+	// case: the schema is read once, by the first plan that uses a gated attribute
+	clientMock.EXPECT().GetRdlSchema().Return(&rdlSchema, nil).Times(1)
+	config["tags"] = []interface{}{map[string]interface{}{"key": "zms.owner", "values": []interface{}{"someone"}}}
+	for i := 0; i < 2; i++ {
+		_, err = ResourceRole().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), meta)
+		ast.ErrorContains(t, err, "tags: not supported by your ZMS version")
+	}
+}
+
+func Test_requireCapability(t *testing.T) {
+	var rdlSchema rdl.Schema
+	ast.NilError(t, json.Unmarshal([]byte(oldZmsSchema), &rdlSchema))
+	meta := &providerConfig{capabilities: newServerCapabilities(&rdlSchema)}
+	config := map[string]interface{}{
+		"domain": "some_domain",
+		"name":   "readers",
+		"member": []interface{}{map[string]interface{}{"name": "user.jane", "expiration": "2030-01-01 00:00:00"}},
+	}
+
+	// case: the role doesn't use the features that this ZMS doesn't have
+	_, err := ResourceRole().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), meta)
+	ast.NilError(t, err)
+
+	// case: the tags of a role
+	config["tags"] = []interface{}{map[string]interface{}{"key": "zms.owner", "values": []interface{}{"someone"}}}
+	_, err = ResourceRole().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), meta)
+	ast.ErrorContains(t, err, "tags: not supported by your ZMS version (the tags of a role, its schema has no field tags in the type Role)")
+	delete(config, "tags")
+
+	// case: the review reminder of a member
+	config["member"] = []interface{}{map[string]interface{}{"name": "user.jane", "review": "30d"}}
+	_, err = ResourceRole().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), meta)
+	ast.ErrorContains(t, err, "member: not supported by your ZMS version (the review reminder of a role member")
+
+	// case: a new group
+	_, err = ResourceGroup().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{"domain": "some_domain", "name": "devs"}), meta)
+	ast.ErrorContains(t, err, "not supported by your ZMS version (the groups, its schema has no type Group). upgrade ZMS or remove the resource")
+}
@@ -1,5 +1,7 @@
 package athenz
 
+import "time"
+
 const (
 	AUDIT_REF            = "done by terraform provider"
 	ROLE_SEPARATOR       = ":role."
@@ -9,4 +11,23 @@ const (
 	SERVICE_SEPARATOR    = "."
 	SUB_DOMAIN_SEPARATOR = "."
 	PREFIX_USER_DOMAIN   = "home."
+	PREFIX_USER          = "user."
+	MEMBER_DATE_LAYOUT   = "2006-01-02 15:04:05"
 )
+
+// the default timeout of a resource operation, it can be changed in the timeouts block of the resource
+const DEFAULT_TIMEOUT = 10 * time.Minute
+
+// the time to wait for a created resource to be replicated to all the ZMS servers
+const READ_AFTER_CREATE_TIMEOUT = 2 * time.Minute
+
+// the time to wait for ZMS to accept the assertions of a role it doesn't see yet, e.g. a role created
+// by the same apply on another ZMS server
+const ASSERTION_ROLE_WAIT_TIMEOUT = time.Minute
+
+// the number of names requested at a time by the list requests, so a list of a large domain or of all
+// the domains isn't a single huge response
+const LIST_PAGE_SIZE int32 = 1000
+
+// the number of members of a group that makes it a large group for the admin role, by the lint
+const DEFAULT_LINT_LARGE_GROUP_MEMBERS = 100
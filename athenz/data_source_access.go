@@ -0,0 +1,202 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the decisions of the access data source, named as the access check status of ZPE
+const (
+	ACCESS_ALLOW         = "ALLOW"
+	ACCESS_DENY          = "DENY"
+	ACCESS_DENY_NO_MATCH = "DENY_NO_MATCH"
+)
+
+// DataSourceAccess evaluates the access of a principal to a resource of a domain as ZPE does, from the
+// signed domain, so the principal of the provider doesn't need to be allowed to check the access of others
+func DataSourceAccess() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAccessRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Description:      "The domain of the policies",
+				Required:         true,
+				ValidateDiagFunc: validateDomainName,
+			},
+			"principal": {
+				Type:             schema.TypeString,
+				Description:      "The principal, e.g. user.jane or some_domain.api",
+				Required:         true,
+				ValidateDiagFunc: validatePrincipalName,
+			},
+			"action": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"resource": {
+				Type:        schema.TypeString,
+				Description: "The resource name, or <domain>:<name>",
+				Required:    true,
+			},
+			"allowed": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"decision": {
+				Type:        schema.TypeString,
+				Description: "ALLOW, DENY when an assertion denies the access, or DENY_NO_MATCH when no assertion allows it",
+				Computed:    true,
+			},
+			"roles": {
+				Type:        schema.TypeList,
+				Description: "The roles of the domain the principal is a member of",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"assertion": {
+				Type:        schema.TypeList,
+				Description: "The assertion of the decision",
+				Computed:    true,
+				Elem:        assertionElem(true),
+			},
+		},
+	}
+}
+
+func dataSourceAccessRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
+
+	dn := d.Get("domain").(string)
+	// the member names are lowercase in ZMS
+	principal := strings.ToLower(d.Get("principal").(string))
+	action := d.Get("action").(string)
+	resource := fullName(dn, d.Get("resource").(string), RESOURCE_SEPARATOR)
+
+	fetch := signedDomainFetch(zmsClient)
+	data, err := fetch(dn)
+	var roles map[string]bool
+	if err == nil {
+		roles, err = principalRoles(data, fetch, principal, time.Now())
+	}
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		return zmsDiagnostics(ctx, meta, v, "error retrieving the signed domains of the access of "+principal, dn, "the domain "+dn)
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+
+	decision, assertion := evaluateAccess(data, roles, action, resource)
+	matched := make([]interface{}, 0, 1)
+	if assertion != nil {
+		matched = append(matched, map[string]interface{}{
+			"effect": assertion.Effect.String(), "action": assertion.Action, "role": assertion.Role, "resource": assertion.Resource,
+		})
+	}
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+
+	d.SetId(fmt.Sprintf("%s:%s:%s:%s", dn, principal, action, resource))
+	if err = d.Set("allowed", decision == ACCESS_ALLOW); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("decision", decision); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("roles", names); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("assertion", matched); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// principalRoles returns the roles of the domain the principal is an active member of, directly, through a
+// wildcard member e.g. some_domain.*, through a group of any domain, or through a delegated role. the members
+// are expanded as in the access matrix, so both data sources give the same answer for a principal
+func principalRoles(data *zms.DomainData, fetch func(string) (*zms.DomainData, error), principal string, now time.Time) (map[string]bool, error) {
+	roles := make(map[string]bool)
+	for _, role := range data.Roles {
+		members, err := roleMembers(role, fetch, now)
+		if err != nil {
+			return nil, err
+		}
+		for member := range members {
+			if memberMatches(member, principal) {
+				roles[strings.ToLower(string(role.Name))] = true
+				break
+			}
+		}
+	}
+	return roles, nil
+}
+
+func memberActive(active *bool, expiration *rdl.Timestamp, systemDisabled *int32, now time.Time) bool {
+	if active != nil && !*active {
+		return false
+	}
+	if systemDisabled != nil && *systemDisabled != 0 {
+		return false
+	}
+	return expiration == nil || expiration.Time.After(now)
+}
+
+// memberMatches returns true when the member is the principal, or a wildcard member matching it
+func memberMatches(member string, principal string) bool {
+	if strings.HasSuffix(member, "*") {
+		return strings.HasPrefix(principal, strings.TrimSuffix(member, "*"))
+	}
+	return member == principal
+}
+
+// evaluateAccess returns the decision of the assertions of the active policies for the roles. as in ZPE, an
+// assertion denying the access wins over the assertions allowing it
+func evaluateAccess(data *zms.DomainData, roles map[string]bool, action string, resource string) (string, *zms.Assertion) {
+	if data.Policies == nil || data.Policies.Contents == nil {
+		return ACCESS_DENY_NO_MATCH, nil
+	}
+	action = strings.ToLower(action)
+	resource = strings.ToLower(resource)
+	decision := ACCESS_DENY_NO_MATCH
+	var allowedBy *zms.Assertion
+	for _, policy := range data.Policies.Contents.Policies {
+		if policy.Active != nil && !*policy.Active {
+			continue
+		}
+		for _, a := range policy.Assertions {
+			if !globMatch(strings.ToLower(a.Action), action) || !globMatch(strings.ToLower(a.Resource), resource) || !assertionRoleMatches(a.Role, roles) {
+				continue
+			}
+			if a.Effect != nil && *a.Effect == zms.DENY {
+				return ACCESS_DENY, a
+			}
+			if allowedBy == nil {
+				decision, allowedBy = ACCESS_ALLOW, a
+			}
+		}
+	}
+	return decision, allowedBy
+}
+
+func assertionRoleMatches(pattern string, roles map[string]bool) bool {
+	pattern = strings.ToLower(pattern)
+	for role := range roles {
+		if globMatch(pattern, role) {
+			return true
+		}
+	}
+	return false
+}
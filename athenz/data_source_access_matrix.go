@@ -0,0 +1,260 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the action of the assertions of a trusted domain allowing its roles to assume a delegated role
+const ASSUME_ROLE_ACTION = "assume_role"
+
+// DataSourceAccessMatrix returns the principals allowed an action on a resource of a domain, with the roles
+// allowing it. the groups and the delegated roles are expanded from the signed domains of their domains
+func DataSourceAccessMatrix() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAccessMatrixRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Description:      "The domain of the policies",
+				Required:         true,
+				ValidateDiagFunc: validateDomainName,
+			},
+			"action": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"resource": {
+				Type:        schema.TypeString,
+				Description: "The resource name or pattern, or <domain>:<name>",
+				Required:    true,
+			},
+			"principals": {
+				Type:        schema.TypeList,
+				Description: "The principals allowed the action on the resource, sorted by name",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"roles": {
+							Type:        schema.TypeList,
+							Description: "The roles of the domain allowing the action to the principal",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"denied_principals": {
+				Type:        schema.TypeList,
+				Description: "The principals of the allowing roles that an assertion denies the action to",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceAccessMatrixRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
+
+	dn := d.Get("domain").(string)
+	action := d.Get("action").(string)
+	resource := fullName(dn, d.Get("resource").(string), RESOURCE_SEPARATOR)
+
+	fetch := signedDomainFetch(zmsClient)
+	data, err := fetch(dn)
+	var matrix *accessMatrix
+	if err == nil {
+		matrix, err = evaluateAccessMatrix(data, fetch, action, resource, true, time.Now())
+	}
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		return zmsDiagnostics(ctx, meta, v, "error retrieving the signed domains of the access to "+resource, dn, "the domain "+dn)
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%s:%s:%s", dn, action, resource))
+	if err = d.Set("principals", matrix.flattenAllowed()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("denied_principals", matrix.deniedNames()); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// signedDomainFetch returns the signed domains of a read, the domain and the domains of its groups and
+// delegated roles, each fetched once
+func signedDomainFetch(zmsClient client.ZmsClient) func(string) (*zms.DomainData, error) {
+	domains := make(map[string]*zms.DomainData)
+	return func(name string) (*zms.DomainData, error) {
+		name = strings.ToLower(name)
+		if data, ok := domains[name]; ok {
+			return data, nil
+		}
+		data, err := zmsClient.GetSignedDomain(name)
+		if err != nil {
+			return nil, err
+		}
+		domains[name] = data
+		return data, nil
+	}
+}
+
+// accessMatrix maps the principals to the roles allowing them the access, and holds the principals denied it
+type accessMatrix struct {
+	allowed map[string]map[string]bool
+	denied  map[string]bool
+}
+
+func (m *accessMatrix) flattenAllowed() []interface{} {
+	names := make([]string, 0, len(m.allowed))
+	for name := range m.allowed {
+		if !m.denied[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	principals := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		roles := make([]string, 0, len(m.allowed[name]))
+		for role := range m.allowed[name] {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		principals = append(principals, map[string]interface{}{"name": name, "roles": roles})
+	}
+	return principals
+}
+
+func (m *accessMatrix) deniedNames() []string {
+	names := make([]string, 0, len(m.denied))
+	for name := range m.denied {
+		if m.allowed[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// evaluateAccessMatrix expands the members of the roles of the assertions matching the action and the resource.
+// as in ZPE, a principal denied the access by an assertion isn't allowed it by the others. a wildcard member,
+// e.g. some_domain.*, isn't expanded, it's a principal of the matrix. as in ZMS, the roles of a trusted domain
+// aren't delegated again, delegated is false for them
+func evaluateAccessMatrix(data *zms.DomainData, fetch func(string) (*zms.DomainData, error), action string, resource string, delegated bool, now time.Time) (*accessMatrix, error) {
+	matrix := &accessMatrix{allowed: make(map[string]map[string]bool), denied: make(map[string]bool)}
+	if data.Policies == nil || data.Policies.Contents == nil {
+		return matrix, nil
+	}
+	action = strings.ToLower(action)
+	resource = strings.ToLower(resource)
+	for _, policy := range data.Policies.Contents.Policies {
+		if policy.Active != nil && !*policy.Active {
+			continue
+		}
+		for _, a := range policy.Assertions {
+			if !globMatch(strings.ToLower(a.Action), action) || !globMatch(strings.ToLower(a.Resource), resource) {
+				continue
+			}
+			for _, role := range data.Roles {
+				roleName := strings.ToLower(string(role.Name))
+				if !globMatch(strings.ToLower(a.Role), roleName) {
+					continue
+				}
+				if role.Trust != "" && !delegated {
+					continue
+				}
+				members, err := roleMembers(role, fetch, now)
+				if err != nil {
+					return nil, err
+				}
+				for member := range members {
+					if a.Effect != nil && *a.Effect == zms.DENY {
+						matrix.denied[member] = true
+						continue
+					}
+					if matrix.allowed[member] == nil {
+						matrix.allowed[member] = make(map[string]bool)
+					}
+					matrix.allowed[member][roleName] = true
+				}
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// roleMembers returns the active principals of the role, the members of its groups instead of the groups, and
+// the members of the roles of the trusted domain allowed to assume a delegated role
+func roleMembers(role *zms.Role, fetch func(string) (*zms.DomainData, error), now time.Time) (map[string]bool, error) {
+	principals := make(map[string]bool)
+	if role.Trust != "" {
+		trusted, err := fetch(string(role.Trust))
+		if err != nil {
+			return nil, err
+		}
+		assumed, err := evaluateAccessMatrix(trusted, fetch, ASSUME_ROLE_ACTION, strings.ToLower(string(role.Name)), false, now)
+		if err != nil {
+			return nil, err
+		}
+		for member := range assumed.allowed {
+			if !assumed.denied[member] {
+				principals[member] = true
+			}
+		}
+		return principals, nil
+	}
+	for _, m := range role.RoleMembers {
+		name := strings.ToLower(string(m.MemberName))
+		if !memberActive(m.Active, m.Expiration, m.SystemDisabled, now) {
+			continue
+		}
+		if principalType(name) != MEMBER_TYPE_GROUP {
+			principals[name] = true
+			continue
+		}
+		members, err := groupMembers(name, fetch, now)
+		if err != nil {
+			return nil, err
+		}
+		for member := range members {
+			principals[member] = true
+		}
+	}
+	return principals, nil
+}
+
+// groupMembers returns the active members of the group, from the signed domain of the group
+func groupMembers(name string, fetch func(string) (*zms.DomainData, error), now time.Time) (map[string]bool, error) {
+	data, err := fetch(strings.SplitN(name, GROUP_SEPARATOR, 2)[0])
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool)
+	for _, group := range data.Groups {
+		if !strings.EqualFold(string(group.Name), name) {
+			continue
+		}
+		for _, m := range group.GroupMembers {
+			if memberActive(m.Active, m.Expiration, m.SystemDisabled, now) {
+				members[strings.ToLower(string(m.MemberName))] = true
+			}
+		}
+	}
+	return members, nil
+}
@@ -0,0 +1,124 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceAccessMatrixRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	expired := rdl.NewTimestamp(time.Now().Add(-time.Hour))
+	allow := zms.ALLOW
+	clientMock.EXPECT().GetSignedDomain("some_domain").Return(&zms.DomainData{
+		Name: "some_domain",
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.readers", RoleMembers: []*zms.RoleMember{{MemberName: "other_domain:group.devs"}, {MemberName: "user.joe", Expiration: &expired}}},
+			{Name: "some_domain:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}, {MemberName: "user.intern"}}},
+			{Name: "some_domain:role.partners", Trust: "partner_domain"},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "some_domain:policy.readers", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "read", Role: "some_domain:role.readers", Resource: "some_domain:data.*"},
+				{Effect: &allow, Action: "read", Role: "some_domain:role.partners", Resource: "some_domain:data.shared"},
+			}},
+			{Name: "some_domain:policy.writers", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "*", Role: "some_domain:role.writers", Resource: "some_domain:data.*"},
+			}},
+		}}},
+	}, nil).AnyTimes()
+	clientMock.EXPECT().GetSignedDomain("other_domain").Return(&zms.DomainData{
+		Name: "other_domain",
+		Groups: []*zms.Group{
+			{Name: "other_domain:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.jane"}, {MemberName: "other_domain.api"}}},
+		},
+	}, nil).AnyTimes()
+	clientMock.EXPECT().GetSignedDomain("partner_domain").Return(&zms.DomainData{
+		Name: "partner_domain",
+		Roles: []*zms.Role{
+			{Name: "partner_domain:role.consumers", RoleMembers: []*zms.RoleMember{{MemberName: "partner_domain.reader"}}},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "partner_domain:policy.trust", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "assume_role", Role: "partner_domain:role.consumers", Resource: "some_domain:role.partners"},
+			}},
+		}}},
+	}, nil).AnyTimes()
+
+	matrix := func(action, resource string) *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, DataSourceAccessMatrix().Schema, map[string]interface{}{
+			"domain": "some_domain", "action": action, "resource": resource,
+		})
+		ast.Assert(t, !dataSourceAccessMatrixRead(context.Background(), d, clientMock).HasError())
+		return d
+	}
+
+	// case: the members of the group of another domain, without the expired member
+	d := matrix("read", "data.logs")
+	ast.DeepEqual(t, d.Get("principals"), []interface{}{
+		map[string]interface{}{"name": "other_domain.api", "roles": []interface{}{"some_domain:role.readers"}},
+		map[string]interface{}{"name": "user.intern", "roles": []interface{}{"some_domain:role.writers"}},
+		map[string]interface{}{"name": "user.jane", "roles": []interface{}{"some_domain:role.readers", "some_domain:role.writers"}},
+	})
+	ast.DeepEqual(t, d.Get("denied_principals"), []interface{}{})
+
+	// case: the members of the roles of the trusted domain allowed to assume the delegated role
+	d = matrix("read", "some_domain:data.shared")
+	ast.Equal(t, d.Get("principals.0.name"), "other_domain.api")
+	ast.Equal(t, d.Get("principals.1.name"), "partner_domain.reader")
+	ast.DeepEqual(t, d.Get("principals.1.roles"), []interface{}{"some_domain:role.partners"})
+
+	// case: the access data source expands the groups and the delegated roles the same way
+	access := func(principal, action, resource string) *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, DataSourceAccess().Schema, map[string]interface{}{
+			"domain": "some_domain", "principal": principal, "action": action, "resource": resource,
+		})
+		ast.Assert(t, !dataSourceAccessRead(context.Background(), d, clientMock).HasError())
+		return d
+	}
+	d = access("other_domain.api", "read", "data.logs")
+	ast.Equal(t, d.Get("decision"), ACCESS_ALLOW)
+	ast.DeepEqual(t, d.Get("roles"), []interface{}{"some_domain:role.readers"})
+	d = access("partner_domain.reader", "read", "data.shared")
+	ast.Equal(t, d.Get("decision"), ACCESS_ALLOW)
+	ast.DeepEqual(t, d.Get("roles"), []interface{}{"some_domain:role.partners"})
+	ast.Equal(t, access("user.joe", "read", "data.logs").Get("decision"), ACCESS_DENY_NO_MATCH)
+
+	// case: the access to every resource of the pattern is only allowed by the assertions of the pattern
+	d = matrix("write", "data.*")
+	ast.Equal(t, d.Get("principals.#"), 2)
+	d = matrix("write", "*")
+	ast.Equal(t, d.Get("principals.#"), 0)
+}
+
+func Test_evaluateAccessMatrix_deny(t *testing.T) {
+	allow, deny := zms.ALLOW, zms.DENY
+	data := &zms.DomainData{
+		Name: "some_domain",
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}, {MemberName: "user.intern"}}},
+			{Name: "some_domain:role.interns", RoleMembers: []*zms.RoleMember{{MemberName: "user.intern"}}},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "some_domain:policy.writers", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "*", Role: "some_domain:role.writers", Resource: "some_domain:data.*"},
+				{Effect: &deny, Action: "delete", Role: "some_domain:role.interns", Resource: "some_domain:*"},
+			}},
+		}}},
+	}
+	matrix, err := evaluateAccessMatrix(data, nil, "DELETE", "some_domain:data.logs", true, time.Now())
+	ast.NilError(t, err)
+	ast.DeepEqual(t, matrix.flattenAllowed(), []interface{}{
+		map[string]interface{}{"name": "user.jane", "roles": []string{"some_domain:role.writers"}},
+	})
+	ast.DeepEqual(t, matrix.deniedNames(), []string{"user.intern"})
+}
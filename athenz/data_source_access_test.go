@@ -0,0 +1,77 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceAccessRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	expired := rdl.NewTimestamp(time.Now().Add(-time.Hour))
+	allow, deny := zms.ALLOW, zms.DENY
+	inactive := false
+	clientMock.EXPECT().GetSignedDomain("some_domain").Return(&zms.DomainData{
+		Name: "some_domain",
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.readers", RoleMembers: []*zms.RoleMember{{MemberName: "some_domain:group.devs"}, {MemberName: "other_domain.*"}}},
+			{Name: "some_domain:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}, {MemberName: "user.joe", Expiration: &expired}}},
+			{Name: "some_domain:role.auditors", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}}},
+		},
+		Groups: []*zms.Group{
+			{Name: "some_domain:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.jane"}, {MemberName: "user.joe"}}},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "some_domain:policy.readers", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "read", Role: "some_domain:role.readers", Resource: "some_domain:data.*"},
+			}},
+			{Name: "some_domain:policy.writers", Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "*", Role: "some_domain:role.writers", Resource: "some_domain:data.*"},
+				{Effect: &deny, Action: "delete", Role: "some_domain:role.writers", Resource: "some_domain:data.audit"},
+			}},
+			{Name: "some_domain:policy.auditors", Version: "next", Active: &inactive, Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "delete", Role: "some_domain:role.auditors", Resource: "some_domain:data.audit"},
+			}},
+		}}},
+	}, nil).AnyTimes()
+
+	access := func(principal, action, resource string) *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, DataSourceAccess().Schema, map[string]interface{}{
+			"domain": "some_domain", "principal": principal, "action": action, "resource": resource,
+		})
+		ast.Assert(t, !dataSourceAccessRead(context.Background(), d, clientMock).HasError())
+		return d
+	}
+
+	// case: allowed through the group of the domain
+	d := access("user.joe", "READ", "data.logs")
+	ast.Equal(t, d.Get("allowed"), true)
+	ast.Equal(t, d.Get("decision"), ACCESS_ALLOW)
+	// the membership of user.joe in the writers role expired
+	ast.DeepEqual(t, d.Get("roles"), []interface{}{"some_domain:role.readers"})
+	ast.DeepEqual(t, d.Get("assertion"), []interface{}{map[string]interface{}{
+		"effect": "ALLOW", "action": "read", "role": "some_domain:role.readers", "resource": "some_domain:data.*",
+	}})
+
+	// case: the deny assertion wins, and the policy version allowing it isn't active
+	d = access("user.jane", "delete", "some_domain:data.audit")
+	ast.Equal(t, d.Get("allowed"), false)
+	ast.Equal(t, d.Get("decision"), ACCESS_DENY)
+
+	// case: allowed through a wildcard member, the principal is compared case insensitively
+	ast.Equal(t, access("other_domain.api", "read", "data.logs").Get("decision"), ACCESS_ALLOW)
+	ast.Equal(t, access("Other_Domain.API", "read", "data.logs").Get("decision"), ACCESS_ALLOW)
+
+	// case: no assertion of the roles of the principal matches
+	ast.Equal(t, access("other_domain.api", "write", "data.logs").Get("decision"), ACCESS_DENY_NO_MATCH)
+	ast.Equal(t, access("user.john", "read", "data.logs").Get("decision"), ACCESS_DENY_NO_MATCH)
+}
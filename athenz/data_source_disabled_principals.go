@@ -0,0 +1,171 @@
+package athenz
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the bits of the system disabled state of a member, as the principal states of ZMS
+const (
+	SYSTEM_DISABLED_AUTHORITY_FILTER = 0x01
+	SYSTEM_DISABLED_SUSPENDED        = 0x02
+)
+
+// the reasons of the disabled principals data source, by bit of the system disabled state
+var systemDisabledReasons = []struct {
+	bit    int32
+	reason string
+}{
+	{SYSTEM_DISABLED_AUTHORITY_FILTER, "authority_filter"},
+	{SYSTEM_DISABLED_SUSPENDED, "suspended"},
+}
+
+// DataSourceDisabledPrincipals returns the members of the roles and the groups of a domain that ZMS disabled,
+// from the signed domain, so a cleanup can remove them
+func DataSourceDisabledPrincipals() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDisabledPrincipalsRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateDomainName,
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Description: "The names of the disabled principals, sorted",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"principals": {
+				Type:        schema.TypeList,
+				Description: "The disabled principals, sorted by name",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"reasons": {
+							Type:        schema.TypeList,
+							Description: "suspended by the user authority, authority_filter when it doesn't pass the user authority filter of the role or the group, or disabled for another state",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"roles": {
+							Type:        schema.TypeList,
+							Description: "The roles the principal is disabled in",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"groups": {
+							Type:        schema.TypeList,
+							Description: "The groups the principal is disabled in",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDisabledPrincipalsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
+	dn := d.Get("domain").(string)
+
+	data, err := zmsClient.GetSignedDomain(dn)
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		return zmsDiagnostics(ctx, meta, v, "error retrieving the signed domain "+dn, dn, "the domain "+dn)
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+
+	names, principals := disabledPrincipals(data)
+	d.SetId(dn)
+	if err = d.Set("names", names); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("principals", principals); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+type disabledPrincipal struct {
+	state  int32
+	roles  []string
+	groups []string
+}
+
+func disabledPrincipals(data *zms.DomainData) ([]string, []interface{}) {
+	disabled := make(map[string]*disabledPrincipal)
+	principal := func(name zms.MemberName, systemDisabled *int32) *disabledPrincipal {
+		if systemDisabled == nil || *systemDisabled == 0 {
+			return nil
+		}
+		key := strings.ToLower(string(name))
+		if disabled[key] == nil {
+			disabled[key] = &disabledPrincipal{}
+		}
+		disabled[key].state |= *systemDisabled
+		return disabled[key]
+	}
+	for _, role := range data.Roles {
+		for _, m := range role.RoleMembers {
+			if p := principal(m.MemberName, m.SystemDisabled); p != nil {
+				p.roles = append(p.roles, string(role.Name))
+			}
+		}
+	}
+	for _, group := range data.Groups {
+		for _, m := range group.GroupMembers {
+			if p := principal(zms.MemberName(m.MemberName), m.SystemDisabled); p != nil {
+				p.groups = append(p.groups, string(group.Name))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(disabled))
+	for name := range disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	principals := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		p := disabled[name]
+		sort.Strings(p.roles)
+		sort.Strings(p.groups)
+		principals = append(principals, map[string]interface{}{
+			"name":    name,
+			"reasons": systemDisabledReasonsOf(p.state),
+			"roles":   p.roles,
+			"groups":  p.groups,
+		})
+	}
+	return names, principals
+}
+
+func systemDisabledReasonsOf(state int32) []string {
+	reasons := make([]string, 0, 1)
+	for _, r := range systemDisabledReasons {
+		if state&r.bit != 0 {
+			reasons = append(reasons, r.reason)
+			state &^= r.bit
+		}
+	}
+	if state != 0 {
+		reasons = append(reasons, "disabled")
+	}
+	return reasons
+}
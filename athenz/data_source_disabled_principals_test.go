@@ -0,0 +1,48 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceDisabledPrincipalsRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	filtered, suspended, unknown := int32(SYSTEM_DISABLED_AUTHORITY_FILTER), int32(SYSTEM_DISABLED_SUSPENDED), int32(0x10)
+	enabled := int32(0)
+	clientMock.EXPECT().GetSignedDomain("some_domain").Return(&zms.DomainData{
+		Name: "some_domain",
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "User.Jane", SystemDisabled: &suspended}, {MemberName: "user.joe", SystemDisabled: &enabled}}},
+			{Name: "some_domain:role.admins", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", SystemDisabled: &filtered}, {MemberName: "user.bob"}}},
+		},
+		Groups: []*zms.Group{
+			{Name: "some_domain:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.jane", SystemDisabled: &suspended}, {MemberName: "user.old", SystemDisabled: &unknown}}},
+		},
+	}, nil)
+
+	d := schema.TestResourceDataRaw(t, DataSourceDisabledPrincipals().Schema, map[string]interface{}{"domain": "some_domain"})
+	ast.Assert(t, !dataSourceDisabledPrincipalsRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("names"), []interface{}{"user.jane", "user.old"})
+	ast.DeepEqual(t, d.Get("principals"), []interface{}{
+		map[string]interface{}{
+			"name":    "user.jane",
+			"reasons": []interface{}{"authority_filter", "suspended"},
+			"roles":   []interface{}{"some_domain:role.admins", "some_domain:role.writers"},
+			"groups":  []interface{}{"some_domain:group.devs"},
+		},
+		map[string]interface{}{
+			"name":    "user.old",
+			"reasons": []interface{}{"disabled"},
+			"roles":   []interface{}{},
+			"groups":  []interface{}{"some_domain:group.devs"},
+		},
+	})
+}
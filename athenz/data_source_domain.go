@@ -1,43 +1,48 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourceDomain() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceDomainRead,
+		ReadContext: dataSourceDomainRead,
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			"tags": dataSourceTagsSchema(),
 		},
 	}
 }
 
-func dataSourceDomainRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func dataSourceDomainRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 	domainName := d.Get("name").(string)
 	domain, err := zmsClient.GetDomain(domainName)
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz domain %s not found, update your data source query", domainName)
+			return diag.Errorf("athenz domain %s not found, update your data source query", domainName)
 		} else {
-			return fmt.Errorf("error retrieving Athenz domain: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz domain "+domainName, "", "the domain "+domainName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	if domain == nil {
-		return fmt.Errorf("error retrieving Athenz domain: %s", domainName)
+		return diag.Errorf("error retrieving Athenz domain: %s", domainName)
 	}
 	d.SetId(string(domain.Name))
+	if err = d.Set("tags", flattenTag(domain.Tags)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return nil
 }
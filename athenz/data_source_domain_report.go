@@ -0,0 +1,147 @@
+package athenz
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// DataSourceDomainReport summarizes domains for governance dashboards, from a single signed domain request
+// per domain
+func DataSourceDomainReport() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceDomainReportRead,
+		Schema: map[string]*schema.Schema{
+			"domains": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateDomainName},
+			},
+			"reports": {
+				Type:        schema.TypeList,
+				Description: "The report of each domain, in the order of domains",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name":                   {Type: schema.TypeString, Computed: true},
+						"role_count":             {Type: schema.TypeInt, Computed: true},
+						"group_count":            {Type: schema.TypeInt, Computed: true},
+						"policy_count":           {Type: schema.TypeInt, Computed: true},
+						"service_count":          {Type: schema.TypeInt, Computed: true},
+						"member_count":           {Type: schema.TypeInt, Description: "The distinct members of the roles and the groups", Computed: true},
+						"expired_member_count":   {Type: schema.TypeInt, Description: "The memberships of the roles and the groups that expired", Computed: true},
+						"audit_enabled":          {Type: schema.TypeBool, Computed: true},
+						"audit_enabled_roles":    {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"review_overdue_members": {Type: schema.TypeInt, Description: "The members of the roles whose review date passed", Computed: true},
+						"review_overdue_roles": {
+							Type:        schema.TypeList,
+							Description: "The roles with a member whose review date passed, or not reviewed within their member_review_days",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDomainReportRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
+
+	domains := make([]string, 0)
+	reports := make([]interface{}, 0)
+	now := time.Now()
+	for _, name := range d.Get("domains").([]interface{}) {
+		dn := name.(string)
+		domains = append(domains, dn)
+		data, err := zmsClient.GetSignedDomain(dn)
+		switch v := err.(type) {
+		case rdl.ResourceError:
+			return zmsDiagnostics(ctx, meta, v, "error retrieving the signed domain "+dn, dn, "the domain "+dn)
+		case rdl.Any:
+			return diag.FromErr(err)
+		}
+		reports = append(reports, domainReport(data, now))
+	}
+
+	d.SetId(strings.Join(domains, ","))
+	if err := d.Set("reports", reports); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func domainReport(data *zms.DomainData, now time.Time) map[string]interface{} {
+	members := make(map[string]bool)
+	expired := 0
+	auditEnabledRoles := make([]string, 0)
+	overdueMembers := 0
+	overdueRoles := make([]string, 0)
+	for _, role := range data.Roles {
+		name := string(role.Name)
+		overdue := false
+		for _, m := range role.RoleMembers {
+			members[strings.ToLower(string(m.MemberName))] = true
+			if m.Expiration != nil && m.Expiration.Time.Before(now) {
+				expired++
+			}
+			if m.ReviewReminder != nil && m.ReviewReminder.Time.Before(now) {
+				overdueMembers++
+				overdue = true
+			}
+		}
+		if role.MemberReviewDays != nil && *role.MemberReviewDays > 0 {
+			due := time.Duration(*role.MemberReviewDays) * 24 * time.Hour
+			if role.LastReviewedDate == nil || role.LastReviewedDate.Time.Add(due).Before(now) {
+				overdue = true
+			}
+		}
+		if overdue {
+			overdueRoles = append(overdueRoles, name)
+		}
+		if role.AuditEnabled != nil && *role.AuditEnabled {
+			auditEnabledRoles = append(auditEnabledRoles, name)
+		}
+	}
+	for _, group := range data.Groups {
+		for _, m := range group.GroupMembers {
+			members[strings.ToLower(string(m.MemberName))] = true
+			if m.Expiration != nil && m.Expiration.Time.Before(now) {
+				expired++
+			}
+		}
+	}
+	policies := 0
+	if data.Policies != nil && data.Policies.Contents != nil {
+		for _, policy := range data.Policies.Contents.Policies {
+			if policy.Active == nil || *policy.Active {
+				policies++
+			}
+		}
+	}
+	sort.Strings(auditEnabledRoles)
+	sort.Strings(overdueRoles)
+
+	return map[string]interface{}{
+		"name":                   string(data.Name),
+		"role_count":             len(data.Roles),
+		"group_count":            len(data.Groups),
+		"policy_count":           policies,
+		"service_count":          len(data.Services),
+		"member_count":           len(members),
+		"expired_member_count":   expired,
+		"audit_enabled":          data.AuditEnabled != nil && *data.AuditEnabled,
+		"audit_enabled_roles":    auditEnabledRoles,
+		"review_overdue_members": overdueMembers,
+		"review_overdue_roles":   overdueRoles,
+	}
+}
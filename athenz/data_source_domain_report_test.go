@@ -0,0 +1,81 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceDomainReportRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	past := rdl.NewTimestamp(time.Now().Add(-24 * time.Hour))
+	reviewed := rdl.NewTimestamp(time.Now().Add(-10 * 24 * time.Hour))
+	enabled, inactive := true, false
+	reviewDays, longReviewDays := int32(7), int32(30)
+	clientMock.EXPECT().GetSignedDomain("some_domain").Return(&zms.DomainData{
+		Name:         "some_domain",
+		AuditEnabled: &enabled,
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.admin", AuditEnabled: &enabled, RoleMembers: []*zms.RoleMember{{MemberName: "user.admin"}}},
+			// reviewed 10 days ago, every 7 days
+			{Name: "some_domain:role.writers", MemberReviewDays: &reviewDays, LastReviewedDate: &reviewed, RoleMembers: []*zms.RoleMember{
+				{MemberName: "user.jane", Expiration: &past},
+			}},
+			{Name: "some_domain:role.readers", MemberReviewDays: &longReviewDays, LastReviewedDate: &reviewed, RoleMembers: []*zms.RoleMember{
+				{MemberName: "User.Jane"}, {MemberName: "user.joe", ReviewReminder: &past}, {MemberName: "some_domain:group.devs"},
+			}},
+		},
+		Groups: []*zms.Group{
+			{Name: "some_domain:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.joe"}, {MemberName: "user.john", Expiration: &past}}},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "some_domain:policy.admin"},
+			{Name: "some_domain:policy.readers", Active: &enabled},
+			{Name: "some_domain:policy.readers", Version: "next", Active: &inactive},
+		}}},
+		Services: []*zms.ServiceIdentity{{Name: "some_domain.api"}},
+	}, nil)
+	clientMock.EXPECT().GetSignedDomain("other_domain").Return(&zms.DomainData{Name: "other_domain"}, nil)
+
+	d := schema.TestResourceDataRaw(t, DataSourceDomainReport().Schema, map[string]interface{}{
+		"domains": []interface{}{"some_domain", "other_domain"},
+	})
+	ast.Assert(t, !dataSourceDomainReportRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("reports"), []interface{}{
+		map[string]interface{}{
+			"name":                   "some_domain",
+			"role_count":             3,
+			"group_count":            1,
+			"policy_count":           2,
+			"service_count":          1,
+			"member_count":           5,
+			"expired_member_count":   2,
+			"audit_enabled":          true,
+			"audit_enabled_roles":    []interface{}{"some_domain:role.admin"},
+			"review_overdue_members": 1,
+			"review_overdue_roles":   []interface{}{"some_domain:role.readers", "some_domain:role.writers"},
+		},
+		map[string]interface{}{
+			"name":                   "other_domain",
+			"role_count":             0,
+			"group_count":            0,
+			"policy_count":           0,
+			"service_count":          0,
+			"member_count":           0,
+			"expired_member_count":   0,
+			"audit_enabled":          false,
+			"audit_enabled_roles":    []interface{}{},
+			"review_overdue_members": 0,
+			"review_overdue_roles":   []interface{}{},
+		},
+	})
+}
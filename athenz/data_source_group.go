@@ -1,17 +1,19 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
+	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourceGroup() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceGroupRead,
-		Schema: map[string]*schema.Schema{
+		ReadContext: dataSourceGroupRead,
+		Schema: addMemberFilters(map[string]*schema.Schema{
 			"domain": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -27,12 +29,12 @@ func DataSourceGroup() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Set:      schema.HashString,
 			},
-		},
+		}),
 	}
 }
 
-func dataSourceGroupRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func dataSourceGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 
 	domainName := d.Get("domain").(string)
 	groupName := d.Get("name").(string)
@@ -42,17 +44,24 @@ func dataSourceGroupRead(d *schema.ResourceData, meta interface{}) error {
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz group %s not found, update your data source query", fullResourceName)
+			return diag.Errorf("athenz group %s not found, update your data source query", fullResourceName)
 		} else {
-			return fmt.Errorf("error retrieving Athenz Group: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Group "+fullResourceName, domainName, "the group "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(fullResourceName)
 
-	if len(group.GroupMembers) > 0 {
-		d.Set("members", flattenGroupMember(group.GroupMembers))
+	match := memberFilter(d)
+	groupMembers := make([]*zms.GroupMember, 0, len(group.GroupMembers))
+	for _, m := range group.GroupMembers {
+		if match(string(m.MemberName), m.Expiration) {
+			groupMembers = append(groupMembers, m)
+		}
+	}
+	if len(groupMembers) > 0 {
+		d.Set("members", flattenGroupMember(groupMembers))
 	}
 
 	return nil
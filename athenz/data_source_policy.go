@@ -1,16 +1,17 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourcePolicy() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourcePolicyRead,
+		ReadContext: dataSourcePolicyRead,
 		Schema: map[string]*schema.Schema{
 			"domain": {
 				Type:     schema.TypeString,
@@ -49,8 +50,8 @@ func DataSourcePolicy() *schema.Resource {
 	}
 }
 
-func dataSourcePolicyRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func dataSourcePolicyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 	dn := d.Get("domain").(string)
 	pn := d.Get("name").(string)
 	fullResourceName := dn + POLICY_SEPARATOR + pn
@@ -58,12 +59,12 @@ func dataSourcePolicyRead(d *schema.ResourceData, meta interface{}) error {
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz Policy %s not found, update your data source query", fullResourceName)
+			return diag.Errorf("athenz Policy %s not found, update your data source query", fullResourceName)
 		} else {
-			return fmt.Errorf("error retrieving Athenz Policy: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Policy "+fullResourceName, dn, "the policy "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(fullResourceName)
 	if len(policy.Assertions) > 0 {
@@ -0,0 +1,183 @@
+package athenz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func assertionElem(computed bool) *schema.Resource {
+	field := func(description string) *schema.Schema {
+		return &schema.Schema{Type: schema.TypeString, Description: description, Required: !computed, Computed: computed}
+	}
+	elem := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"effect":   field("ALLOW or DENY"),
+			"action":   field("The action, it may have the * and ? wildcards"),
+			"role":     field("The role name, or <domain>:role.<name>"),
+			"resource": field("The resource name, or <domain>:<name>, it may have the * and ? wildcards"),
+		},
+	}
+	if !computed {
+		elem.Schema["effect"].ValidateFunc = validation.StringInSlice([]string{"ALLOW", "DENY"}, true)
+	}
+	return elem
+}
+
+// DataSourcePolicyDocument composes assertions, e.g. of reusable modules, into the assertions of athenz_policy
+func DataSourcePolicyDocument() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourcePolicyDocumentRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Description:      "The domain of the policy, the roles and the resources of the domain are written without it as in athenz_policy",
+				Optional:         true,
+				ValidateDiagFunc: validateDomainName,
+			},
+			"source_json": {
+				Type:        schema.TypeList,
+				Description: "The json of other documents, their assertions are merged with the assertion blocks",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"assertion": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     assertionElem(false),
+			},
+			"assertions": {
+				Type:        schema.TypeList,
+				Description: "The merged assertions, without duplicates and the assertions covered by a wildcard of another one",
+				Computed:    true,
+				Elem:        assertionElem(true),
+			},
+			"json": {
+				Type:        schema.TypeString,
+				Description: "The json of the document, to use in source_json of another document",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+type policyDocument struct {
+	Assertions []map[string]string `json:"assertions"`
+}
+
+func dataSourcePolicyDocumentRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	configured := make([]interface{}, 0)
+	for i, v := range d.Get("source_json").([]interface{}) {
+		var source policyDocument
+		if err := json.Unmarshal([]byte(v.(string)), &source); err != nil {
+			return diag.Errorf("source_json.%d isn't the json of a policy document: %s", i, err)
+		}
+		for _, a := range source.Assertions {
+			// the effect of the assertion blocks is validated by the schema
+			if effect := strings.ToUpper(a["effect"]); effect != "ALLOW" && effect != "DENY" {
+				return diag.Errorf("source_json.%d has an assertion with the effect %q, expected ALLOW or DENY", i, a["effect"])
+			}
+			configured = append(configured, map[string]interface{}{"effect": a["effect"], "action": a["action"], "role": a["role"], "resource": a["resource"]})
+		}
+	}
+	configured = append(configured, d.Get("assertion").([]interface{})...)
+
+	assertions := mergeAssertions(d.Get("domain").(string), configured)
+	document := policyDocument{Assertions: make([]map[string]string, 0, len(assertions))}
+	for _, v := range assertions {
+		a := v.(map[string]interface{})
+		document.Assertions = append(document.Assertions, map[string]string{
+			"effect": a["effect"].(string), "action": a["action"].(string), "role": a["role"].(string), "resource": a["resource"].(string),
+		})
+	}
+	content, err := json.Marshal(document)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("assertions", assertions); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("json", string(content)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%d", schema.HashString(string(content))))
+	return nil
+}
+
+// mergeAssertions returns the assertions without the duplicates, sorted. ZMS keeps the actions and the
+// resources in lowercase, so they are compared in lowercase. an assertion covered by a wildcard of another
+// one with the same effect and role is dropped. with the domain, the roles and the resources of the domain
+// are returned without it, as athenz_policy reads them
+func mergeAssertions(dn string, configured []interface{}) []interface{} {
+	assertions := make([]*zms.Assertion, 0, len(configured))
+	seen := make(map[string]bool, len(configured))
+	for _, v := range configured {
+		a := v.(map[string]interface{})
+		role := strings.ToLower(a["role"].(string))
+		resource := strings.ToLower(a["resource"].(string))
+		if dn != "" {
+			role = fullName(dn, role, ROLE_SEPARATOR)
+			resource = fullName(dn, resource, RESOURCE_SEPARATOR)
+		}
+		effect := zms.NewAssertionEffect(strings.ToUpper(a["effect"].(string)))
+		assertion := &zms.Assertion{Role: role, Resource: resource, Action: strings.ToLower(a["action"].(string)), Effect: &effect}
+		if key := assertionKey(assertion); !seen[key] {
+			seen[key] = true
+			assertions = append(assertions, assertion)
+		}
+	}
+
+	merged := make([]*zms.Assertion, 0, len(assertions))
+	for i, a := range assertions {
+		covered := false
+		for j, other := range assertions {
+			if i != j && *a.Effect == *other.Effect && a.Role == other.Role && covers(other, a) && !(j > i && covers(a, other)) {
+				// of two assertions covering each other, the first is kept
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			merged = append(merged, a)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return assertionKey(merged[i]) < assertionKey(merged[j]) })
+
+	result := make([]interface{}, 0, len(merged))
+	for _, a := range merged {
+		role, resource := a.Role, a.Resource
+		if dn != "" {
+			// only the names of the domain are written without it, the names of another domain keep it
+			role = shortName(dn, role, ROLE_SEPARATOR)
+			if short := shortName(dn, resource, RESOURCE_SEPARATOR); !strings.Contains(short, RESOURCE_SEPARATOR) {
+				resource = short
+			}
+		}
+		result = append(result, map[string]interface{}{"effect": a.Effect.String(), "action": a.Action, "role": role, "resource": resource})
+	}
+	return result
+}
+
+// covers returns true when the action and the resource of the assertion match the ones of the other assertion
+func covers(assertion *zms.Assertion, other *zms.Assertion) bool {
+	return globMatch(assertion.Action, other.Action) && globMatch(assertion.Resource, other.Resource)
+}
+
+func assertionKey(a *zms.Assertion) string {
+	return strings.Join([]string{a.Role, a.Resource, a.Action, a.Effect.String()}, " ")
+}
+
+// fullName returns the name with the domain, as expandPolicyAssertions does for a name without a separator
+func fullName(dn string, name string, separator string) string {
+	if strings.Contains(name, separator) {
+		return name
+	}
+	return dn + separator + name
+}
@@ -0,0 +1,70 @@
+package athenz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_mergeAssertions(t *testing.T) {
+	assertion := func(effect, action, role, resource string) interface{} {
+		return map[string]interface{}{"effect": effect, "action": action, "role": role, "resource": resource}
+	}
+	configured := []interface{}{
+		assertion("allow", "Read", "readers", "some_domain:data"),
+		// a duplicate of the first one, written differently
+		assertion("ALLOW", "read", "some_domain:role.readers", "data"),
+		// covered by the wildcard action
+		assertion("ALLOW", "write", "writers", "data"),
+		assertion("ALLOW", "*", "writers", "data"),
+		// the effect isn't the same, so it's kept
+		assertion("DENY", "delete", "writers", "data"),
+		assertion("ALLOW", "read", "readers", "other_domain:data"),
+	}
+
+	ast.DeepEqual(t, mergeAssertions("some_domain", configured), []interface{}{
+		// the resource of another domain keeps its domain
+		assertion("ALLOW", "read", "readers", "other_domain:data"),
+		assertion("ALLOW", "read", "readers", "data"),
+		assertion("ALLOW", "*", "writers", "data"),
+		assertion("DENY", "delete", "writers", "data"),
+	})
+	// without the domain, the names are kept as they are written, so only the covered one is dropped
+	ast.Equal(t, len(mergeAssertions("", configured)), 5)
+}
+
+func Test_dataSourcePolicyDocumentRead(t *testing.T) {
+	source := schema.TestResourceDataRaw(t, DataSourcePolicyDocument().Schema, map[string]interface{}{
+		"assertion": []interface{}{map[string]interface{}{"effect": "ALLOW", "action": "read", "role": "readers", "resource": "data"}},
+	})
+	ast.Assert(t, !dataSourcePolicyDocumentRead(context.Background(), source, nil).HasError())
+	ast.Equal(t, source.Get("json"), `{"assertions":[{"action":"read","effect":"ALLOW","resource":"data","role":"readers"}]}`)
+
+	d := schema.TestResourceDataRaw(t, DataSourcePolicyDocument().Schema, map[string]interface{}{
+		"domain":      "some_domain",
+		"source_json": []interface{}{source.Get("json")},
+		"assertion":   []interface{}{map[string]interface{}{"effect": "allow", "action": "read", "role": "readers", "resource": "*"}},
+	})
+	ast.Assert(t, !dataSourcePolicyDocumentRead(context.Background(), d, nil).HasError())
+	// the assertion of the source is covered by the wildcard resource
+	ast.DeepEqual(t, d.Get("assertions"), []interface{}{
+		map[string]interface{}{"effect": "ALLOW", "action": "read", "role": "readers", "resource": "*"},
+	})
+
+	d = schema.TestResourceDataRaw(t, DataSourcePolicyDocument().Schema, map[string]interface{}{
+		"source_json": []interface{}{`{"assertions":[{"effect":"DENI","action":"read","role":"readers","resource":"data"}]}`},
+	})
+	diags := dataSourcePolicyDocumentRead(context.Background(), d, nil)
+	ast.Assert(t, diags.HasError())
+	ast.Assert(t, strings.Contains(diags[0].Summary, "expected ALLOW or DENY"), diags[0].Summary)
+}
+
+func Test_globMatch(t *testing.T) {
+	ast.Assert(t, globMatch("some_domain:*", "some_domain:data.a"))
+	ast.Assert(t, globMatch("re?d", "read"))
+	ast.Assert(t, !globMatch("some_domain:data", "some_domain:data.a"))
+	ast.Assert(t, !globMatch("some.domain:*", "someXdomain:data"))
+}
@@ -0,0 +1,109 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the domain of the users, e.g. user.jane
+var userDomain = strings.TrimSuffix(PREFIX_USER, ".")
+
+func DataSourcePrincipal() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourcePrincipalRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:             schema.TypeString,
+				Description:      "The principal, e.g. user.jane, some_domain.api or some_domain:group.devs",
+				Required:         true,
+				ValidateDiagFunc: validatePrincipalName,
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Description: "The type of the principal: user, service or group",
+				Computed:    true,
+			},
+			"domain": {
+				Type:        schema.TypeString,
+				Description: "The domain of the principal",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// dataSourcePrincipalRead fails when the principal doesn't exist, so a typo in a member or in a principal of an
+// assertion fails the plan of the module using it
+func dataSourcePrincipalRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
+	name := strings.ToLower(d.Get("name").(string))
+	principal := principalType(name)
+
+	var dn, shortName string
+	var err error
+	exists := true
+	switch principal {
+	case MEMBER_TYPE_GROUP:
+		parts := strings.SplitN(name, GROUP_SEPARATOR, 2)
+		dn, shortName = parts[0], parts[1]
+		_, err = zmsClient.GetGroup(dn, shortName)
+	case MEMBER_TYPE_USER:
+		dn = userDomain
+		exists, err = userExists(zmsClient, name)
+	default:
+		i := strings.LastIndex(name, SERVICE_SEPARATOR)
+		if i < 0 {
+			return diag.Errorf("invalid principal %s, expected <domain>.<service>", name)
+		}
+		dn, shortName = name[:i], name[i+1:]
+		_, err = zmsClient.GetServiceIdentity(dn, shortName)
+	}
+	if err != nil && !isNotFound(err) {
+		return zmsDiagnostics(ctx, meta, err, "error verifying the principal "+name, dn, "the "+principal+" "+name)
+	}
+	if err != nil || !exists {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("the %s %s doesn't exist", principal, name),
+			Detail:   "check the principal name",
+		}}
+	}
+
+	d.SetId(name)
+	if err = d.Set("type", principal); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("domain", dn); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// userExists returns true when the user is in the users of ZMS. ZMS knows the users that are members of a role
+// or a group, and checks the others with its user authority when they're added. the roles of the user are
+// looked up first, the list of all the users is read only for a user without roles or that can't be looked up
+func userExists(zmsClient client.ZmsClient, name string) (bool, error) {
+	roles, err := zmsClient.GetPrincipalRoles(name, "")
+	if err == nil && roles != nil && len(roles.MemberRoles) > 0 {
+		return true, nil
+	}
+	if err != nil {
+		log.Printf("[DEBUG] can't look up the roles of the user %s, reading the users of ZMS: %s", name, err)
+	}
+	users, err := zmsClient.GetUserList(userDomain)
+	if err != nil || users == nil {
+		return false, err
+	}
+	for _, user := range users.Names {
+		if strings.EqualFold(string(user), name) || strings.EqualFold(PREFIX_USER+string(user), name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
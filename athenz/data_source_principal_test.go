@@ -0,0 +1,78 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourcePrincipalRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	notFound := rdl.ResourceError{Code: 404, Message: "not found"}
+	clientMock.EXPECT().GetUserList("user").Return(&zms.UserList{Names: []zms.SimpleName{"user.jane", "user.joe"}}, nil).AnyTimes()
+	clientMock.EXPECT().GetPrincipalRoles("user.joe", "").Return(&zms.DomainRoleMember{
+		MemberName: "user.joe", MemberRoles: []*zms.MemberRole{{DomainName: "some_domain", RoleName: "readers"}},
+	}, nil)
+	clientMock.EXPECT().GetPrincipalRoles(gomock.Any(), "").Return(nil, rdl.ResourceError{Code: 403, Message: "forbidden"}).AnyTimes()
+	clientMock.EXPECT().GetServiceIdentity("some_domain", "api").Return(&zms.ServiceIdentity{Name: "some_domain.api"}, nil)
+	clientMock.EXPECT().GetServiceIdentity("some_domain", "apii").Return(nil, notFound)
+	clientMock.EXPECT().GetGroup("some_domain", "devs").Return(&zms.Group{Name: "some_domain:group.devs"}, nil)
+	clientMock.EXPECT().GetServiceIdentity("other_domain.sub", "api").Return(nil, rdl.ResourceError{Code: 403, Message: "forbidden"})
+
+	read := func(name string) (*schema.ResourceData, diag.Diagnostics) {
+		d := schema.TestResourceDataRaw(t, DataSourcePrincipal().Schema, map[string]interface{}{"name": name})
+		return d, dataSourcePrincipalRead(context.Background(), d, clientMock)
+	}
+
+	d, diags := read("User.Jane")
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Id(), "user.jane")
+	ast.Equal(t, d.Get("type"), MEMBER_TYPE_USER)
+	ast.Equal(t, d.Get("domain"), "user")
+
+	// case: the user is found by its roles, without the users of ZMS
+	d, diags = read("user.joe")
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Get("type"), MEMBER_TYPE_USER)
+
+	d, diags = read("some_domain.api")
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Get("type"), MEMBER_TYPE_SERVICE)
+	ast.Equal(t, d.Get("domain"), "some_domain")
+
+	d, diags = read("some_domain:group.devs")
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Get("type"), MEMBER_TYPE_GROUP)
+
+	// case: the principals that don't exist
+	_, diags = read("user.jame")
+	ast.Equal(t, diags[0].Summary, "the user user.jame doesn't exist")
+	_, diags = read("some_domain.apii")
+	ast.Equal(t, diags[0].Summary, "the service some_domain.apii doesn't exist")
+
+	// case: the principal can't be verified
+	_, diags = read("other_domain.sub.api")
+	ast.Assert(t, diags.HasError())
+	ast.Assert(t, diags[0].Summary != "the service other_domain.sub.api doesn't exist")
+}
+
+func Test_dataSourcePrincipalValidate(t *testing.T) {
+	// a user or a service without a domain, and a wildcard, aren't principals
+	for _, name := range []string{"john", "user.*", "some_domain:role.readers"} {
+		diags := DataSourcePrincipal().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{"name": name}))
+		ast.Assert(t, diags.HasError(), name)
+		ast.Equal(t, diags[0].Summary, fmt.Sprintf("invalid principal name %q", name))
+	}
+	ast.Assert(t, !DataSourcePrincipal().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{"name": "user.john"})).HasError())
+}
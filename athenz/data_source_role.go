@@ -1,17 +1,19 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
+	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourceRole() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceRoleRead,
-		Schema: map[string]*schema.Schema{
+		ReadContext: dataSourceRoleRead,
+		Schema: addMemberFilters(map[string]*schema.Schema{
 			"domain": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -27,17 +29,13 @@ func DataSourceRole() *schema.Resource {
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Set:      schema.HashString,
 			},
-			"tags": {
-				Type:     schema.TypeMap,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-			},
-		},
+			"tags": dataSourceTagsSchema(),
+		}),
 	}
 }
 
-func dataSourceRoleRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func dataSourceRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 
 	dn := d.Get("domain").(string)
 	rn := d.Get("name").(string)
@@ -48,17 +46,24 @@ func dataSourceRoleRead(d *schema.ResourceData, meta interface{}) error {
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz Role %s not found, update your data source query", fullResourceName)
+			return diag.Errorf("athenz Role %s not found, update your data source query", fullResourceName)
 		} else {
-			return fmt.Errorf("error retrieving Athenz Role: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(fullResourceName)
 
-	if len(role.RoleMembers) > 0 {
-		d.Set("members", flattenRoleMembers(role.RoleMembers))
+	match := memberFilter(d)
+	roleMembers := make([]*zms.RoleMember, 0, len(role.RoleMembers))
+	for _, m := range role.RoleMembers {
+		if match(string(m.MemberName), m.Expiration) {
+			roleMembers = append(roleMembers, m)
+		}
+	}
+	if len(roleMembers) > 0 {
+		d.Set("members", flattenRoleMembers(roleMembers))
 
 	}
 	if len(role.Tags) > 0 {
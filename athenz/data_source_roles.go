@@ -1,16 +1,17 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourceRoles() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceRolesRead,
+		ReadContext: dataSourceRolesRead,
 		Schema: map[string]*schema.Schema{
 			"domain": {
 				Type:     schema.TypeString,
@@ -48,11 +49,7 @@ func DataSourceRoles() *schema.Resource {
 							Elem:     &schema.Schema{Type: schema.TypeString},
 							Set:      schema.HashString,
 						},
-						"tags": {
-							Type:     schema.TypeMap,
-							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-						},
+						"tags": dataSourceTagsSchema(),
 					},
 				},
 			},
@@ -60,32 +57,32 @@ func DataSourceRoles() *schema.Resource {
 	}
 }
 
-func dataSourceRolesRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func dataSourceRolesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 
 	dn := d.Get("domain").(string)
 	tagKey := d.Get("tag_key").(string)
 	tagValue := d.Get("tag_value").(string)
 	if tagValue != "" && tagKey == "" {
-		return fmt.Errorf("in order to input tag_value, tag_key must be provided")
+		return diag.Errorf("in order to input tag_value, tag_key must be provided")
 	}
 	members := d.Get("include_members").(bool)
 	roles, err := zmsClient.GetRoles(dn, &members, tagKey, tagValue)
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz Roles %s not found, update your data source query", dn+"key: "+tagKey+", value: "+tagValue)
+			return diag.Errorf("athenz Roles %s not found, update your data source query", dn+"key: "+tagKey+", value: "+tagValue)
 		} else {
-			return fmt.Errorf("error retrieving Athenz Role: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving the Athenz Roles of "+dn, dn, "the domain "+dn)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	fullResourceName := dn + "_" + tagKey + "_" + tagValue
 	d.SetId(fullResourceName)
 	if roles != nil && roles.List != nil {
 		if err = d.Set("roles", flattenRoles(roles.List, dn)); err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 	}
 	return nil
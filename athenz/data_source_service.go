@@ -1,16 +1,17 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func dataSourceService() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceServiceRead,
+		ReadContext: dataSourceServiceRead,
 		Schema: map[string]*schema.Schema{
 			"domain": {
 				Type:     schema.TypeString,
@@ -24,24 +25,24 @@ func dataSourceService() *schema.Resource {
 	}
 }
 
-func dataSourceServiceRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(client.ZmsClient)
+func dataSourceServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(client.WithCachedReads(ctx))
 
 	domainName := d.Get("domain").(string)
 	serviceName := d.Get("name").(string)
 	shortServiceName := shortName(domainName, serviceName, SERVICE_SEPARATOR)
 	fullResourceName := domainName + SERVICE_SEPARATOR + shortServiceName
 
-	_, err := client.GetServiceIdentity(domainName, shortServiceName)
+	_, err := zmsClient.GetServiceIdentity(domainName, shortServiceName)
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
-			return fmt.Errorf("athenz Service %s not found, update your data source query", fullResourceName)
+			return diag.Errorf("athenz Service %s not found, update your data source query", fullResourceName)
 		} else {
-			return fmt.Errorf("error retrieving Athenz Service: %s", v)
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Service "+fullResourceName, domainName, "the service "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(fullResourceName)
 
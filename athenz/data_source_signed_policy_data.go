@@ -0,0 +1,108 @@
+package athenz
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zts"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// the formats of the signed policy data, as the policy files of ZPU
+const (
+	POLICY_DATA_FORMAT_JSON = "json"
+	POLICY_DATA_FORMAT_JWS  = "jws"
+)
+
+// DataSourceSignedPolicyData returns the policies of a domain signed by ZTS, the content of the policy file
+// ZPU writes for ZPE
+func DataSourceSignedPolicyData() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSignedPolicyDataRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateDomainName,
+			},
+			"format": {
+				Type:         schema.TypeString,
+				Description:  "json for the signed policy data, or jws for the active policies in the JWS format",
+				Optional:     true,
+				Default:      POLICY_DATA_FORMAT_JSON,
+				ValidateFunc: validation.StringInSlice([]string{POLICY_DATA_FORMAT_JSON, POLICY_DATA_FORMAT_JWS}, false),
+			},
+			"policy_data": {
+				Type:        schema.TypeString,
+				Description: "The content of the policy file",
+				Computed:    true,
+			},
+			"expires": {
+				Type:        schema.TypeString,
+				Description: "The expiration of the signature, ZPE rejects the policy data after it",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceSignedPolicyDataRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn := d.Get("domain").(string)
+
+	var content []byte
+	var expires rdl.Timestamp
+	var err error
+	if d.Get("format").(string) == POLICY_DATA_FORMAT_JWS {
+		var data *zts.JWSPolicyData
+		if data, err = zmsClient.GetJWSPolicyData(dn); err == nil {
+			if content, err = json.Marshal(data); err == nil {
+				expires, err = jwsPolicyDataExpires(data)
+			}
+		}
+	} else {
+		var data *zts.DomainSignedPolicyData
+		if data, err = zmsClient.GetDomainSignedPolicyData(dn); err == nil {
+			if content, err = json.Marshal(data); err == nil && data.SignedPolicyData != nil {
+				expires = data.SignedPolicyData.Expires
+			}
+		}
+	}
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		if v.Code == 404 {
+			return diag.Errorf("athenz Domain %s not found, update your data source query", dn)
+		}
+		return diag.Errorf("error retrieving the signed policy data of the domain %s from ZTS: %s", dn, v)
+	case rdl.Any:
+		return diag.Errorf("error retrieving the signed policy data of the domain %s from ZTS: %s", dn, err)
+	}
+
+	d.SetId(dn)
+	if err = d.Set("policy_data", string(content)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("expires", expires.String()); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// jwsPolicyDataExpires returns the expiration of the signed policy data in the payload of the JWS
+func jwsPolicyDataExpires(data *zts.JWSPolicyData) (rdl.Timestamp, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(data.Payload, "="))
+	if err != nil {
+		return rdl.Timestamp{}, err
+	}
+	var signed zts.SignedPolicyData
+	if err = json.Unmarshal(payload, &signed); err != nil {
+		return rdl.Timestamp{}, err
+	}
+	return signed.Expires, nil
+}
@@ -0,0 +1,43 @@
+package athenz
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zts"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceSignedPolicyDataRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	expires, err := rdl.TimestampParse("2030-01-02T03:04:05.000Z")
+	ast.NilError(t, err)
+	clientMock.EXPECT().GetDomainSignedPolicyData("some_domain").Return(&zts.DomainSignedPolicyData{
+		SignedPolicyData: &zts.SignedPolicyData{PolicyData: &zts.PolicyData{Domain: "some_domain"}, Expires: expires},
+		Signature:        "signature",
+		KeyId:            "0",
+	}, nil)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"policyData":{"domain":"some_domain","policies":[]},"modified":"2021-01-02T03:04:05.000Z","expires":"2030-01-02T03:04:05.000Z"}`))
+	clientMock.EXPECT().GetJWSPolicyData("some_domain").Return(&zts.JWSPolicyData{Payload: payload, Protected: "protected", Signature: "signature"}, nil)
+	clientMock.EXPECT().GetDomainSignedPolicyData("other_domain").Return(nil, rdl.ResourceError{Code: 404, Message: "not found"})
+
+	d := schema.TestResourceDataRaw(t, DataSourceSignedPolicyData().Schema, map[string]interface{}{"domain": "some_domain"})
+	ast.Assert(t, !dataSourceSignedPolicyDataRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("expires"), "2030-01-02T03:04:05.000Z")
+	ast.Equal(t, d.Get("policy_data"), `{"signedPolicyData":{"policyData":{"domain":"some_domain","policies":null},"modified":"","expires":"2030-01-02T03:04:05.000Z"},"signature":"signature","keyId":"0"}`)
+
+	d = schema.TestResourceDataRaw(t, DataSourceSignedPolicyData().Schema, map[string]interface{}{"domain": "some_domain", "format": "jws"})
+	ast.Assert(t, !dataSourceSignedPolicyDataRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("expires"), "2030-01-02T03:04:05.000Z")
+	ast.Equal(t, d.Get("policy_data"), `{"payload":"`+payload+`","protected":"protected","header":null,"signature":"signature"}`)
+
+	d = schema.TestResourceDataRaw(t, DataSourceSignedPolicyData().Schema, map[string]interface{}{"domain": "other_domain"})
+	ast.Assert(t, dataSourceSignedPolicyDataRead(context.Background(), d, clientMock).HasError())
+}
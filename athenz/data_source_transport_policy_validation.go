@@ -0,0 +1,187 @@
+package athenz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/msd"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func transportPolicyPortsSchema(description string, required bool) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		Required:    required,
+		Optional:    !required,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"port": {
+					Type:         schema.TypeInt,
+					Required:     true,
+					ValidateFunc: validation.IntBetween(0, 65535),
+				},
+				"end_port": {
+					Type:         schema.TypeInt,
+					Description:  "The last port of a range of ports, the range is the single port when it's not set",
+					Optional:     true,
+					ValidateFunc: validation.IntBetween(0, 65535),
+				},
+				"protocol": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      msd.TCP.String(),
+					ValidateFunc: validation.StringInSlice(msd.TCP.SymbolSet()[1:], true),
+				},
+			},
+		},
+	}
+}
+
+// DataSourceTransportPolicyValidation validates a microsegmentation rule with MSD, which checks it against the
+// transport policies of the domain (e.g. a conflict with an existing rule or an overlap of ports), without
+// changing them
+func DataSourceTransportPolicyValidation() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceTransportPolicyValidationRead,
+		Schema: map[string]*schema.Schema{
+			"direction": {
+				Type:         schema.TypeString,
+				Description:  "The direction of the traffic: INGRESS to the service, or EGRESS from the service",
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(msd.INGRESS.SymbolSet()[1:], true),
+			},
+			"service": {
+				Type:             schema.TypeString,
+				Description:      "The service the rule applies to, e.g. some_domain.api",
+				Required:         true,
+				ValidateDiagFunc: validateMemberName,
+			},
+			"ports": transportPolicyPortsSchema("The ports of the service", true),
+			"enforcement_state": {
+				Type:         schema.TypeString,
+				Description:  "ENFORCE to block the traffic that isn't allowed, or REPORT to only report it",
+				Optional:     true,
+				Default:      msd.ENFORCE.String(),
+				ValidateFunc: validation.StringInSlice(msd.ENFORCE.SymbolSet()[1:], true),
+			},
+			"instances": {
+				Type:        schema.TypeSet,
+				Description: "The hosts of the service the rule applies to, all of them when it's empty",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"peer_services": {
+				Type:        schema.TypeSet,
+				Description: "The sources of the traffic of an ingress rule, or its destinations of an egress rule",
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateMemberName},
+			},
+			"peer_ports": transportPolicyPortsSchema("The ports of the peer services", false),
+			"fail_on_invalid": {
+				Type:        schema.TypeBool,
+				Description: "Fail the read when MSD rejects the rule, the plan using the data source fails with the errors",
+				Optional:    true,
+				Default:     true,
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Description: "VALID, INVALID, or PARTIAL when only some of the rule is valid",
+				Computed:    true,
+			},
+			"errors": {
+				Type:        schema.TypeList,
+				Description: "Why MSD rejects the rule",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceTransportPolicyValidationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	service := d.Get("service").(string)
+	direction := strings.ToUpper(d.Get("direction").(string))
+
+	response, err := zmsClient.ValidateTransportPolicy(expandTransportPolicyValidationRequest(d))
+	if err != nil {
+		return diag.Errorf("error validating the %s transport policy of the service %s with MSD: %s", strings.ToLower(direction), service, err)
+	}
+	if response == nil {
+		return emptyResponseError("the validation of the transport policy of the service " + service)
+	}
+
+	d.SetId(strings.ToLower(direction) + ":" + service)
+	if err = d.Set("status", response.Status.String()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("errors", response.Errors); err != nil {
+		return diag.FromErr(err)
+	}
+	if response.Status == msd.VALID || !d.Get("fail_on_invalid").(bool) {
+		return nil
+	}
+	// a partially valid rule is reported, it's enforced without its invalid part
+	severity := diag.Error
+	if response.Status == msd.PARTIAL {
+		severity = diag.Warning
+	}
+	return diag.Diagnostics{{
+		Severity: severity,
+		Summary:  "the " + strings.ToLower(direction) + " transport policy of the service " + service + " is " + strings.ToLower(response.Status.String()),
+		Detail:   strings.Join(response.Errors, "\n"),
+	}}
+}
+
+func expandTransportPolicyValidationRequest(d *schema.ResourceData) *msd.TransportPolicyValidationRequest {
+	condition := &msd.TransportPolicyCondition{
+		EnforcementState: msd.NewTransportPolicyEnforcementState(strings.ToUpper(d.Get("enforcement_state").(string))),
+		Instances:        expandStringSet(d.Get("instances").(*schema.Set)),
+	}
+	peers := make([]*msd.TransportPolicySubject, 0)
+	for _, name := range expandStringSet(d.Get("peer_services").(*schema.Set)) {
+		peers = append(peers, expandTransportPolicySubject(name))
+	}
+	return &msd.TransportPolicyValidationRequest{
+		EntitySelector: &msd.TransportPolicyEntitySelector{
+			Match: &msd.TransportPolicyMatch{
+				AthenzService: expandTransportPolicySubject(d.Get("service").(string)),
+				Conditions:    []*msd.TransportPolicyCondition{condition},
+			},
+			Ports: expandTransportPolicyPorts(d.Get("ports").([]interface{})),
+		},
+		Peer: &msd.TransportPolicyPeer{
+			AthenzServices: peers,
+			Ports:          expandTransportPolicyPorts(d.Get("peer_ports").([]interface{})),
+		},
+		TrafficDirection: msd.NewTransportPolicyTrafficDirection(strings.ToUpper(d.Get("direction").(string))),
+	}
+}
+
+func expandTransportPolicySubject(name string) *msd.TransportPolicySubject {
+	dn, sn := splitId(name, SERVICE_SEPARATOR)
+	return &msd.TransportPolicySubject{DomainName: msd.DomainName(dn), ServiceName: msd.EntityName(sn)}
+}
+
+func expandTransportPolicyPorts(ports []interface{}) []*msd.TransportPolicyPort {
+	expanded := make([]*msd.TransportPolicyPort, 0, len(ports))
+	for _, p := range ports {
+		m := p.(map[string]interface{})
+		port := int32(m["port"].(int))
+		endPort := int32(m["end_port"].(int))
+		if endPort == 0 {
+			endPort = port
+		}
+		expanded = append(expanded, &msd.TransportPolicyPort{
+			Port:     port,
+			EndPort:  endPort,
+			Protocol: msd.NewTransportPolicyProtocol(strings.ToUpper(m["protocol"].(string))),
+		})
+	}
+	return expanded
+}
@@ -0,0 +1,75 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/msd"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_dataSourceTransportPolicyValidationRead(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+
+	config := map[string]interface{}{
+		"direction":     "ingress",
+		"service":       "some_domain.api",
+		"ports":         []interface{}{map[string]interface{}{"port": 4443}},
+		"peer_services": []interface{}{"other_domain.client"},
+		"peer_ports":    []interface{}{map[string]interface{}{"port": 1024, "end_port": 65535, "protocol": "tcp"}},
+	}
+	expected := &msd.TransportPolicyValidationRequest{
+		EntitySelector: &msd.TransportPolicyEntitySelector{
+			Match: &msd.TransportPolicyMatch{
+				AthenzService: &msd.TransportPolicySubject{DomainName: "some_domain", ServiceName: "api"},
+				Conditions:    []*msd.TransportPolicyCondition{{EnforcementState: msd.ENFORCE, Instances: []string{}}},
+			},
+			Ports: []*msd.TransportPolicyPort{{Port: 4443, EndPort: 4443, Protocol: msd.TCP}},
+		},
+		Peer: &msd.TransportPolicyPeer{
+			AthenzServices: []*msd.TransportPolicySubject{{DomainName: "other_domain", ServiceName: "client"}},
+			Ports:          []*msd.TransportPolicyPort{{Port: 1024, EndPort: 65535, Protocol: msd.TCP}},
+		},
+		TrafficDirection: msd.INGRESS,
+	}
+	read := func(c map[string]interface{}) (*schema.ResourceData, diag.Diagnostics) {
+		d := schema.TestResourceDataRaw(t, DataSourceTransportPolicyValidation().Schema, c)
+		return d, dataSourceTransportPolicyValidationRead(context.Background(), d, clientMock)
+	}
+
+	// case: a valid rule
+	clientMock.EXPECT().ValidateTransportPolicy(expected).Return(&msd.TransportPolicyValidationResponse{Status: msd.VALID}, nil)
+	d, diags := read(config)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Id(), "ingress:some_domain.api")
+	ast.Equal(t, d.Get("status"), "VALID")
+
+	// case: an invalid rule fails the read with the errors of MSD
+	invalid := &msd.TransportPolicyValidationResponse{Status: msd.INVALID, Errors: []string{"the ports overlap the policy 42"}}
+	clientMock.EXPECT().ValidateTransportPolicy(gomock.Any()).Return(invalid, nil)
+	_, diags = read(config)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, diags[0].Summary, "the ingress transport policy of the service some_domain.api is invalid")
+	ast.Equal(t, diags[0].Detail, "the ports overlap the policy 42")
+
+	// case: the errors are only returned when the read doesn't fail on an invalid rule
+	config["fail_on_invalid"] = false
+	clientMock.EXPECT().ValidateTransportPolicy(gomock.Any()).Return(invalid, nil)
+	d, diags = read(config)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, d.Get("status"), "INVALID")
+	ast.DeepEqual(t, d.Get("errors"), []interface{}{"the ports overlap the policy 42"})
+
+	// case: a partially valid rule is a warning
+	config["fail_on_invalid"] = true
+	clientMock.EXPECT().ValidateTransportPolicy(gomock.Any()).Return(&msd.TransportPolicyValidationResponse{Status: msd.PARTIAL, Errors: []string{"no instance"}}, nil)
+	_, diags = read(config)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, diags[0].Severity, diag.Warning)
+}
@@ -0,0 +1,83 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// zmsDiagnostics returns the diagnostics of a failed ZMS request for an object of the domain dn, e.g. the role
+// some_domain:role.readers, with the likely cause of the error. dn is empty for a top level or a user domain
+func zmsDiagnostics(ctx context.Context, meta interface{}, err error, summary string, dn string, objectName string) diag.Diagnostics {
+	v, ok := err.(rdl.ResourceError)
+	if !ok {
+		return diag.Diagnostics{{Severity: diag.Error, Summary: summary, Detail: err.Error()}}
+	}
+	var detail string
+	switch v.Code {
+	case 400:
+		// the message says what ZMS rejected, e.g. an invalid member name
+		detail = v.Message
+	case 401:
+		detail = fmt.Sprintf("the request isn't authenticated, make sure the cert and the key of the provider are valid and not expired: %s", v.Message)
+	case 403:
+		detail = fmt.Sprintf("%s isn't authorized: %s", principalOf(meta), v.Message)
+		if dn != "" {
+			detail = fmt.Sprintf("%s needs to be an admin of the domain %s, or to be allowed the action by a policy of the domain: %s", principalOf(meta), dn, v.Message)
+		}
+	case 404:
+		detail = fmt.Sprintf("%s doesn't exist: %s", objectName, v.Message)
+		if zmsClient, ok := meta.(client.ZmsClient); ok && dn != "" {
+			if _, err := zmsClient.WithContext(ctx).GetDomain(dn); isNotFound(err) {
+				detail = fmt.Sprintf("the domain %s doesn't exist", dn)
+			}
+		}
+	case 409:
+		detail = fmt.Sprintf("%s was changed at the same time by another request, apply again: %s", objectName, v.Message)
+	default:
+		detail = v.Error()
+	}
+	return diag.Diagnostics{{Severity: diag.Error, Summary: summary, Detail: detail}}
+}
+
+func principalOf(meta interface{}) string {
+	if config, ok := meta.(*providerConfig); ok && config.principal != "" {
+		return "the principal " + config.principal
+	}
+	return "the principal of the provider"
+}
+
+// emptyResponseError is the error of a ZMS response without the requested object
+func emptyResponseError(objectName string) diag.Diagnostics {
+	return diag.Errorf("ZMS returned an empty response for %s", objectName)
+}
+
+// deletionProtectedError is the error of destroying an object while its deletion_protection is set
+func deletionProtectedError(objectName string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("%s is protected from deletion", objectName),
+		Detail:   "set deletion_protection = false and apply before destroying it",
+	}}
+}
+
+// deleteDomainDiagnostics - ZMS refuses to delete a domain that has sub domains or that services depend on,
+// the diagnostics say what to remove first instead of the raw error. parent is empty for a top level domain
+func deleteDomainDiagnostics(ctx context.Context, meta interface{}, err error, summary string, parent string, domainName string) diag.Diagnostics {
+	if v, ok := err.(rdl.ResourceError); ok {
+		message := strings.ToLower(v.Message)
+		switch {
+		case strings.Contains(message, "subdomain"):
+			return diag.Diagnostics{{Severity: diag.Error, Summary: summary,
+				Detail: fmt.Sprintf("the domain %s has sub domains, delete them before the domain: %s", domainName, v.Message)}}
+		case strings.Contains(message, "dependency"):
+			return diag.Diagnostics{{Severity: diag.Error, Summary: summary,
+				Detail: fmt.Sprintf("services depend on the domain %s, remove the dependencies before deleting the domain: %s", domainName, v.Message)}}
+		}
+	}
+	return zmsDiagnostics(ctx, meta, err, summary, parent, "the domain "+domainName)
+}
@@ -0,0 +1,79 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_zmsDiagnostics(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetDomain("some_domain").Return(&zms.Domain{Name: "some_domain"}, nil).AnyTimes()
+	clientMock.EXPECT().GetDomain("missing_domain").Return(nil, rdl.ResourceError{Code: 404, Message: "not found"}).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, principal: "some_domain.deployer"}
+	diagnostics := func(err error, dn string) string {
+		diags := zmsDiagnostics(context.Background(), meta, err, "error creating Athenz Role", dn, "the role "+dn+":role.readers")
+		ast.Equal(t, len(diags), 1)
+		ast.Equal(t, diags[0].Summary, "error creating Athenz Role")
+		return diags[0].Detail
+	}
+
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 400, Message: "Invalid member name"}, "some_domain"), "Invalid member name")
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 403, Message: "Forbidden"}, "some_domain"),
+		"the principal some_domain.deployer needs to be an admin of the domain some_domain, or to be allowed the action by a policy of the domain: Forbidden")
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 404, Message: "Role not found"}, "some_domain"), "the role some_domain:role.readers doesn't exist: Role not found")
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 404, Message: "Domain not found"}, "missing_domain"), "the domain missing_domain doesn't exist")
+	ast.Equal(t, diagnostics(fmt.Errorf("connection refused"), "some_domain"), "connection refused")
+
+	// case: the principal isn't known
+	diags := zmsDiagnostics(context.Background(), clientMock, rdl.ResourceError{Code: 403, Message: "Forbidden"}, "error", "", "the domain some_domain")
+	ast.Equal(t, diags[0].Detail, "the principal of the provider isn't authorized: Forbidden")
+}
+
+func Test_deleteDomainDiagnostics(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	diagnostics := func(err error) string {
+		diags := deleteDomainDiagnostics(context.Background(), clientMock, err, "error deleting Athenz Sub Domain", "some_domain", "some_domain.sub")
+		ast.Equal(t, len(diags), 1)
+		return diags[0].Detail
+	}
+
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 403, Message: "Cannot delete domain some_domain.sub: 2 subdomains of it exist"}),
+		"the domain some_domain.sub has sub domains, delete them before the domain: Cannot delete domain some_domain.sub: 2 subdomains of it exist")
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 403, Message: "Remove domain 'some_domain.sub' dependency from the following service(s): sys.auth.api"}),
+		"services depend on the domain some_domain.sub, remove the dependencies before deleting the domain: Remove domain 'some_domain.sub' dependency from the following service(s): sys.auth.api")
+	ast.Equal(t, diagnostics(rdl.ResourceError{Code: 403, Message: "Forbidden"}),
+		"the principal of the provider needs to be an admin of the domain some_domain, or to be allowed the action by a policy of the domain: Forbidden")
+}
+
+func Test_deletionProtection(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	// no delete request is expected by the mock
+	raw := map[string]interface{}{
+		"domain":              "some_domain",
+		"name":                "readers",
+		"deletion_protection": true,
+	}
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("some_domain:role.readers")
+	diags := resourceRoleDelete(context.Background(), d, clientMock)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, diags[0].Summary, "the role some_domain:role.readers is protected from deletion")
+
+	d = schema.TestResourceDataRaw(t, ResourceGroup().Schema, raw)
+	d.SetId("some_domain:group.readers")
+	ast.Assert(t, resourceGroupDelete(context.Background(), d, clientMock).HasError())
+}
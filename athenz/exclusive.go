@@ -0,0 +1,200 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// exclusiveKind is a kind of object of a domain managed exclusively by a resource, e.g. the roles of
+// athenz_domain_roles
+type exclusiveKind struct {
+	// attribute is the attribute of the names, e.g. roles
+	attribute string
+	// object is the name of an object in the messages, e.g. Role
+	object string
+	// separator is the separator of the full name of an object, e.g. :role.
+	separator string
+	list      func(zmsClient client.ZmsClient, dn string) ([]string, error)
+	delete    func(zmsClient client.ZmsClient, dn string, name string, auditRef string) error
+}
+
+// the object of a domain that is never deleted, as the domain can't be managed without it
+const exclusiveAlwaysKept = "admin"
+
+// exclusiveResource returns a resource declaring the complete set of the objects of a kind in a domain. the
+// objects created outside of the resource are deleted, unless they match the ignored patterns. the
+// objects themselves are created by their own resources, e.g. athenz_role
+func exclusiveResource(kind exclusiveKind) *schema.Resource {
+	unmanaged := "unmanaged_" + kind.attribute
+	ignored := "ignored_" + kind.attribute
+	return &schema.Resource{
+		CreateContext: exclusiveApply(kind),
+		ReadContext:   exclusiveRead(kind),
+		UpdateContext: exclusiveApply(kind),
+		DeleteContext: exclusiveDelete,
+		Timeouts:      resourceTimeouts(),
+		Importer: &schema.ResourceImporter{
+			StateContext: exclusiveImport(kind),
+		},
+
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Description:      "Name of the domain",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			kind.attribute: {
+				Type:        schema.TypeSet,
+				Description: fmt.Sprintf("The names of all the %s of the domain, the others are deleted", kind.attribute),
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateEntityName},
+				Set:         hashCaseInsensitiveString,
+			},
+			ignored: {
+				Type:        schema.TypeSet,
+				Description: fmt.Sprintf("The %s that are kept even if they aren't in %s, they may have the * and ? wildcards", kind.attribute, kind.attribute),
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         hashCaseInsensitiveString,
+			},
+			unmanaged: {
+				Type:        schema.TypeSet,
+				Description: fmt.Sprintf("The %s of the domain in the last read that aren't in %s, the next apply deletes them", kind.attribute, kind.attribute),
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         hashCaseInsensitiveString,
+			},
+			"audit_ref": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  AUDIT_REF,
+			},
+		}),
+	}
+}
+
+// exclusiveUnmanaged returns the existing objects that aren't managed nor ignored, sorted
+func exclusiveUnmanaged(existing []string, managed *schema.Set, ignored *schema.Set) []string {
+	names := make(map[string]bool, managed.Len())
+	for _, v := range managed.List() {
+		names[strings.ToLower(v.(string))] = true
+	}
+	patterns := make([]string, 0, ignored.Len())
+	for _, v := range ignored.List() {
+		patterns = append(patterns, strings.ToLower(v.(string)))
+	}
+	unmanaged := make([]string, 0)
+	for _, name := range existing {
+		name = strings.ToLower(name)
+		if names[name] || name == exclusiveAlwaysKept || matchesAny(patterns, name) {
+			continue
+		}
+		unmanaged = append(unmanaged, name)
+	}
+	sort.Strings(unmanaged)
+	return unmanaged
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if globMatch(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func exclusiveRead(kind exclusiveKind) schema.ReadContextFunc {
+	unmanagedKey := "unmanaged_" + kind.attribute
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+		dn := d.Id()
+
+		existing, err := kind.list(zmsClient, dn)
+		if isNotFound(err) {
+			log.Printf("[WARN] Athenz Domain %s not found, removing the %s of the domain from state", dn, kind.attribute)
+			d.SetId("")
+			return nil
+		}
+		if err != nil {
+			return zmsDiagnostics(ctx, meta, err, fmt.Sprintf("error listing the %s of the domain %s", kind.attribute, dn), dn, "the domain "+dn)
+		}
+
+		// the managed names are the names in the state without the unmanaged ones of the previous read.
+		// they're kept even when they don't exist (yet), they're created by their own resources
+		managed := d.Get(kind.attribute).(*schema.Set).Difference(d.Get(unmanagedKey).(*schema.Set))
+		unmanaged := exclusiveUnmanaged(existing, managed, d.Get("ignored_"+kind.attribute).(*schema.Set))
+		names := managed.List()
+		for _, name := range unmanaged {
+			names = append(names, name)
+		}
+
+		if err = d.Set("domain", dn); err != nil {
+			return diag.FromErr(err)
+		}
+		// the unmanaged names are in the state, so the plan shows their removal
+		if err = d.Set(kind.attribute, names); err != nil {
+			return diag.FromErr(err)
+		}
+		if err = d.Set(unmanagedKey, unmanaged); err != nil {
+			return diag.FromErr(err)
+		}
+		return nil
+	}
+}
+
+// exclusiveApply deletes the objects of the domain that aren't configured nor ignored
+func exclusiveApply(kind exclusiveKind) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+		dn := d.Get("domain").(string)
+		auditRef := d.Get("audit_ref").(string)
+
+		existing, err := kind.list(zmsClient, dn)
+		if err != nil {
+			return zmsDiagnostics(ctx, meta, err, fmt.Sprintf("error listing the %s of the domain %s", kind.attribute, dn), dn, "the domain "+dn)
+		}
+		for _, name := range exclusiveUnmanaged(existing, d.Get(kind.attribute).(*schema.Set), d.Get("ignored_"+kind.attribute).(*schema.Set)) {
+			fullResourceName := dn + kind.separator + name
+			log.Printf("[INFO] deleting the Athenz %s %s, it isn't in the %s of the domain", kind.object, fullResourceName, kind.attribute)
+			if err = kind.delete(zmsClient, dn, name, auditRef); err != nil && !isNotFound(err) {
+				return zmsDiagnostics(ctx, meta, err, fmt.Sprintf("error deleting Athenz %s %s", kind.object, fullResourceName), dn, fmt.Sprintf("the %s %s", strings.ToLower(kind.object), fullResourceName))
+			}
+		}
+		d.SetId(dn)
+		// all the configured names are managed, including the unmanaged ones of the previous read
+		if err = d.Set("unmanaged_"+kind.attribute, []string{}); err != nil {
+			return diag.FromErr(err)
+		}
+		return exclusiveRead(kind)(ctx, d, meta)
+	}
+}
+
+// exclusiveImport sets all the objects of the domain but the admin one as the managed ones
+func exclusiveImport(kind exclusiveKind) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		existing, err := kind.list(meta.(client.ZmsClient).WithContext(ctx), d.Id())
+		if err != nil {
+			return nil, fmt.Errorf("can't list the %s of the domain %s: %s", kind.attribute, d.Id(), err)
+		}
+		if err = d.Set(kind.attribute, exclusiveUnmanaged(existing, schema.NewSet(schema.HashString, nil), schema.NewSet(schema.HashString, nil))); err != nil {
+			return nil, err
+		}
+		return importState(ctx, d, meta)
+	}
+}
+
+// exclusiveDelete removes only the resource, the objects of the domain are kept
+func exclusiveDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	log.Printf("[INFO] %s is removed from the state, the objects of the domain are kept", d.Id())
+	return nil
+}
@@ -0,0 +1,87 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_exclusiveUnmanaged(t *testing.T) {
+	managed := schema.NewSet(hashCaseInsensitiveString, []interface{}{"Readers", "writers"})
+	ignored := schema.NewSet(hashCaseInsensitiveString, []interface{}{"tmp_*"})
+	existing := []string{"admin", "readers", "writers", "tmp_debug", "shadow", "other"}
+	ast.DeepEqual(t, exclusiveUnmanaged(existing, managed, ignored), []string{"other", "shadow"})
+}
+
+func Test_resourceDomainRoles(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	roleList := func(names ...zms.EntityName) {
+		clientMock.EXPECT().GetRoleList("some_domain", gomock.Any(), "").Return(&zms.RoleList{Names: names}, nil)
+	}
+	d := schema.TestResourceDataRaw(t, ResourceDomainRoles().Schema, map[string]interface{}{
+		"domain":        "some_domain",
+		"roles":         []interface{}{"readers", "writers"},
+		"ignored_roles": []interface{}{"tmp_*"},
+	})
+
+	// case: the roles created outside of terraform are deleted, the ignored ones and the admin role are kept
+	roleList("admin", "readers", "shadow", "tmp_debug")
+	clientMock.EXPECT().DeleteRole("some_domain", "shadow", AUDIT_REF).Return(nil)
+	// writers isn't created yet, it's kept in the state
+	roleList("admin", "readers", "tmp_debug")
+	ast.Assert(t, !ResourceDomainRoles().CreateContext(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "some_domain")
+	ast.Equal(t, d.Get("roles").(*schema.Set).Len(), 2)
+	ast.Equal(t, d.Get("unmanaged_roles").(*schema.Set).Len(), 0)
+
+	// case: a role created outside of terraform is in the state, so the plan shows its removal
+	roleList("admin", "readers", "writers", "tmp_debug", "other")
+	ast.Assert(t, !ResourceDomainRoles().ReadContext(context.Background(), d, clientMock).HasError())
+	ast.Assert(t, d.Get("roles").(*schema.Set).Contains("other"))
+	ast.DeepEqual(t, d.Get("unmanaged_roles").(*schema.Set).List(), []interface{}{"other"})
+
+	// case: the role was deleted before the apply, it's removed from the state by the next read
+	roleList("admin", "readers", "writers")
+	ast.Assert(t, !ResourceDomainRoles().ReadContext(context.Background(), d, clientMock).HasError())
+	ast.Assert(t, !d.Get("roles").(*schema.Set).Contains("other"))
+	ast.Equal(t, d.Get("unmanaged_roles").(*schema.Set).Len(), 0)
+
+	// case: the roles of the domain are managed after the import
+	d = ResourceDomainRoles().TestResourceData()
+	d.SetId("some_domain")
+	roleList("admin", "readers", "writers")
+	imported, err := ResourceDomainRoles().Importer.StateContext(context.Background(), d, clientMock)
+	ast.NilError(t, err)
+	roleList("admin", "readers", "writers")
+	ast.Assert(t, !ResourceDomainRoles().ReadContext(context.Background(), imported[0], clientMock).HasError())
+	ast.Equal(t, imported[0].Get("roles").(*schema.Set).Len(), 2)
+	ast.Equal(t, imported[0].Get("unmanaged_roles").(*schema.Set).Len(), 0)
+	ast.Equal(t, imported[0].Get("audit_ref"), AUDIT_REF)
+}
+
+func Test_resourceDomainPolicies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	gomock.InOrder(
+		clientMock.EXPECT().GetPolicyList("some_domain", gomock.Any(), "").Return(&zms.PolicyList{Names: []zms.EntityName{"admin", "readers", "shadow"}}, nil),
+		clientMock.EXPECT().GetPolicyList("some_domain", gomock.Any(), "").Return(&zms.PolicyList{Names: []zms.EntityName{"admin", "readers"}}, nil),
+	)
+	d := schema.TestResourceDataRaw(t, ResourceDomainPolicies().Schema, map[string]interface{}{
+		"domain":   "some_domain",
+		"policies": []interface{}{"readers"},
+	})
+
+	// case: the policy created outside of terraform is deleted, the admin policy is kept
+	clientMock.EXPECT().DeletePolicy("some_domain", "shadow", AUDIT_REF).Return(nil)
+	ast.Assert(t, !ResourceDomainPolicies().CreateContext(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "some_domain")
+	ast.Equal(t, d.Get("unmanaged_policies").(*schema.Set).Len(), 0)
+}
@@ -0,0 +1,121 @@
+package athenz
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the absolute formats accepted for a member expiration / review date, the first one is the format kept in the state
+var memberDateLayouts = []string{MEMBER_DATE_LAYOUT, time.RFC3339, "2006-01-02"}
+
+// a duration like "720h", "90m" or "30d". the days unit isn't supported by time.ParseDuration
+var daysDurationRegex = regexp.MustCompile(`^(\d+)d(.*)$`)
+
+// parseDuration parses a go duration string extended with a days unit, e.g. "30d" or "1d12h"
+func parseDuration(value string) (time.Duration, error) {
+	var days time.Duration
+	if match := daysDurationRegex.FindStringSubmatch(value); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		if match[2] == "" {
+			return days, nil
+		}
+		value = match[2]
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return days + duration, nil
+}
+
+func isDuration(value string) bool {
+	_, err := parseDuration(value)
+	return err == nil
+}
+
+func parseMemberDate(value string) (time.Time, error) {
+	for _, layout := range memberDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration (e.g. 720h, 30d) nor a date in the format %q", value, MEMBER_DATE_LAYOUT)
+}
+
+func validateMemberDate(val interface{}, key string) (ws []string, errs []error) {
+	value := val.(string)
+	if value == "" {
+		return
+	}
+	if duration, err := parseDuration(value); err == nil {
+		if duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s: the duration %q must be positive", key, value))
+		}
+		return
+	}
+	if _, err := parseMemberDate(value); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %s", key, err))
+	}
+	return
+}
+
+// expandMemberDate returns the timestamp of a configured date. a duration is relative to the current time
+func expandMemberDate(value string) *rdl.Timestamp {
+	if value == "" {
+		return nil
+	}
+	var t time.Time
+	if duration, err := parseDuration(value); err == nil {
+		t = time.Now().UTC().Add(duration).Truncate(time.Second)
+	} else if t, err = parseMemberDate(value); err != nil {
+		return nil
+	}
+	timestamp := rdl.NewTimestamp(t)
+	return &timestamp
+}
+
+func flattenMemberDate(timestamp *rdl.Timestamp) string {
+	if timestamp == nil || timestamp.IsZero() {
+		return ""
+	}
+	return timestamp.UTC().Format(MEMBER_DATE_LAYOUT)
+}
+
+// normalizeMemberDate returns a canonical form of a configured date, so the same duration or date
+// written differently (e.g. "30d" and "720h") doesn't show a diff
+func normalizeMemberDate(value string) string {
+	// most members have no dates, and the hash of every member normalizes them
+	if value == "" {
+		return value
+	}
+	if duration, err := parseDuration(value); err == nil {
+		return duration.String()
+	}
+	if t, err := parseMemberDate(value); err == nil {
+		return t.Format(MEMBER_DATE_LAYOUT)
+	}
+	return value
+}
+
+func suppressMemberDateDiff(_, old, new string, _ *schema.ResourceData) bool {
+	return normalizeMemberDate(old) == normalizeMemberDate(new)
+}
+
+// stateMemberDate returns the date of an existing member to keep in the state. a configured duration is kept
+// as is while the member has a date in ZMS, otherwise the date computed on apply would show a diff on every plan.
+// a date that isn't configured is left empty, as it's set by ZMS (e.g. by the member expiry days of the domain)
+func stateMemberDate(stateValue string, timestamp *rdl.Timestamp) string {
+	if timestamp != nil && (stateValue == "" || isDuration(stateValue)) {
+		return stateValue
+	}
+	return flattenMemberDate(timestamp)
+}
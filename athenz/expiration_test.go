@@ -0,0 +1,134 @@
+package athenz
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_parseDuration(t *testing.T) {
+	duration, err := parseDuration("720h")
+	ast.NilError(t, err)
+	ast.Equal(t, duration, 720*time.Hour)
+
+	duration, err = parseDuration("30d")
+	ast.NilError(t, err)
+	ast.Equal(t, duration, 720*time.Hour)
+
+	duration, err = parseDuration("1d12h")
+	ast.NilError(t, err)
+	ast.Equal(t, duration, 36*time.Hour)
+
+	_, err = parseDuration("2022-05-29 23:59:59")
+	ast.Assert(t, err != nil)
+	_, err = parseDuration("d")
+	ast.Assert(t, err != nil)
+}
+
+func Test_validateMemberDate(t *testing.T) {
+	for _, value := range []string{"", "720h", "30d", "2022-05-29 23:59:59", "2022-05-29T23:59:59Z", "2022-05-29"} {
+		_, errs := validateMemberDate(value, "expiration")
+		ast.Equal(t, len(errs), 0, value)
+	}
+	for _, value := range []string{"-1h", "0d", "tomorrow", "29/05/2022"} {
+		_, errs := validateMemberDate(value, "expiration")
+		ast.Equal(t, len(errs), 1, value)
+	}
+}
+
+func Test_expandMemberDate(t *testing.T) {
+	ast.Assert(t, expandMemberDate("") == nil)
+
+	timestamp := expandMemberDate("2022-05-29")
+	ast.Equal(t, timestamp.String(), "2022-05-29T00:00:00.000Z")
+
+	before := time.Now().UTC().Truncate(time.Second)
+	timestamp = expandMemberDate("30d")
+	ast.Assert(t, !timestamp.Before(before.Add(720*time.Hour)))
+	ast.Assert(t, !timestamp.After(time.Now().UTC().Add(720*time.Hour)))
+}
+
+func Test_normalizeMemberDate(t *testing.T) {
+	ast.Equal(t, normalizeMemberDate("30d"), normalizeMemberDate("720h"))
+	ast.Equal(t, normalizeMemberDate("2022-05-29"), "2022-05-29 00:00:00")
+	ast.Equal(t, normalizeMemberDate("2022-05-29T23:59:59Z"), "2022-05-29 23:59:59")
+	ast.Equal(t, normalizeMemberDate(""), "")
+}
+
+func Test_stateMemberDate(t *testing.T) {
+	timestamp, err := rdl.TimestampParse("2022-05-29T23:59:59.000Z")
+	ast.NilError(t, err)
+	ast.Equal(t, stateMemberDate("30d", &timestamp), "30d")
+	ast.Equal(t, stateMemberDate("", &timestamp), "")
+	ast.Equal(t, stateMemberDate("2022-01-01 00:00:00", &timestamp), "2022-05-29 23:59:59")
+	// the date was removed in ZMS
+	ast.Equal(t, stateMemberDate("30d", nil), "")
+}
+
+func Test_flattenRoleMemberObjects(t *testing.T) {
+	timestamp, err := rdl.TimestampParse("2022-05-29T23:59:59.000Z")
+	ast.NilError(t, err)
+	list := []*zms.RoleMember{
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "member1", Expiration: &timestamp}),
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "member2", ReviewReminder: &timestamp}),
+	}
+	stateMembers := []interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "30d", "review": ""},
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "30d", "review": ""},
+		map[string]interface{}{"name": "member2", "expiration": "", "review": "2022-05-29 23:59:59"},
+	}
+	ast.DeepEqual(t, flattenRoleMemberObjects(list, stateMembers), expected)
+
+	roleMembers := expandRoleMembers(expected)
+	ast.Equal(t, len(roleMembers), 2)
+	ast.Assert(t, roleMembers[0].Expiration != nil)
+	ast.Assert(t, roleMembers[0].ReviewReminder == nil)
+	ast.Equal(t, roleMembers[1].ReviewReminder.String(), "2022-05-29T23:59:59.000Z")
+}
+
+func Test_roleMemberDatePlanIsStable(t *testing.T) {
+	member := map[string]interface{}{"name": "user.someone", "expiration": "30d", "review": ""}
+	hash := strconv.Itoa(hashRoleMember(member))
+	state := &terraform.InstanceState{
+		ID: "home.someone:role.test",
+		Attributes: map[string]string{
+			"domain":                         "home.someone",
+			"name":                           "test",
+			"audit_ref":                      AUDIT_REF,
+			"member.#":                       "1",
+			"member." + hash + ".name":       "user.someone",
+			"member." + hash + ".expiration": "30d",
+			"member." + hash + ".review":     "",
+			"unmanaged_members.#":            "0",
+			"pending_members.#":              "0",
+		},
+	}
+
+	// case: the same duration written differently
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"domain": "home.someone",
+		"name":   "test",
+		"member": []interface{}{map[string]interface{}{"name": "user.someone", "expiration": "720h"}},
+	})
+	diff, err := ResourceRole().Diff(context.Background(), state, config, nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || len(diff.Attributes) == 0)
+
+	// case: the duration changed
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"domain": "home.someone",
+		"name":   "test",
+		"member": []interface{}{map[string]interface{}{"name": "user.someone", "expiration": "60d"}},
+	})
+	diff, err = ResourceRole().Diff(context.Background(), state, config, nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff != nil && len(diff.Attributes) > 0)
+}
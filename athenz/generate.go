@@ -0,0 +1,353 @@
+package athenz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// GenerateConfig writes the import blocks and the resources of the domains and their roles, groups, policies
+// and services, fetched with a single signed domain request per domain. with subDomains, the sub domains of
+// the domains are written too. the configuration of a domain is written to <dir>/<domain>.tf, or to w when
+// dir is empty. the client is configured from the same environment variables as the provider, e.g.
+// ATHENZ_ZMS_URL
+func GenerateConfig(w io.Writer, domains []string, subDomains bool, dir string) error {
+	zmsClient, err := environmentClient()
+	if err != nil {
+		return err
+	}
+	if subDomains {
+		if domains, err = withSubDomains(zmsClient, domains); err != nil {
+			return err
+		}
+	}
+	// the resources of all the domains are in the same module, so their labels are prefixed by the domain
+	labels := make(map[string]bool)
+	for _, domain := range domains {
+		labelPrefix := ""
+		if len(domains) > 1 {
+			labelPrefix = resourceLabel(domain) + "_"
+		}
+		out := w
+		if dir != "" {
+			file, err := os.Create(filepath.Join(dir, domain+".tf"))
+			if err != nil {
+				return err
+			}
+			out = file
+			err = writeDomainConfig(out, zmsClient, domain, labels, labelPrefix)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		} else {
+			err = writeDomainConfig(out, zmsClient, domain, labels, labelPrefix)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withSubDomains returns the domains followed by their sub domains, each domain once
+func withSubDomains(zmsClient client.ZmsClient, domains []string) ([]string, error) {
+	all := make([]string, 0, len(domains))
+	seen := make(map[string]bool)
+	for _, domain := range domains {
+		subDomains, err := listDomainNames(zmsClient, domain+SUB_DOMAIN_SEPARATOR)
+		if err != nil {
+			return nil, fmt.Errorf("can't list the sub domains of %s: %s", domain, err)
+		}
+		sort.Strings(subDomains)
+		for _, name := range append([]string{domain}, subDomains...) {
+			if !seen[name] {
+				seen[name] = true
+				all = append(all, name)
+			}
+		}
+	}
+	return all, nil
+}
+
+func environmentClient() (client.ZmsClient, error) {
+	providerSchema := Provider().Schema
+	values := make(map[string]string)
+	for _, key := range []string{"zms_url", "cert", "key", "cacert"} {
+		v, err := providerSchema[key].DefaultValue()
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, fmt.Errorf("the %s of the provider isn't set in the environment", key)
+		}
+		values[key] = v.(string)
+	}
+	return client.NewClient(values["zms_url"], values["cert"], values["key"], values["cacert"])
+}
+
+// writeDomainConfig writes the configuration of the domain, with the labels of its resources unique in
+// labels. the admin role and the admin policy are managed by the domain resource, and the delegated roles
+// can't be managed by athenz_role, so they are written as comments
+func writeDomainConfig(w io.Writer, zmsClient client.ZmsClient, domain string, labels map[string]bool, labelPrefix string) error {
+	data, err := zmsClient.GetSignedDomain(domain)
+	if err != nil {
+		return fmt.Errorf("can't read the signed domain %s: %s", domain, err)
+	}
+	var buf bytes.Buffer
+	writeResource := func(buf *bytes.Buffer, resourceType, name, id string, resource hclBlock) {
+		writeLabeledResource(buf, labels, resourceType, labelPrefix+name, id, resource)
+	}
+
+	writeDomainResource(&buf, labels, data)
+
+	sort.Slice(data.Roles, func(i, j int) bool { return data.Roles[i].Name < data.Roles[j].Name })
+	for _, role := range data.Roles {
+		name := nameAfterSeparator(string(role.Name), ROLE_SEPARATOR)
+		if name == "admin" {
+			continue
+		}
+		if role.Trust != "" {
+			fmt.Fprintf(&buf, "# the role %s is delegated to the domain %s, it isn't managed by athenz_role\n\n", role.Name, role.Trust)
+			continue
+		}
+		resource := hclBlock{attributes: []hclAttribute{{"domain", hclString(domain)}, {"name", hclString(name)}}}
+		if roleMembersHaveDates(role.RoleMembers) {
+			for _, m := range role.RoleMembers {
+				resource.blocks = append(resource.blocks, memberBlock(string(m.MemberName), flattenMemberDate(m.Expiration), flattenMemberDate(m.ReviewReminder)))
+			}
+		} else if len(role.RoleMembers) > 0 {
+			resource.attributes = append(resource.attributes, hclAttribute{"members", hclList(flattenRoleMembers(role.RoleMembers))})
+		}
+		resource.blocks = append(resource.blocks, tagBlocks(role.Tags)...)
+		writeResource(&buf, "athenz_role", name, string(role.Name), resource)
+	}
+
+	sort.Slice(data.Groups, func(i, j int) bool { return data.Groups[i].Name < data.Groups[j].Name })
+	for _, group := range data.Groups {
+		name := nameAfterSeparator(string(group.Name), GROUP_SEPARATOR)
+		resource := hclBlock{attributes: []hclAttribute{{"domain", hclString(domain)}, {"name", hclString(name)}}}
+		if groupMembersHaveDates(group.GroupMembers) {
+			for _, m := range group.GroupMembers {
+				resource.blocks = append(resource.blocks, memberBlock(string(m.MemberName), flattenMemberDate(m.Expiration), ""))
+			}
+		} else if len(group.GroupMembers) > 0 {
+			resource.attributes = append(resource.attributes, hclAttribute{"members", hclList(flattenGroupMember(group.GroupMembers))})
+		}
+		writeResource(&buf, "athenz_group", name, string(group.Name), resource)
+	}
+
+	if data.Policies != nil && data.Policies.Contents != nil {
+		policies := data.Policies.Contents.Policies
+		sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+		for _, policy := range policies {
+			name := nameAfterSeparator(string(policy.Name), POLICY_SEPARATOR)
+			if name == "admin" || (policy.Active != nil && !*policy.Active) {
+				continue
+			}
+			resource := hclBlock{attributes: []hclAttribute{{"domain", hclString(domain)}, {"name", hclString(name)}}}
+			assertions := make([]string, 0, len(policy.Assertions))
+			for _, v := range flattenPolicyAssertion(policy.Assertions) {
+				a := v.(map[string]interface{})
+				assertions = append(assertions, fmt.Sprintf("{ effect = %s, action = %s, role = %s, resource = %s }",
+					hclString(a["effect"].(string)), hclString(a["action"].(string)), hclString(a["role"].(string)), hclString(a["resource"].(string))))
+			}
+			if len(assertions) > 0 {
+				resource.attributes = append(resource.attributes, hclAttribute{"assertion", "[\n    " + strings.Join(assertions, ",\n    ") + ",\n  ]"})
+			}
+			writeResource(&buf, "athenz_policy", name, string(policy.Name), resource)
+		}
+	}
+
+	sort.Slice(data.Services, func(i, j int) bool { return data.Services[i].Name < data.Services[j].Name })
+	for _, service := range data.Services {
+		name := nameAfterSeparator(string(service.Name), domain+SERVICE_SEPARATOR)
+		resource := hclBlock{attributes: []hclAttribute{{"domain", hclString(domain)}, {"name", hclString(name)}}}
+		if service.Description != "" {
+			resource.attributes = append(resource.attributes, hclAttribute{"description", hclString(service.Description)})
+		}
+		for _, key := range service.PublicKeys {
+			resource.blocks = append(resource.blocks, hclBlock{
+				name: "public_keys",
+				attributes: []hclAttribute{
+					{"key_id", hclString(key.Id)},
+					{"key_value", hclHeredoc(convertToDecodedKey(key.Key), "    ")},
+				},
+			})
+		}
+		writeResource(&buf, "athenz_service", name, domain+SERVICE_SEPARATOR+name, resource)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeDomainResource writes the resource of the domain: athenz_user_domain for a domain of a user,
+// athenz_top_level_domain for a domain without a parent, or athenz_sub_domain. its label is the domain name
+func writeDomainResource(buf *bytes.Buffer, labels map[string]bool, data *zms.DomainData) {
+	domain := string(data.Name)
+	var admins []*zms.RoleMember
+	for _, role := range data.Roles {
+		if string(role.Name) == domain+ROLE_SEPARATOR+"admin" {
+			admins = role.RoleMembers
+		}
+	}
+	adminUsers, adminGroups := splitAdminMembers(admins, schema.NewSet(schema.HashString, nil))
+
+	parent, name := splitId(domain, SUB_DOMAIN_SEPARATOR)
+	var resourceType string
+	var resource hclBlock
+	switch {
+	case parent+SUB_DOMAIN_SEPARATOR == PREFIX_USER_DOMAIN:
+		resourceType = "athenz_user_domain"
+		resource.attributes = []hclAttribute{{"name", hclString(name)}}
+	case parent == "":
+		resourceType = "athenz_top_level_domain"
+		resource.attributes = []hclAttribute{{"name", hclString(name)}}
+		if len(adminUsers) > 0 {
+			resource.attributes = append(resource.attributes, hclAttribute{"admin_users", hclList(adminUsers)})
+		}
+		if len(adminGroups) > 0 {
+			resource.attributes = append(resource.attributes, hclAttribute{"admin_groups", hclList(adminGroups)})
+		}
+		var ypmId int32
+		if data.YpmId != nil {
+			ypmId = *data.YpmId
+		}
+		resource.attributes = append(resource.attributes, hclAttribute{"ypm_id", fmt.Sprint(ypmId)})
+	default:
+		resourceType = "athenz_sub_domain"
+		resource.attributes = []hclAttribute{{"parent_name", hclString(parent)}, {"name", hclString(name)}, {"admin_users", hclList(append(adminUsers, adminGroups...))}}
+	}
+	resource.blocks = tagBlocks(data.Tags)
+	writeLabeledResource(buf, labels, resourceType, domain, domain, resource)
+}
+
+// tagBlocks returns the tags blocks, sorted by the key
+func tagBlocks(tags map[zms.CompoundName]*zms.TagValueList) []hclBlock {
+	blocks := make([]hclBlock, 0, len(tags))
+	for _, v := range flattenTag(tags) {
+		tag := v.(map[string]interface{})
+		blocks = append(blocks, hclBlock{name: "tags", attributes: []hclAttribute{
+			{"key", hclString(tag["key"].(string))},
+			{"values", hclList(tag["values"].([]interface{}))},
+		}})
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].attributes[0].value < blocks[j].attributes[0].value })
+	return blocks
+}
+
+func memberBlock(name, expiration, review string) hclBlock {
+	member := hclBlock{name: "member", attributes: []hclAttribute{{"name", hclString(name)}}}
+	if expiration != "" {
+		member.attributes = append(member.attributes, hclAttribute{"expiration", hclString(expiration)})
+	}
+	if review != "" {
+		member.attributes = append(member.attributes, hclAttribute{"review", hclString(review)})
+	}
+	return member
+}
+
+type hclAttribute struct {
+	name  string
+	value string
+}
+
+type hclBlock struct {
+	name       string
+	attributes []hclAttribute
+	blocks     []hclBlock
+}
+
+// writeLabeledResource writes the import block and the resource, its label is the name, unique in the type
+func writeLabeledResource(buf *bytes.Buffer, labels map[string]bool, resourceType, name, id string, resource hclBlock) {
+	label := resourceLabel(name)
+	for i := 2; labels[resourceType+"."+label]; i++ {
+		label = fmt.Sprintf("%s_%d", resourceLabel(name), i)
+	}
+	labels[resourceType+"."+label] = true
+
+	fmt.Fprintf(buf, "import {\n  to = %s.%s\n  id = %s\n}\n\n", resourceType, label, hclString(id))
+	fmt.Fprintf(buf, "resource %q %q {\n", resourceType, label)
+	writeBody(buf, resource, "  ")
+	buf.WriteString("}\n\n")
+}
+
+// writeBody writes the attributes aligned as terraform fmt does, followed by the nested blocks
+func writeBody(buf *bytes.Buffer, block hclBlock, indent string) {
+	width := 0
+	for _, a := range block.attributes {
+		if len(a.name) > width {
+			width = len(a.name)
+		}
+	}
+	for _, a := range block.attributes {
+		fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, a.name, a.value)
+	}
+	for _, nested := range block.blocks {
+		fmt.Fprintf(buf, "\n%s%s {\n", indent, nested.name)
+		writeBody(buf, nested, indent+"  ")
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// resourceLabel returns a terraform identifier of the name, e.g. some.role becomes some_role
+func resourceLabel(name string) string {
+	label := invalidLabelChars.ReplaceAllString(name, "_")
+	if label == "" || (label[0] >= '0' && label[0] <= '9') || label[0] == '-' {
+		label = "_" + label
+	}
+	return label
+}
+
+// hclString returns the quoted HCL string of the value, with the template sequences escaped
+func hclString(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(value[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func hclList(values []interface{}) string {
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		items = append(items, hclString(v.(string)))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// hclHeredoc returns an indented heredoc of a multiline value, e.g. a PEM key
+func hclHeredoc(value string, indent string) string {
+	lines := strings.Split(strings.TrimRight(normalizeKeyValue(value), "\n"), "\n")
+	return "<<-EOT\n" + indent + "  " + strings.Join(lines, "\n"+indent+"  ") + "\n" + indent + "  EOT"
+}
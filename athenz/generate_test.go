@@ -0,0 +1,234 @@
+package athenz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	ast "gotest.tools/assert"
+)
+
+func Test_writeDomainConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	expiration, err := rdl.TimestampParse("2030-01-02T03:04:05.000Z")
+	ast.NilError(t, err)
+	active, inactive := true, false
+	allow := zms.ALLOW
+	ypmId := int32(123)
+	clientMock.EXPECT().GetSignedDomain("some_domain").Return(&zms.DomainData{
+		Name:  "some_domain",
+		YpmId: &ypmId,
+		Tags:  map[zms.CompoundName]*zms.TagValueList{"owner": {List: []zms.TagCompoundValue{"team"}}},
+		Roles: []*zms.Role{
+			{Name: "some_domain:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Expiration: &expiration}}, Tags: map[zms.CompoundName]*zms.TagValueList{
+				"zone": {List: []zms.TagCompoundValue{"us"}},
+				"env":  {List: []zms.TagCompoundValue{"prod", "dev"}},
+			}},
+			{Name: "some_domain:role.admin", RoleMembers: []*zms.RoleMember{{MemberName: "user.admin"}}},
+			{Name: "some_domain:role.readers", RoleMembers: []*zms.RoleMember{{MemberName: "user.joe"}, {MemberName: "some_domain:group.devs"}}},
+			{Name: "some_domain:role.partners", Trust: "other_domain"},
+			{Name: "some_domain:role.read.only"},
+			{Name: "some_domain:role.read_only"},
+		},
+		Groups: []*zms.Group{
+			{Name: "some_domain:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.joe"}}},
+		},
+		Policies: &zms.SignedPolicies{Contents: &zms.DomainPolicies{Policies: []*zms.Policy{
+			{Name: "some_domain:policy.admin"},
+			{Name: "some_domain:policy.readers", Active: &active, Assertions: []*zms.Assertion{
+				{Effect: &allow, Action: "read", Role: "some_domain:role.readers", Resource: "some_domain:data_${env}"},
+			}},
+			{Name: "some_domain:policy.readers", Version: "next", Active: &inactive},
+		}}},
+		Services: []*zms.ServiceIdentity{
+			{Name: "some_domain.api", Description: "the \"api\"", PublicKeys: []*zms.PublicKeyEntry{
+				{Id: "v0", Key: "LS0tLS1CRUdJTiBQVUJMSUMgS0VZLS0tLS0KYWJjCi0tLS0tRU5EIFBVQkxJQyBLRVktLS0tLQo-"},
+			}},
+		},
+	}, nil)
+
+	var buf bytes.Buffer
+	ast.NilError(t, writeDomainConfig(&buf, clientMock, "some_domain", make(map[string]bool), ""))
+	ast.Equal(t, buf.String(), `import {
+  to = athenz_top_level_domain.some_domain
+  id = "some_domain"
+}
+
+resource "athenz_top_level_domain" "some_domain" {
+  name        = "some_domain"
+  admin_users = ["user.admin"]
+  ypm_id      = 123
+
+  tags {
+    key    = "owner"
+    values = ["team"]
+  }
+}
+
+# the role some_domain:role.partners is delegated to the domain other_domain, it isn't managed by athenz_role
+
+import {
+  to = athenz_role.read_only
+  id = "some_domain:role.read.only"
+}
+
+resource "athenz_role" "read_only" {
+  domain = "some_domain"
+  name   = "read.only"
+}
+
+import {
+  to = athenz_role.read_only_2
+  id = "some_domain:role.read_only"
+}
+
+resource "athenz_role" "read_only_2" {
+  domain = "some_domain"
+  name   = "read_only"
+}
+
+import {
+  to = athenz_role.readers
+  id = "some_domain:role.readers"
+}
+
+resource "athenz_role" "readers" {
+  domain  = "some_domain"
+  name    = "readers"
+  members = ["user.joe", "some_domain:group.devs"]
+}
+
+import {
+  to = athenz_role.writers
+  id = "some_domain:role.writers"
+}
+
+resource "athenz_role" "writers" {
+  domain = "some_domain"
+  name   = "writers"
+
+  member {
+    name       = "user.jane"
+    expiration = "2030-01-02 03:04:05"
+  }
+
+  tags {
+    key    = "env"
+    values = ["prod", "dev"]
+  }
+
+  tags {
+    key    = "zone"
+    values = ["us"]
+  }
+}
+
+import {
+  to = athenz_group.devs
+  id = "some_domain:group.devs"
+}
+
+resource "athenz_group" "devs" {
+  domain  = "some_domain"
+  name    = "devs"
+  members = ["user.joe"]
+}
+
+import {
+  to = athenz_policy.readers
+  id = "some_domain:policy.readers"
+}
+
+resource "athenz_policy" "readers" {
+  domain    = "some_domain"
+  name      = "readers"
+  assertion = [
+    { effect = "ALLOW", action = "read", role = "readers", resource = "data_$${env}" },
+  ]
+}
+
+import {
+  to = athenz_service.api
+  id = "some_domain.api"
+}
+
+resource "athenz_service" "api" {
+  domain      = "some_domain"
+  name        = "api"
+  description = "the \"api\""
+
+  public_keys {
+    key_id    = "v0"
+    key_value = <<-EOT
+      -----BEGIN PUBLIC KEY-----
+      abc
+      -----END PUBLIC KEY-----
+      EOT
+  }
+}
+
+`)
+}
+
+func Test_writeDomainConfigDomainResource(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().GetSignedDomain("some_domain.sub").Return(&zms.DomainData{
+		Name: "some_domain.sub",
+		Roles: []*zms.Role{
+			{Name: "some_domain.sub:role.admin", RoleMembers: []*zms.RoleMember{{MemberName: "user.admin"}, {MemberName: "some_domain:group.admins"}}},
+		},
+	}, nil)
+	clientMock.EXPECT().GetSignedDomain("home.jane").Return(&zms.DomainData{Name: "home.jane"}, nil)
+
+	var buf bytes.Buffer
+	labels := make(map[string]bool)
+	ast.NilError(t, writeDomainConfig(&buf, clientMock, "some_domain.sub", labels, "some_domain_sub_"))
+	ast.NilError(t, writeDomainConfig(&buf, clientMock, "home.jane", labels, "home_jane_"))
+	ast.Equal(t, buf.String(), `import {
+  to = athenz_sub_domain.some_domain_sub
+  id = "some_domain.sub"
+}
+
+resource "athenz_sub_domain" "some_domain_sub" {
+  parent_name = "some_domain"
+  name        = "sub"
+  admin_users = ["user.admin", "some_domain:group.admins"]
+}
+
+import {
+  to = athenz_user_domain.home_jane
+  id = "home.jane"
+}
+
+resource "athenz_user_domain" "home_jane" {
+  name = "jane"
+}
+
+`)
+}
+
+func Test_withSubDomains(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().GetDomainList("some_domain.", gomock.Any(), "").Return(&zms.DomainList{
+		Names: []zms.DomainName{"some_domain.sub.b", "some_domain.sub"},
+	}, nil)
+	clientMock.EXPECT().GetDomainList("some_domain.sub.", gomock.Any(), "").Return(&zms.DomainList{
+		Names: []zms.DomainName{"some_domain.sub.b"},
+	}, nil)
+
+	domains, err := withSubDomains(clientMock, []string{"some_domain", "some_domain.sub"})
+	ast.NilError(t, err)
+	ast.DeepEqual(t, domains, []string{"some_domain", "some_domain.sub", "some_domain.sub.b"})
+}
+
+func Test_resourceLabel(t *testing.T) {
+	ast.Equal(t, resourceLabel("some-role"), "some-role")
+	ast.Equal(t, resourceLabel("some.role"), "some_role")
+	ast.Equal(t, resourceLabel("1st"), "_1st")
+}
@@ -0,0 +1,56 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// parseEntityId returns the domain and the name of a role, group or policy ID. the ID is either the ZMS
+// resource name, e.g. some_domain:role.readers, or <domain>/<name>
+func parseEntityId(id, separator string) (string, string, error) {
+	var parts []string
+	if strings.Contains(id, separator) {
+		parts = strings.SplitN(id, separator, 2)
+	} else {
+		parts = strings.SplitN(id, "/", 2)
+	}
+	if len(parts) != 2 || !compoundNameRegex.MatchString(parts[0]) || !compoundNameRegex.MatchString(parts[1]) {
+		return "", "", fmt.Errorf("invalid ID %q, expected <domain>%s<name> or <domain>/<name>", id, separator)
+	}
+	return parts[0], parts[1], nil
+}
+
+// importEntityState accepts the import ID formats of parseEntityId and sets the ID to the ZMS resource name
+func importEntityState(separator string) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		dn, name, err := parseEntityId(d.Id(), separator)
+		if err != nil {
+			return nil, err
+		}
+		d.SetId(dn + separator + name)
+		return importState(ctx, d, meta)
+	}
+}
+
+// importState sets the attributes that have a default in the configuration, as the read doesn't set them.
+// otherwise the configuration generated by terraform plan -generate-config-out has them as null, and the
+// next plan shows them as a change
+func importState(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set("audit_ref", AUDIT_REF); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseDomainObjectId returns the domain and the name of a service or a sub domain ID, where the name is
+// the last part of the ID, e.g. some_domain.api
+func parseDomainObjectId(id, kind string) (string, string, error) {
+	dn, name := splitId(id, SERVICE_SEPARATOR)
+	if !compoundNameRegex.MatchString(dn) || !simpleNameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("invalid %s ID %q, expected <domain>.<name>", kind, id)
+	}
+	return dn, name, nil
+}
@@ -0,0 +1,124 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_parseEntityId(t *testing.T) {
+	dn, rn, err := parseEntityId("some_domain.sub:role.readers", ROLE_SEPARATOR)
+	ast.NilError(t, err)
+	ast.Equal(t, dn, "some_domain.sub")
+	ast.Equal(t, rn, "readers")
+
+	dn, rn, err = parseEntityId("some_domain.sub/readers", ROLE_SEPARATOR)
+	ast.NilError(t, err)
+	ast.Equal(t, dn, "some_domain.sub")
+	ast.Equal(t, rn, "readers")
+
+	for _, id := range []string{"some_domain", "some_domain:group.readers", "some_domain/", "/readers", "some_domain:role.", "a/b/c"} {
+		_, _, err = parseEntityId(id, ROLE_SEPARATOR)
+		ast.Assert(t, err != nil, id)
+	}
+}
+
+func Test_importEntityState(t *testing.T) {
+	d := ResourceGroup().TestResourceData()
+	d.SetId("some_domain/admins")
+	result, err := importEntityState(GROUP_SEPARATOR)(context.Background(), d, nil)
+	ast.NilError(t, err)
+	ast.Equal(t, len(result), 1)
+	ast.Equal(t, result[0].Id(), "some_domain:group.admins")
+	ast.Equal(t, result[0].Get("audit_ref"), AUDIT_REF)
+
+	d.SetId("some_domain:role.admins")
+	_, err = importEntityState(GROUP_SEPARATOR)(context.Background(), d, nil)
+	ast.ErrorContains(t, err, "expected <domain>:group.<name> or <domain>/<name>")
+}
+
+func Test_importState(t *testing.T) {
+	for name, resource := range Provider().ResourcesMap {
+		if name == "athenz_role_member_grant" {
+			// a grant can't be imported, its duration isn't in ZMS
+			ast.Assert(t, resource.Importer == nil)
+			continue
+		}
+		if name == "athenz_domain_roles" || name == "athenz_domain_policies" {
+			// they list the objects of the domain, see Test_resourceDomainRoles
+			continue
+		}
+		d := resource.TestResourceData()
+		d.SetId("some_domain.api")
+		result, err := resource.Importer.StateContext(context.Background(), d, nil)
+		if err != nil {
+			// the roles, groups and policies are imported with the entity IDs
+			d.SetId("some_domain/api")
+			result, err = resource.Importer.StateContext(context.Background(), d, nil)
+		}
+		if err != nil {
+			// the raw objects are imported with their ZMS API paths
+			d.SetId("/domain/some_domain/service/api")
+			result, err = resource.Importer.StateContext(context.Background(), d, nil)
+		}
+		ast.NilError(t, err, name)
+		ast.Equal(t, len(result), 1, name)
+		// the default is in the state, so the generated configuration has it
+		ast.Equal(t, result[0].Get("audit_ref"), AUDIT_REF, name)
+	}
+}
+
+func Test_parseDomainObjectId(t *testing.T) {
+	dn, sn, err := parseDomainObjectId("some_domain.sub.api", "service")
+	ast.NilError(t, err)
+	ast.Equal(t, dn, "some_domain.sub")
+	ast.Equal(t, sn, "api")
+
+	for _, id := range []string{"some_domain", "some_domain.", ".api", "some_domain:role.api"} {
+		_, _, err = parseDomainObjectId(id, "service")
+		ast.ErrorContains(t, err, "expected <domain>.<name>", id)
+	}
+}
+
+func Test_readMalformedId(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	d := ResourceRole().TestResourceData()
+	d.SetId("some_domain")
+	diags := resourceRoleRead(context.Background(), d, clientMock)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, diags[0].Summary, `invalid ID "some_domain", expected <domain>:role.<name> or <domain>/<name>`)
+
+	d = ResourceService().TestResourceData()
+	d.SetId("some_domain")
+	ast.Assert(t, resourceServiceRead(context.Background(), d, clientMock).HasError())
+}
+
+func Test_resourceRoleReadImported(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	expiration := rdl.NewTimestamp(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC))
+	clientMock.EXPECT().GetRole("some_domain", "readers").Return(&zms.Role{
+		Name:        "some_domain:role.readers",
+		RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Expiration: &expiration}},
+	}, nil)
+	d := ResourceRole().TestResourceData()
+	d.SetId("some_domain:role.readers")
+	ast.NilError(t, d.Set("tags", []interface{}{map[string]interface{}{"key": "zms.owner", "values": []interface{}{"someone"}}}))
+
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	// the member is read with its expiration, and the tags removed from the role aren't kept
+	ast.DeepEqual(t, d.Get("member").(*schema.Set).List(), []interface{}{
+		map[string]interface{}{"name": "user.jane", "expiration": "2030-01-02 03:04:05", "review": ""},
+	})
+	ast.Equal(t, d.Get("tags").(*schema.Set).Len(), 0)
+}
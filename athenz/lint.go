@@ -0,0 +1,268 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the modes of the lint: the findings are warnings of the apply, or errors of the plan and the apply
+const (
+	LINT_WARN  = "warn"
+	LINT_ERROR = "error"
+)
+
+// the rules of the lint, named in the findings
+const (
+	LINT_WILDCARD_ASSERTION      = "wildcard_assertion"
+	LINT_ADMIN_WILDCARD_MEMBER   = "admin_wildcard_member"
+	LINT_ADMIN_LARGE_GROUP       = "admin_large_group"
+	LINT_NO_EXPIRY_IN_PRODUCTION = "no_expiry_in_production"
+)
+
+// lintConfig is the lint of the provider, nil when it's disabled
+type lintConfig struct {
+	mode              string
+	productionDomains []string
+	largeGroupMembers int
+}
+
+func lintConfigOf(meta interface{}) *lintConfig {
+	if config, ok := meta.(*providerConfig); ok {
+		return config.lint
+	}
+	return nil
+}
+
+func (c *lintConfig) isProductionDomain(dn string) bool {
+	for _, pattern := range c.productionDomains {
+		if globMatch(pattern, dn) {
+			return true
+		}
+	}
+	return false
+}
+
+type lintFinding struct {
+	rule    string
+	summary string
+}
+
+// lintValues are the values of a resource, planned (a schema.ResourceDiff) or applied (a schema.ResourceData)
+type lintValues interface {
+	Get(key string) interface{}
+}
+
+// a resourceLinter returns the findings of a resource, linted when one of its keys changes
+type resourceLinter struct {
+	keys []string
+	lint func(zmsClient client.ZmsClient, config *lintConfig, values lintValues) []lintFinding
+}
+
+var resourceLinters = map[string]resourceLinter{
+	"athenz_policy": {
+		keys: []string{"domain", "assertion"},
+		lint: func(_ client.ZmsClient, _ *lintConfig, values lintValues) []lintFinding {
+			dn := values.Get("domain").(string)
+			return lintAssertions(expandPolicyAssertions(dn, values.Get("assertion").(*schema.Set).List()))
+		},
+	},
+	"athenz_policy_version": {
+		keys: []string{"domain", "versions"},
+		lint: func(_ client.ZmsClient, _ *lintConfig, values lintValues) []lintFinding {
+			dn := values.Get("domain").(string)
+			var findings []lintFinding
+			for _, v := range values.Get("versions").(*schema.Set).List() {
+				assertions := v.(map[string]interface{})["assertion"].(*schema.Set).List()
+				findings = append(findings, lintAssertions(expandPolicyAssertions(dn, assertions))...)
+			}
+			return findings
+		},
+	},
+	"athenz_role": {
+		keys: []string{"domain", "name", "members", "member"},
+		lint: lintRole,
+	},
+	"athenz_top_level_domain": {
+		keys: []string{"name", "admin_users", "admin_groups"},
+		lint: func(zmsClient client.ZmsClient, config *lintConfig, values lintValues) []lintFinding {
+			members := expandStringSet(values.Get("admin_users").(*schema.Set))
+			members = append(members, expandStringSet(values.Get("admin_groups").(*schema.Set))...)
+			return lintAdminMembers(zmsClient, config, values.Get("name").(string)+ROLE_SEPARATOR+"admin", members)
+		},
+	},
+	"athenz_sub_domain": {
+		keys: []string{"parent_name", "name", "admin_users"},
+		lint: func(zmsClient client.ZmsClient, config *lintConfig, values lintValues) []lintFinding {
+			dn := values.Get("parent_name").(string) + SUB_DOMAIN_SEPARATOR + values.Get("name").(string)
+			return lintAdminMembers(zmsClient, config, dn+ROLE_SEPARATOR+"admin", expandStringSet(values.Get("admin_users").(*schema.Set)))
+		},
+	},
+}
+
+// lintAssertions finds the assertions allowing every action on every resource of a domain
+func lintAssertions(assertions []*zms.Assertion) []lintFinding {
+	var findings []lintFinding
+	for _, a := range assertions {
+		if a.Effect == nil || *a.Effect != zms.ALLOW || a.Action != "*" || nameAfterSeparator(a.Resource, RESOURCE_SEPARATOR) != "*" {
+			continue
+		}
+		findings = append(findings, lintFinding{
+			rule:    LINT_WILDCARD_ASSERTION,
+			summary: fmt.Sprintf("an assertion allows the role %s every action (*) on every resource of %s", a.Role, a.Resource),
+		})
+	}
+	return findings
+}
+
+func lintRole(zmsClient client.ZmsClient, config *lintConfig, values lintValues) []lintFinding {
+	dn, rn := values.Get("domain").(string), values.Get("name").(string)
+	fullRoleName := dn + ROLE_SEPARATOR + rn
+	members := expandStringSet(values.Get("members").(*schema.Set))
+	// the members without a member block have no expiration
+	withoutExpiration := append([]string{}, members...)
+	for _, m := range values.Get("member").(*schema.Set).List() {
+		member := m.(map[string]interface{})
+		members = append(members, member["name"].(string))
+		if member["expiration"].(string) == "" {
+			withoutExpiration = append(withoutExpiration, member["name"].(string))
+		}
+	}
+
+	var findings []lintFinding
+	if rn == "admin" {
+		findings = lintAdminMembers(zmsClient, config, fullRoleName, members)
+	}
+	if len(withoutExpiration) > 0 && config.isProductionDomain(dn) {
+		sort.Strings(withoutExpiration)
+		findings = append(findings, lintFinding{
+			rule:    LINT_NO_EXPIRY_IN_PRODUCTION,
+			summary: fmt.Sprintf("the members %s of the role %s of a production domain have no expiration", strings.Join(withoutExpiration, ", "), fullRoleName),
+		})
+	}
+	return findings
+}
+
+// lintAdminMembers finds the members of an admin role that are wildcards of principals or groups with more
+// members than the large group size. a group that can't be read isn't a finding, the lint doesn't fail the plan
+func lintAdminMembers(zmsClient client.ZmsClient, config *lintConfig, fullRoleName string, members []string) []lintFinding {
+	var findings []lintFinding
+	for _, member := range members {
+		if strings.Contains(member, "*") {
+			findings = append(findings, lintFinding{
+				rule:    LINT_ADMIN_WILDCARD_MEMBER,
+				summary: fmt.Sprintf("the admin role %s has the wildcard member %s, every principal matching it is an admin of the domain", fullRoleName, member),
+			})
+			continue
+		}
+		if !strings.Contains(member, GROUP_SEPARATOR) {
+			continue
+		}
+		parts := strings.SplitN(member, GROUP_SEPARATOR, 2)
+		group, err := zmsClient.GetGroup(parts[0], parts[1])
+		if err != nil || group == nil {
+			log.Printf("[DEBUG] the lint can't read the group %s of the admin role %s: %v", member, fullRoleName, err)
+			continue
+		}
+		if len(group.GroupMembers) > config.largeGroupMembers {
+			findings = append(findings, lintFinding{
+				rule:    LINT_ADMIN_LARGE_GROUP,
+				summary: fmt.Sprintf("the admin role %s has the group %s of %d members, more than %d", fullRoleName, member, len(group.GroupMembers), config.largeGroupMembers),
+			})
+		}
+	}
+	return findings
+}
+
+// withLint lints the resource, if it has a linter, when the lint of the provider is enabled: a plan that changes
+// the linted values fails in the error mode, and the apply of a change returns the findings as warnings in the
+// warn mode, as the plan can't have warnings. the values unknown during the plan are linted by the apply, which
+// fails before the change in the error mode
+func withLint(name string, resource *schema.Resource) *schema.Resource {
+	linter, ok := resourceLinters[name]
+	if !ok {
+		return resource
+	}
+	customizeDiff := resource.CustomizeDiff
+	resource.CustomizeDiff = func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if customizeDiff != nil {
+			if err := customizeDiff(ctx, d, meta); err != nil {
+				return err
+			}
+		}
+		config := lintConfigOf(meta)
+		if config == nil || !lintedKeysChanged(d, linter.keys) {
+			return nil
+		}
+		for _, key := range linter.keys {
+			if !d.NewValueKnown(key) {
+				return nil
+			}
+		}
+		findings := linter.lint(meta.(client.ZmsClient).WithContext(ctx), config, d)
+		if len(findings) == 0 {
+			return nil
+		}
+		if config.mode == LINT_ERROR {
+			summaries := make([]string, 0, len(findings))
+			for _, f := range findings {
+				summaries = append(summaries, fmt.Sprintf("%s (%s)", f.summary, f.rule))
+			}
+			return fmt.Errorf("the lint of the provider found: %s", strings.Join(summaries, "; "))
+		}
+		for _, f := range findings {
+			log.Printf("[WARN] the lint of the provider found in %s: %s (%s)", name, f.summary, f.rule)
+		}
+		return nil
+	}
+	wrap := func(apply func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		if apply == nil {
+			return nil
+		}
+		return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			config := lintConfigOf(meta)
+			if config == nil || (d.Id() != "" && !d.HasChanges(linter.keys...)) {
+				return apply(ctx, d, meta)
+			}
+			findings := linter.lint(meta.(client.ZmsClient).WithContext(ctx), config, d)
+			severity := diag.Warning
+			if config.mode == LINT_ERROR {
+				severity = diag.Error
+			}
+			var diags diag.Diagnostics
+			for _, f := range findings {
+				diags = append(diags, diag.Diagnostic{
+					Severity: severity,
+					Summary:  f.summary,
+					Detail:   fmt.Sprintf("found by the %s rule of the lint of the provider", f.rule),
+				})
+			}
+			if diags.HasError() {
+				return diags
+			}
+			return append(diags, apply(ctx, d, meta)...)
+		}
+	}
+	resource.CreateContext = wrap(resource.CreateContext)
+	resource.UpdateContext = wrap(resource.UpdateContext)
+	return resource
+}
+
+func lintedKeysChanged(d *schema.ResourceDiff, keys []string) bool {
+	if d.Id() == "" {
+		return true
+	}
+	for _, key := range keys {
+		if d.HasChange(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package athenz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_lintPolicy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, lint: &lintConfig{mode: LINT_ERROR, largeGroupMembers: DEFAULT_LINT_LARGE_GROUP_MEMBERS}}
+	config := func(action string, resource string) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"domain": "some_domain",
+			"name":   "test",
+			"assertion": []interface{}{
+				map[string]interface{}{"effect": "ALLOW", "action": action, "role": "readers", "resource": resource},
+			},
+		})
+	}
+
+	_, err := withLint("athenz_policy", ResourcePolicy()).Diff(context.Background(), nil, config("*", "*"), meta)
+	ast.ErrorContains(t, err, "an assertion allows the role some_domain:role.readers every action (*) on every resource of some_domain:* (wildcard_assertion)")
+	_, err = withLint("athenz_policy", ResourcePolicy()).Diff(context.Background(), nil, config("*", "other_domain:*"), meta)
+	ast.ErrorContains(t, err, "wildcard_assertion")
+
+	// case: an action or a resource that isn't the wildcard
+	_, err = withLint("athenz_policy", ResourcePolicy()).Diff(context.Background(), nil, config("read", "*"), meta)
+	ast.NilError(t, err)
+	_, err = withLint("athenz_policy", ResourcePolicy()).Diff(context.Background(), nil, config("*", "data.*"), meta)
+	ast.NilError(t, err)
+
+	// case: the lint isn't enabled
+	_, err = withLint("athenz_policy", ResourcePolicy()).Diff(context.Background(), nil, config("*", "*"), &providerConfig{ZmsClient: clientMock})
+	ast.NilError(t, err)
+}
+
+func Test_lintRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetGroup("some_domain", "everyone").Return(&zms.Group{GroupMembers: make([]*zms.GroupMember, 3)}, nil).AnyTimes()
+	clientMock.EXPECT().GetGroup("some_domain", "admins").Return(&zms.Group{GroupMembers: make([]*zms.GroupMember, 2)}, nil).AnyTimes()
+	lint := &lintConfig{mode: LINT_ERROR, productionDomains: []string{"prod.*"}, largeGroupMembers: 2}
+	meta := &providerConfig{ZmsClient: clientMock, lint: lint}
+	diff := func(values map[string]interface{}) error {
+		_, err := withLint("athenz_role", ResourceRole()).Diff(context.Background(), nil, terraform.NewResourceConfigRaw(values), meta)
+		return err
+	}
+
+	// case: the admin role of a wildcard and of a large group
+	err := diff(map[string]interface{}{"domain": "some_domain", "name": "admin", "members": []interface{}{"user.*", "some_domain:group.everyone", "some_domain:group.admins"}})
+	ast.ErrorContains(t, err, "the admin role some_domain:role.admin has the wildcard member user.*, every principal matching it is an admin of the domain (admin_wildcard_member)")
+	ast.ErrorContains(t, err, "has the group some_domain:group.everyone of 3 members, more than 2 (admin_large_group)")
+	ast.Assert(t, !strings.Contains(err.Error(), "group.admins"))
+
+	// case: the wildcard of another role isn't a finding
+	ast.NilError(t, diff(map[string]interface{}{"domain": "some_domain", "name": "readers", "members": []interface{}{"user.*"}}))
+
+	// case: the members of a production domain without an expiration
+	err = diff(map[string]interface{}{"domain": "prod.api", "name": "readers", "member": []interface{}{
+		map[string]interface{}{"name": "user.joe"},
+		map[string]interface{}{"name": "user.jane", "expiration": "30d"},
+	}})
+	ast.ErrorContains(t, err, "the members user.joe of the role prod.api:role.readers of a production domain have no expiration (no_expiry_in_production)")
+	ast.NilError(t, diff(map[string]interface{}{"domain": "dev.api", "name": "readers", "members": []interface{}{"user.joe"}}))
+}
+
+func Test_withLint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	applied := false
+	resource := withLint("athenz_policy", &schema.Resource{
+		Schema: ResourcePolicy().Schema,
+		CreateContext: func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			applied = true
+			d.SetId("some_domain:policy.test")
+			return nil
+		},
+	})
+	d := resource.TestResourceData()
+	ast.NilError(t, d.Set("domain", "some_domain"))
+	ast.NilError(t, d.Set("assertion", []interface{}{map[string]interface{}{"effect": "ALLOW", "action": "*", "role": "admin", "resource": "*"}}))
+
+	// case: the findings are warnings of the apply in the warn mode
+	diags := resource.CreateContext(context.Background(), d, &providerConfig{ZmsClient: clientMock, lint: &lintConfig{mode: LINT_WARN}})
+	ast.Assert(t, applied)
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, diags[0].Severity, diag.Warning)
+	ast.Equal(t, diags[0].Detail, "found by the wildcard_assertion rule of the lint of the provider")
+
+	// case: the values unknown during the plan fail the apply in the error mode, before the change
+	applied = false
+	d.SetId("")
+	diags = resource.CreateContext(context.Background(), d, &providerConfig{ZmsClient: clientMock, lint: &lintConfig{mode: LINT_ERROR}})
+	ast.Assert(t, !applied)
+	ast.Assert(t, diags.HasError())
+
+	// case: the lint of the plan in the warn mode doesn't fail it
+	_, err := resource.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"domain":    "some_domain",
+		"name":      "test",
+		"assertion": []interface{}{map[string]interface{}{"effect": "ALLOW", "action": "*", "role": "admin", "resource": "*"}},
+	}), &providerConfig{ZmsClient: clientMock, lint: &lintConfig{mode: LINT_WARN}})
+	ast.NilError(t, err)
+}
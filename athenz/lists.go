@@ -0,0 +1,72 @@
+package athenz
+
+import (
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+)
+
+// listPage returns a page of names, and the name to skip to for the next page, empty after the last page
+type listPage func(limit *int32, skip string) ([]zms.EntityName, string, error)
+
+func listAllNames(page listPage) ([]zms.EntityName, error) {
+	limit := LIST_PAGE_SIZE
+	names := make([]zms.EntityName, 0)
+	skip := ""
+	for {
+		pageNames, next, err := page(&limit, skip)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, pageNames...)
+		if next == "" || next == skip {
+			return names, nil
+		}
+		skip = next
+	}
+}
+
+func listRoleNames(zmsClient client.ZmsClient, dn string) ([]zms.EntityName, error) {
+	return listAllNames(func(limit *int32, skip string) ([]zms.EntityName, string, error) {
+		list, err := zmsClient.GetRoleList(dn, limit, skip)
+		if err != nil || list == nil {
+			return nil, "", err
+		}
+		return list.Names, list.Next, nil
+	})
+}
+
+func listPolicyNames(zmsClient client.ZmsClient, dn string) ([]zms.EntityName, error) {
+	return listAllNames(func(limit *int32, skip string) ([]zms.EntityName, string, error) {
+		list, err := zmsClient.GetPolicyList(dn, limit, skip)
+		if err != nil || list == nil {
+			return nil, "", err
+		}
+		return list.Names, list.Next, nil
+	})
+}
+
+func listServiceNames(zmsClient client.ZmsClient, dn string) ([]zms.EntityName, error) {
+	return listAllNames(func(limit *int32, skip string) ([]zms.EntityName, string, error) {
+		list, err := zmsClient.GetServiceIdentityList(dn, limit, skip)
+		if err != nil || list == nil {
+			return nil, "", err
+		}
+		return list.Names, list.Next, nil
+	})
+}
+
+// listDomainNames returns the names of the domains with the prefix, e.g. the sub domains of a domain
+func listDomainNames(zmsClient client.ZmsClient, prefix string) ([]string, error) {
+	names, err := listAllNames(func(limit *int32, skip string) ([]zms.EntityName, string, error) {
+		list, err := zmsClient.GetDomainList(prefix, limit, skip)
+		if err != nil || list == nil {
+			return nil, "", err
+		}
+		pageNames := make([]zms.EntityName, 0, len(list.Names))
+		for _, name := range list.Names {
+			pageNames = append(pageNames, zms.EntityName(name))
+		}
+		return pageNames, list.Next, nil
+	})
+	return convertEntityNameListToStringList(names), err
+}
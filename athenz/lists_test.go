@@ -0,0 +1,38 @@
+package athenz
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	ast "gotest.tools/assert"
+)
+
+func Test_listRoleNames(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	limit := LIST_PAGE_SIZE
+	gomock.InOrder(
+		clientMock.EXPECT().GetRoleList("some_domain", &limit, "").Return(&zms.RoleList{Names: []zms.EntityName{"admin", "readers"}, Next: "readers"}, nil),
+		clientMock.EXPECT().GetRoleList("some_domain", &limit, "readers").Return(&zms.RoleList{Names: []zms.EntityName{"writers"}}, nil),
+	)
+
+	names, err := listRoleNames(clientMock, "some_domain")
+	ast.NilError(t, err)
+	ast.DeepEqual(t, names, []zms.EntityName{"admin", "readers", "writers"})
+}
+
+func Test_listDomainNames(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	limit := LIST_PAGE_SIZE
+	gomock.InOrder(
+		clientMock.EXPECT().GetDomainList("some_domain.", &limit, "").Return(&zms.DomainList{Names: []zms.DomainName{"some_domain.a"}, Next: "some_domain.a"}, nil),
+		clientMock.EXPECT().GetDomainList("some_domain.", &limit, "some_domain.a").Return(&zms.DomainList{Names: []zms.DomainName{"some_domain.b"}}, nil),
+	)
+
+	names, err := listDomainNames(clientMock, "some_domain.")
+	ast.NilError(t, err)
+	ast.DeepEqual(t, names, []string{"some_domain.a", "some_domain.b"})
+}
@@ -0,0 +1,85 @@
+package athenz
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// the principal types of the member_type filter
+const (
+	MEMBER_TYPE_USER    = "user"
+	MEMBER_TYPE_SERVICE = "service"
+	MEMBER_TYPE_GROUP   = "group"
+)
+
+// addMemberFilters adds the filters of the members to the schema of a role or a group data source
+func addMemberFilters(dataSourceSchema map[string]*schema.Schema) map[string]*schema.Schema {
+	dataSourceSchema["member_type"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  "Return only the members of the type: user, service or group",
+		Optional:     true,
+		ValidateFunc: validation.StringInSlice([]string{MEMBER_TYPE_USER, MEMBER_TYPE_SERVICE, MEMBER_TYPE_GROUP}, false),
+	}
+	dataSourceSchema["member_name_pattern"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  "Return only the members whose name matches the glob pattern, e.g. some_domain.*",
+		Optional:     true,
+		ValidateFunc: validateGlobPattern,
+	}
+	dataSourceSchema["expiring_within"] = &schema.Schema{
+		Type:             schema.TypeString,
+		Description:      "Return only the members whose membership expires within the duration (e.g. 30d), including the expired ones",
+		Optional:         true,
+		ValidateDiagFunc: validateDuration,
+	}
+	return dataSourceSchema
+}
+
+// memberFilter returns a function that returns true for the members matching the filters of the data source
+func memberFilter(d *schema.ResourceData) func(name string, expiration *rdl.Timestamp) bool {
+	memberType := d.Get("member_type").(string)
+	pattern := strings.ToLower(d.Get("member_name_pattern").(string))
+	var deadline time.Time
+	if window, err := parseDuration(d.Get("expiring_within").(string)); err == nil {
+		deadline = time.Now().Add(window)
+	}
+	return func(name string, expiration *rdl.Timestamp) bool {
+		if memberType != "" && principalType(name) != memberType {
+			return false
+		}
+		if pattern != "" {
+			// the pattern is validated, so it can't fail
+			if matched, _ := path.Match(pattern, strings.ToLower(name)); !matched {
+				return false
+			}
+		}
+		if !deadline.IsZero() && (expiration == nil || expiration.Time.After(deadline)) {
+			return false
+		}
+		return true
+	}
+}
+
+func principalType(name string) string {
+	switch {
+	case strings.Contains(name, GROUP_SEPARATOR):
+		return MEMBER_TYPE_GROUP
+	case strings.HasPrefix(name, PREFIX_USER):
+		return MEMBER_TYPE_USER
+	default:
+		return MEMBER_TYPE_SERVICE
+	}
+}
+
+func validateGlobPattern(val interface{}, key string) (ws []string, errs []error) {
+	if _, err := path.Match(val.(string), ""); err != nil {
+		errs = append(errs, fmt.Errorf("%s is an invalid glob pattern %q: %s", key, val, err))
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_principalType(t *testing.T) {
+	ast.Equal(t, principalType("user.jane"), MEMBER_TYPE_USER)
+	ast.Equal(t, principalType("some_domain.api"), MEMBER_TYPE_SERVICE)
+	ast.Equal(t, principalType("some_domain:group.devs"), MEMBER_TYPE_GROUP)
+}
+
+func Test_dataSourceRoleReadMemberFilters(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	soon := rdl.NewTimestamp(time.Now().Add(24 * time.Hour))
+	later := rdl.NewTimestamp(time.Now().Add(90 * 24 * time.Hour))
+	clientMock.EXPECT().GetRole("some_domain", "readers").Return(&zms.Role{
+		Name: "some_domain:role.readers",
+		RoleMembers: []*zms.RoleMember{
+			{MemberName: "user.jane", Expiration: &soon},
+			{MemberName: "user.joe", Expiration: &later},
+			{MemberName: "user.john"},
+			{MemberName: "some_domain.api", Expiration: &soon},
+			{MemberName: "some_domain:group.devs"},
+		},
+	}, nil).AnyTimes()
+
+	members := func(filters map[string]interface{}) []interface{} {
+		filters["domain"] = "some_domain"
+		filters["name"] = "readers"
+		d := schema.TestResourceDataRaw(t, DataSourceRole().Schema, filters)
+		ast.Assert(t, !dataSourceRoleRead(context.Background(), d, clientMock).HasError())
+		return d.Get("members").(*schema.Set).List()
+	}
+
+	// case: the members of a type
+	ast.DeepEqual(t, members(map[string]interface{}{"member_type": "group"}), []interface{}{"some_domain:group.devs"})
+	// case: the members whose name matches the pattern
+	ast.DeepEqual(t, members(map[string]interface{}{"member_name_pattern": "some_domain.*"}), []interface{}{"some_domain.api"})
+	// case: the users whose membership expires within 30 days
+	ast.DeepEqual(t, members(map[string]interface{}{"member_type": "user", "expiring_within": "30d"}), []interface{}{"user.jane"})
+}
+
+func Test_validateGlobPattern(t *testing.T) {
+	_, errs := validateGlobPattern("user.*", "member_name_pattern")
+	ast.Equal(t, len(errs), 0)
+	_, errs = validateGlobPattern("user.[", "member_name_pattern")
+	ast.Equal(t, len(errs), 1)
+}
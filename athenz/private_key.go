@@ -0,0 +1,112 @@
+package athenz
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+// the prefix of the value of a private key in the state, which is its SHA-256 and not the key
+const privateKeyHashPrefix = "sha256:"
+
+// hashPrivateKey is the state function of a private key, so the state only has its hash. the hash of a key
+// is still compared with the one of the configuration, a new key is planned as a change
+func hashPrivateKey(v interface{}) string {
+	keyValue := normalizeKeyValue(v.(string))
+	if keyValue == "" || isPrivateKeyHash(keyValue) {
+		return keyValue
+	}
+	sum := sha256.Sum256([]byte(keyValue))
+	return privateKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// isPrivateKeyHash returns true for a value read from the state, whose key isn't known
+func isPrivateKeyHash(keyValue string) bool {
+	return strings.HasPrefix(keyValue, privateKeyHashPrefix)
+}
+
+func validatePrivateKey(val interface{}, key string) (ws []string, errs []error) {
+	if _, err := derivePublicKey(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %s", key, err))
+	}
+	return
+}
+
+// derivePublicKey returns the PEM encoded public key of a PEM encoded RSA or EC private key, in PKCS #1,
+// SEC 1 or PKCS #8 (e.g. the private_key_pem of tls_private_key)
+func derivePublicKey(privateKey string) (string, error) {
+	block, _ := pem.Decode([]byte(normalizeKeyValue(privateKey)))
+	if block == nil {
+		return "", fmt.Errorf("must be a PEM encoded private key")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return "", fmt.Errorf("unsupported PEM block %s, expected an RSA or EC private key", block.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't parse the private key: %s", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", fmt.Errorf("unsupported private key type %T", key)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", fmt.Errorf("can't encode the public key: %s", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})), nil
+}
+
+// derivePublicKeys returns the public keys of the private keys, as the elements of public_keys. a private key
+// read from the state has only its hash, its public key is the one registered in ZMS for the hash
+func derivePublicKeys(privateKeys []interface{}, registered map[string]string) ([]interface{}, error) {
+	publicKeys := make([]interface{}, 0, len(privateKeys))
+	for _, val := range privateKeys {
+		m := val.(map[string]interface{})
+		keyId := m["key_id"].(string)
+		keyValue := m["key_value"].(string)
+		var publicKey string
+		if isPrivateKeyHash(keyValue) {
+			var ok bool
+			if publicKey, ok = registered[keyValue]; !ok {
+				return nil, fmt.Errorf("the public key of the private key %s isn't in ZMS, refresh the service to register it again", keyId)
+			}
+		} else {
+			var err error
+			if publicKey, err = derivePublicKey(keyValue); err != nil {
+				return nil, fmt.Errorf("private key %s %s", keyId, err)
+			}
+		}
+		publicKeys = append(publicKeys, map[string]interface{}{"key_id": keyId, "key_value": publicKey})
+	}
+	return publicKeys, nil
+}
+
+// registeredPrivateKeys returns the public keys registered in ZMS for the private keys of the state, by the
+// hash of the private key
+func registeredPrivateKeys(privateKeys []interface{}, service *zms.ServiceIdentity) map[string]string {
+	registered := make(map[string]string, len(privateKeys))
+	for _, val := range privateKeys {
+		m := val.(map[string]interface{})
+		for _, key := range service.PublicKeys {
+			if key.Id == m["key_id"].(string) {
+				registered[m["key_value"].(string)] = convertToDecodedKey(key.Key)
+			}
+		}
+	}
+	return registered
+}
@@ -1,22 +1,44 @@
 package athenz
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // Provider returns a terraform.ResourceProvider.
 func Provider() *schema.Provider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"zms_url": {
-				Type:        schema.TypeString,
-				Description: fmt.Sprintf("Athenz API URL"),
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_ZMS_URL", nil),
+				Type:             schema.TypeString,
+				Description:      fmt.Sprintf("Athenz API URL"),
+				Required:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("ATHENZ_ZMS_URL", nil),
+				ValidateDiagFunc: validateZmsUrl,
+			},
+			"zts_url": {
+				Type:             schema.TypeString,
+				Description:      "Athenz ZTS API URL, required only by athenz_signed_policy_data",
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("ATHENZ_ZTS_URL", ""),
+				ValidateDiagFunc: validateZtsUrl,
+			},
+			"msd_url": {
+				Type:             schema.TypeString,
+				Description:      "Athenz MSD API URL, required only by athenz_transport_policy_validation",
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("ATHENZ_MSD_URL", ""),
+				ValidateDiagFunc: validateMsdUrl,
 			},
 			"cert": {
 				Type:        schema.TypeString,
@@ -36,41 +58,313 @@ func Provider() *schema.Provider {
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_CA_CERT", ""),
 			},
+			"max_connections": {
+				Type:         schema.TypeInt,
+				Description:  "The number of connections to ZMS kept open for reuse by the parallel requests, 16 by default",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ATHENZ_MAX_CONNECTIONS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"disable_http2": {
+				Type:        schema.TypeBool,
+				Description: "Use HTTP/1.1 instead of HTTP/2 for the requests to ZMS",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_DISABLE_HTTP2", false),
+			},
+			"data_source_cache_ttl": {
+				Type:             schema.TypeString,
+				Description:      "Keep the reads of the data sources for the duration (e.g. 10m), so the plans within it don't repeat them",
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("ATHENZ_DATA_SOURCE_CACHE_TTL", ""),
+				ValidateDiagFunc: validateDuration,
+			},
+			"data_source_cache_dir": {
+				Type:        schema.TypeString,
+				Description: "The directory of the data_source_cache_ttl cache, a directory in the cache directory of the user by default",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_DATA_SOURCE_CACHE_DIR", ""),
+			},
+			"verify_connection": {
+				Type:        schema.TypeBool,
+				Description: "Verify during the provider configuration that ZMS is reachable and accepts the cert",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_VERIFY_CONNECTION", false),
+			},
+			"verify_references": {
+				Type:        schema.TypeBool,
+				Description: "Verify during the plan that the domains and the roles referenced by new resources exist",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_VERIFY_REFERENCES", false),
+			},
+			"check_references_on_delete": {
+				Type:        schema.TypeBool,
+				Description: "Don't delete a role that is referenced by assertions of policies in its domain, unless its force_delete is set",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_CHECK_REFERENCES_ON_DELETE", false),
+			},
+			"member_batch_threshold": {
+				Type:         schema.TypeInt,
+				Description:  "Apply a member change of a role or a group with more added and removed members than this with a single request for the whole member list, 0 to always update the members one by one",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ATHENZ_MEMBER_BATCH_THRESHOLD", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"fast_refresh": {
+				Type:        schema.TypeBool,
+				Description: "Read the roles, groups, policies and services only when their domain was changed since their last read",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_FAST_REFRESH", false),
+			},
+			"bulk_refresh": {
+				Type:        schema.TypeBool,
+				Description: "Read the roles, groups and policies of a domain from its signed domain, fetched once per domain, instead of one request per object",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_BULK_REFRESH", false),
+			},
+			"otlp_endpoint": {
+				Type:        schema.TypeString,
+				Description: "An OpenTelemetry collector receiving the spans and the metrics of the requests to ZMS and ZTS with OTLP over HTTP, e.g. https://otel-collector:4318",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_OTLP_ENDPOINT", ""),
+			},
+			"bulk_refresh_cache_dir": {
+				Type:        schema.TypeString,
+				Description: "A directory keeping the signed domains of bulk_refresh between runs, so only the domains changed since the last run are transferred",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_BULK_REFRESH_CACHE_DIR", ""),
+			},
+			"audit_ref_run_metadata": {
+				Type:        schema.TypeBool,
+				Description: "Append the commit, the Terraform Cloud run or the pipeline URL of the run, found in the environment of the CI system, to the audit_ref of every change",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_AUDIT_REF_RUN_METADATA", false),
+			},
+			"lint": {
+				Type:         schema.TypeString,
+				Description:  "Lint the changes of the policies, the roles and the domains for risky grants, with warnings of the apply (warn) or errors of the plan (error)",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ATHENZ_LINT", ""),
+				ValidateFunc: validation.StringInSlice([]string{"", LINT_WARN, LINT_ERROR}, false),
+			},
+			"lint_production_domains": {
+				Type:        schema.TypeSet,
+				Description: "The production domains of the lint, where the role members require an expiration, as names or patterns with * and ?, e.g. prod.*",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"lint_large_group_members": {
+				Type:         schema.TypeInt,
+				Description:  "The lint finds the groups with more members than this in admin roles, 100 by default",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ATHENZ_LINT_LARGE_GROUP_MEMBERS", DEFAULT_LINT_LARGE_GROUP_MEMBERS),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"audit_log": {
+				Type:        schema.TypeString,
+				Description: "A file appended with a JSON record of every change applied by terraform, or an http(s) URL of a webhook receiving them",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ATHENZ_AUDIT_LOG", ""),
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"athenz_role":               DataSourceRole(),
-			"athenz_group":              DataSourceGroup(),
-			"athenz_policy":             DataSourcePolicy(),
-			"athenz_policy_version":     DataSourcePolicyVersion(),
-			"athenz_service":            dataSourceService(),
-			"athenz_domain":             DataSourceDomain(),
-			"athenz_all_domain_details": DataSourceAllDomainDetails(),
-			"athenz_roles":              DataSourceRoles(),
+			"athenz_role":                        DataSourceRole(),
+			"athenz_group":                       DataSourceGroup(),
+			"athenz_policy":                      DataSourcePolicy(),
+			"athenz_policy_version":              DataSourcePolicyVersion(),
+			"athenz_service":                     dataSourceService(),
+			"athenz_domain":                      DataSourceDomain(),
+			"athenz_all_domain_details":          DataSourceAllDomainDetails(),
+			"athenz_roles":                       DataSourceRoles(),
+			"athenz_policy_document":             DataSourcePolicyDocument(),
+			"athenz_access":                      DataSourceAccess(),
+			"athenz_access_matrix":               DataSourceAccessMatrix(),
+			"athenz_signed_policy_data":          DataSourceSignedPolicyData(),
+			"athenz_domain_report":               DataSourceDomainReport(),
+			"athenz_principal":                   DataSourcePrincipal(),
+			"athenz_disabled_principals":         DataSourceDisabledPrincipals(),
+			"athenz_transport_policy_validation": DataSourceTransportPolicyValidation(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"athenz_role":             ResourceRole(),
-			"athenz_group":            ResourceGroup(),
-			"athenz_policy":           ResourcePolicy(),
-			"athenz_policy_version":   ResourcePolicyVersion(),
-			"athenz_service":          ResourceService(),
-			"athenz_sub_domain":       ResourceSubDomain(),
-			"athenz_user_domain":      ResourceUserDomain(),
-			"athenz_top_level_domain": ResourceTopLevelDomain(),
+			"athenz_role":              ResourceRole(),
+			"athenz_group":             ResourceGroup(),
+			"athenz_policy":            ResourcePolicy(),
+			"athenz_policy_version":    ResourcePolicyVersion(),
+			"athenz_service":           ResourceService(),
+			"athenz_sub_domain":        ResourceSubDomain(),
+			"athenz_user_domain":       ResourceUserDomain(),
+			"athenz_top_level_domain":  ResourceTopLevelDomain(),
+			"athenz_role_member_grant": ResourceRoleMemberGrant(),
+			"athenz_domain_roles":      ResourceDomainRoles(),
+			"athenz_domain_policies":   ResourceDomainPolicies(),
+			"athenz_domain_onboarding": ResourceDomainOnboarding(),
+			"athenz_zms_raw":           ResourceZmsRaw(),
 		},
 
-		ConfigureFunc: configProvider,
+		ConfigureContextFunc: configProvider,
+	}
+	for name, resource := range provider.ResourcesMap {
+		withLint(name, resource)
+		withAuditLog(name, resource)
 	}
+	return provider
 }
 
-func configProvider(d *schema.ResourceData) (interface{}, error) {
+func configProvider(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	zms := client.ZmsConfig{
-		Url:    d.Get("zms_url").(string),
-		Cert:   d.Get("cert").(string),
-		Key:    d.Get("key").(string),
-		CaCert: d.Get("cacert").(string),
+		Url:             d.Get("zms_url").(string),
+		Cert:            d.Get("cert").(string),
+		Key:             d.Get("key").(string),
+		CaCert:          d.Get("cacert").(string),
+		MaxConnsPerHost: d.Get("max_connections").(int),
+		DisableHTTP2:    d.Get("disable_http2").(bool),
+		ZtsUrl:          d.Get("zts_url").(string),
+		MsdUrl:          d.Get("msd_url").(string),
+	}
+
+	// the URL from the environment isn't validated with the configuration
+	if diags := validateZmsUrl(zms.Url, cty.GetAttrPath("zms_url")); diags.HasError() {
+		return nil, diags
+	}
+	if diags := validateZtsUrl(zms.ZtsUrl, cty.GetAttrPath("zts_url")); diags.HasError() {
+		return nil, diags
+	}
+	if diags := validateMsdUrl(zms.MsdUrl, cty.GetAttrPath("msd_url")); diags.HasError() {
+		return nil, diags
+	}
+	if ttl := d.Get("data_source_cache_ttl").(string); ttl != "" {
+		// as the URL, the value from the environment is validated here
+		if diags := validateDuration(ttl, cty.GetAttrPath("data_source_cache_ttl")); diags.HasError() {
+			return nil, diags
+		}
+		zms.CacheTTL, _ = parseDuration(ttl)
+		dir, err := dataSourceCacheDir(d.Get("data_source_cache_dir").(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		zms.CacheDir = dir
+	}
+	if endpoint := d.Get("otlp_endpoint").(string); endpoint != "" {
+		telemetry, err := client.StartTelemetry(endpoint)
+		if err != nil {
+			return nil, diag.Diagnostics{{Severity: diag.Error, Summary: err.Error(), AttributePath: cty.GetAttrPath("otlp_endpoint")}}
+		}
+		zms.Telemetry = telemetry
+	}
+	if d.Get("audit_ref_run_metadata").(bool) {
+		zms.AuditRefSuffix = runMetadata(os.Getenv)
+		if zms.AuditRefSuffix == "" {
+			log.Print("[WARN] audit_ref_run_metadata is enabled, but there's no commit, run or pipeline in the environment")
+		}
+	}
+	var lint *lintConfig
+	switch mode := d.Get("lint").(string); mode {
+	case "":
+	case LINT_WARN, LINT_ERROR:
+		lint = &lintConfig{
+			mode:              mode,
+			productionDomains: expandStringSet(d.Get("lint_production_domains").(*schema.Set)),
+			largeGroupMembers: d.Get("lint_large_group_members").(int),
+		}
+	default:
+		// the mode from the environment isn't validated with the configuration
+		return nil, diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("invalid lint mode %q, expected %s or %s", mode, LINT_WARN, LINT_ERROR),
+			AttributePath: cty.GetAttrPath("lint"),
+		}}
+	}
+	var changeLog *auditLog
+	if destination := d.Get("audit_log").(string); destination != "" {
+		var err error
+		if changeLog, err = newAuditLog(destination); err != nil {
+			return nil, diag.Diagnostics{{Severity: diag.Error, Summary: err.Error(), AttributePath: cty.GetAttrPath("audit_log")}}
+		}
 	}
+	zmsClient, err := client.NewClientWithConfig(zms)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	zmsClient.SignedDomainReads = d.Get("bulk_refresh").(bool)
+	zmsClient.SignedDomainCacheDir = d.Get("bulk_refresh_cache_dir").(string)
+	if d.Get("verify_connection").(bool) {
+		if _, err = zmsClient.WithContext(ctx).GetStatus(); err != nil {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("can't connect to ZMS at %s", zms.Url),
+				Detail:   fmt.Sprintf("the status request with the cert %s failed: %s", zms.Cert, err),
+			}}
+		}
+	}
+	return &providerConfig{
+		ZmsClient:            zmsClient,
+		principal:            zmsClient.Principal,
+		verifyReferences:     d.Get("verify_references").(bool),
+		checkReferences:      d.Get("check_references_on_delete").(bool),
+		memberBatchThreshold: d.Get("member_batch_threshold").(int),
+		fastRefresh:          d.Get("fast_refresh").(bool),
+		auditLog:             changeLog,
+		lint:                 lint,
+		auditRefSuffix:       zms.AuditRefSuffix,
+	}, nil
+}
+
+func dataSourceCacheDir(dir string) (string, error) {
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("can't find the cache directory of the user for data_source_cache_ttl, set data_source_cache_dir: %s", err)
+		}
+		dir = filepath.Join(userCacheDir, "terraform-provider-athenz")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("can't create the directory of data_source_cache_ttl: %s", err)
+	}
+	return dir, nil
+}
+
+// providerConfig is the meta of the resources. it's used as the zms client, and holds the provider
+// settings that aren't related to the client
+type providerConfig struct {
+	client.ZmsClient
+	// the principal of the client certificate, shown in the authorization errors
+	principal        string
+	verifyReferences bool
+	// the roles referenced by assertions aren't deleted without force_delete
+	checkReferences bool
+	// the member changes of a role or a group with more members than this are applied with a single request
+	memberBatchThreshold int
+	// the resources of a domain that wasn't changed since their last read aren't read again
+	fastRefresh bool
+	// the changes are recorded in the audit log when it's not nil
+	auditLog *auditLog
+	// the features of ZMS, detected by the first plan that uses one of them. unknown when it's nil
+	capabilities     *serverCapabilities
+	detectCapability sync.Once
+	// the metadata of the run appended to the audit_ref of the changes by the client
+	auditRefSuffix string
+	// the changes are linted when it's not nil
+	lint *lintConfig
+}
+
+func fastRefreshEnabled(meta interface{}) bool {
+	config, ok := meta.(*providerConfig)
+	return ok && config.fastRefresh
+}
+
+// batchMembers returns true when a change of count members is applied with a single request
+func batchMembers(meta interface{}, count int) bool {
+	config, ok := meta.(*providerConfig)
+	return ok && config.memberBatchThreshold > 0 && count > config.memberBatchThreshold
+}
+
+func verifyReferencesEnabled(meta interface{}) bool {
+	config, ok := meta.(*providerConfig)
+	return ok && config.verifyReferences
+}
 
-	return client.NewClient(zms.Url, zms.Cert, zms.Key, zms.CaCert)
+func checkReferencesOnDeleteEnabled(meta interface{}) bool {
+	config, ok := meta.(*providerConfig)
+	return ok && config.checkReferences
 }
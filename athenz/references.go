@@ -0,0 +1,132 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the message of ZMS rejecting an assertion whose role doesn't exist, when ZMS validates the assertion roles
+const missingAssertionRoleMessage = "that associated to an assertion does not exist"
+
+// verifyDomainExists returns a CustomizeDiff function that fails the plan of a new resource whose domain
+// (in the given attribute) doesn't exist, when verify_references is enabled in the provider.
+// a domain that is created in the same apply is only known after the apply, so it isn't checked
+func verifyDomainExists(key string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() != "" || !verifyReferencesEnabled(meta) || !d.NewValueKnown(key) {
+			return nil
+		}
+		dn := d.Get(key).(string)
+		_, err := meta.(client.ZmsClient).WithContext(ctx).GetDomain(dn)
+		if isNotFound(err) {
+			return fmt.Errorf("%s: the domain %s doesn't exist", key, dn)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: can't verify that the domain %s exists: %s", key, dn, err)
+		}
+		return nil
+	}
+}
+
+// verifyAssertionRolesExist fails the plan when an assertion of the policy references a role that doesn't exist
+func verifyAssertionRolesExist(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !verifyReferencesEnabled(meta) || !d.HasChange("assertion") || !d.NewValueKnown("assertion") {
+		return nil
+	}
+	return verifyRolesExist(ctx, meta, d.Get("domain").(string), d.Get("assertion").(*schema.Set).List())
+}
+
+// verifyVersionAssertionRolesExist is verifyAssertionRolesExist for the assertions of all the policy versions
+func verifyVersionAssertionRolesExist(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !verifyReferencesEnabled(meta) || !d.HasChange("versions") || !d.NewValueKnown("versions") {
+		return nil
+	}
+	var assertions []interface{}
+	for _, v := range d.Get("versions").(*schema.Set).List() {
+		assertions = append(assertions, v.(map[string]interface{})["assertion"].(*schema.Set).List()...)
+	}
+	return verifyRolesExist(ctx, meta, d.Get("domain").(string), assertions)
+}
+
+func verifyRolesExist(ctx context.Context, meta interface{}, dn string, assertions []interface{}) error {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	verified := make(map[string]bool)
+	for _, a := range assertions {
+		role, _ := a.(map[string]interface{})["role"].(string)
+		roleDomain, rn := dn, role
+		if strings.Contains(role, ROLE_SEPARATOR) {
+			parts := strings.SplitN(role, ROLE_SEPARATOR, 2)
+			roleDomain, rn = parts[0], parts[1]
+		}
+		fullRoleName := roleDomain + ROLE_SEPARATOR + rn
+		if rn == "" || verified[fullRoleName] {
+			continue
+		}
+		_, err := zmsClient.GetRole(roleDomain, rn)
+		if isNotFound(err) {
+			return fmt.Errorf("assertion: the role %s doesn't exist", fullRoleName)
+		}
+		if err != nil {
+			return fmt.Errorf("assertion: can't verify that the role %s exists: %s", fullRoleName, err)
+		}
+		verified[fullRoleName] = true
+	}
+	return nil
+}
+
+// putPolicyWaitingForRoles puts the policy, again while ZMS rejects an assertion of a role it doesn't see yet.
+// the role of the assertion can be created by the same apply on a ZMS server that the request didn't reach,
+// it's accepted once the role is replicated
+func putPolicyWaitingForRoles(ctx context.Context, zmsClient client.ZmsClient, dn string, pn string, auditRef string, policy *zms.Policy) error {
+	var err error
+	waitErr := resource.RetryContext(ctx, ASSERTION_ROLE_WAIT_TIMEOUT, func() *resource.RetryError {
+		err = zmsClient.PutPolicy(dn, pn, auditRef, policy)
+		if isMissingAssertionRole(err) {
+			log.Printf("[DEBUG] waiting for the role of an assertion of the policy %s%s%s: %s", dn, POLICY_SEPARATOR, pn, err)
+			return resource.RetryableError(err)
+		}
+		return nil
+	})
+	// the error of ZMS is returned as is when the role still doesn't exist, it names the role
+	if err != nil {
+		return err
+	}
+	return waitErr
+}
+
+func isMissingAssertionRole(err error) bool {
+	v, ok := err.(rdl.ResourceError)
+	return ok && v.Code == 400 && strings.Contains(v.Message, missingAssertionRoleMessage)
+}
+
+// policiesReferencingRole returns the names of the policies of the domain with assertions of the role,
+// including the policy versions that aren't active
+func policiesReferencingRole(zmsClient client.ZmsClient, dn string, rn string) ([]string, error) {
+	policies, err := zmsClient.GetPolicies(dn, true, true)
+	if err != nil {
+		return nil, err
+	}
+	fullRoleName := dn + ROLE_SEPARATOR + rn
+	names := make([]string, 0)
+	for _, policy := range policies.List {
+		for _, assertion := range policy.Assertions {
+			if strings.EqualFold(assertion.Role, fullRoleName) {
+				name := string(policy.Name)
+				if policy.Version != "" {
+					name += " (version " + string(policy.Version) + ")"
+				}
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
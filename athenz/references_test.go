@@ -0,0 +1,123 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_verifyDomainExists(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetDomain("some_domain").Return(&zms.Domain{Name: "some_domain"}, nil).AnyTimes()
+	clientMock.EXPECT().GetDomain("missing_domain").Return(nil, rdl.ResourceError{Code: 404, Message: "not found"}).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, verifyReferences: true}
+	roleConfig := func(domain string) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{"domain": domain, "name": "test"})
+	}
+
+	_, err := ResourceRole().Diff(context.Background(), nil, roleConfig("some_domain"), meta)
+	ast.NilError(t, err)
+
+	_, err = ResourceRole().Diff(context.Background(), nil, roleConfig("missing_domain"), meta)
+	ast.ErrorContains(t, err, "the domain missing_domain doesn't exist")
+
+	// case: the verification isn't enabled
+	_, err = ResourceRole().Diff(context.Background(), nil, roleConfig("missing_domain"), &providerConfig{ZmsClient: clientMock})
+	ast.NilError(t, err)
+}
+
+func Test_verifyAssertionRolesExist(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetDomain(gomock.Any()).Return(&zms.Domain{Name: "some_domain"}, nil).AnyTimes()
+	clientMock.EXPECT().GetRole("some_domain", "readers").Return(&zms.Role{Name: "some_domain:role.readers"}, nil).AnyTimes()
+	clientMock.EXPECT().GetRole("other_domain", "writers").Return(nil, rdl.ResourceError{Code: 404, Message: "not found"}).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, verifyReferences: true}
+
+	assertion := func(role string) map[string]interface{} {
+		return map[string]interface{}{"effect": "ALLOW", "action": "read", "role": role, "resource": "some_resource"}
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"domain":    "some_domain",
+		"name":      "test",
+		"assertion": []interface{}{assertion("readers"), assertion("some_domain:role.readers")},
+	})
+	_, err := ResourcePolicy().Diff(context.Background(), nil, config, meta)
+	ast.NilError(t, err)
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"domain":    "some_domain",
+		"name":      "test",
+		"assertion": []interface{}{assertion("other_domain:role.writers")},
+	})
+	_, err = ResourcePolicy().Diff(context.Background(), nil, config, meta)
+	ast.ErrorContains(t, err, "the role other_domain:role.writers doesn't exist")
+}
+
+func Test_resourceRoleDeleteReferencedByPolicies(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetPolicies("some_domain", true, true).Return(&zms.Policies{List: []*zms.Policy{
+		{Name: "some_domain:policy.readers", Assertions: []*zms.Assertion{{Role: "some_domain:role.readers", Resource: "some_domain:data", Action: "read"}}},
+		{Name: "some_domain:policy.writers", Assertions: []*zms.Assertion{{Role: "some_domain:role.writers", Resource: "some_domain:data", Action: "write"}}},
+	}}, nil).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, checkReferences: true}
+	d := ResourceRole().TestResourceData()
+	d.SetId("some_domain:role.readers")
+
+	diags := resourceRoleDelete(context.Background(), d, meta)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, diags[0].Summary, "the role some_domain:role.readers is referenced by assertions of the policies: some_domain:policy.readers")
+
+	// case: the role is deleted with a warning
+	ast.NilError(t, d.Set("force_delete", true))
+	clientMock.EXPECT().DeleteRole("some_domain", "readers", gomock.Any()).Return(nil)
+	diags = resourceRoleDelete(context.Background(), d, meta)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, diags[0].Severity, diag.Warning)
+
+	// case: the references aren't checked by verify_references
+	ast.NilError(t, d.Set("force_delete", false))
+	clientMock.EXPECT().DeleteRole("some_domain", "readers", gomock.Any()).Return(nil)
+	diags = resourceRoleDelete(context.Background(), d, &providerConfig{ZmsClient: clientMock, verifyReferences: true})
+	ast.Equal(t, len(diags), 0)
+}
+
+func Test_putPolicyWaitingForRoles(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	policy := &zms.Policy{Name: "some_domain:policy.readers"}
+	missingRole := rdl.ResourceError{Code: 400, Message: "putPolicy: The role: some_domain:role.readers that associated to an assertion does not exist"}
+
+	// case: the policy is put again once the role is replicated
+	gomock.InOrder(
+		clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, policy).Return(missingRole),
+		clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, policy).Return(nil),
+	)
+	ast.NilError(t, putPolicyWaitingForRoles(context.Background(), clientMock, "some_domain", "readers", AUDIT_REF, policy))
+
+	// case: another error isn't retried
+	invalid := rdl.ResourceError{Code: 400, Message: "putPolicy: invalid action"}
+	clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, policy).Return(invalid)
+	ast.Equal(t, putPolicyWaitingForRoles(context.Background(), clientMock, "some_domain", "readers", AUDIT_REF, policy), error(invalid))
+
+	// case: the error of ZMS is returned when the wait is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, policy).DoAndReturn(func(_, _, _ string, _ *zms.Policy) error {
+		cancel()
+		return missingRole
+	})
+	ast.Equal(t, putPolicyWaitingForRoles(ctx, clientMock, "some_domain", "readers", AUDIT_REF, policy), error(missingRole))
+}
@@ -0,0 +1,449 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// the name of the admin group of an onboarded domain, when it isn't configured
+const DEFAULT_ADMIN_GROUP = "admins"
+
+// ResourceDomainOnboarding creates a domain with its meta, its templates and an admin group member of its admin
+// role, in this order, instead of a chain of a domain, a group and a role membership resources
+func ResourceDomainOnboarding() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDomainOnboardingCreate,
+		ReadContext:   resourceDomainOnboardingRead,
+		UpdateContext: resourceDomainOnboardingUpdate,
+		DeleteContext: resourceDomainOnboardingDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, verifyOnboardingDomain, requireCapability(capabilityGroups, "admin_group", isNotEmpty("admin_group"))),
+		Timeouts:      resourceTimeouts(),
+		Importer: &schema.ResourceImporter{
+			StateContext: importDomainOnboardingState,
+		},
+
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
+			"name": {
+				Type:             schema.TypeString,
+				Description:      "Name of the domain, without its parent",
+				ValidateDiagFunc: validateSimpleDomainName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"parent_name": {
+				Type:             schema.TypeString,
+				Description:      "Name of the parent domain, the domain is a top level domain when it's empty",
+				ValidateDiagFunc: validateDomainName,
+				Optional:         true,
+				ForceNew:         true,
+			},
+			"ypm_id": {
+				Type:        schema.TypeInt,
+				Description: "The product id of a top level domain",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"admin_users": {
+				Type:        schema.TypeSet,
+				Description: "Names of the admin principals, besides the admin group",
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateMemberName},
+				Set:         hashCaseInsensitiveString,
+			},
+			"admin_group": {
+				Type:        schema.TypeList,
+				Description: "The group of the domain that is a member of its admin role",
+				Required:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:             schema.TypeString,
+							Description:      "Name of the group in the domain",
+							ValidateDiagFunc: validateEntityName,
+							Optional:         true,
+							Default:          DEFAULT_ADMIN_GROUP,
+						},
+						"members": {
+							Type:     schema.TypeSet,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateGroupMemberName},
+							Set:      hashCaseInsensitiveString,
+						},
+					},
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"org": {
+				Type:        schema.TypeString,
+				Description: "The organization of the domain",
+				Optional:    true,
+			},
+			"business_service": {
+				Type:        schema.TypeString,
+				Description: "The business service of the domain",
+				Optional:    true,
+			},
+			"templates": {
+				Type:        schema.TypeSet,
+				Description: "Names of the server templates applied to the domain",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateEntityName},
+				Set:         schema.HashString,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the domain",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the domain",
+				Computed:    true,
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Fail the destroy of the domain, it has to be set to false and applied first",
+				Optional:    true,
+			},
+			"audit_ref": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  AUDIT_REF,
+			},
+			"tags": tagsSchema(),
+		}),
+	}
+}
+
+// verifyOnboardingDomain fails the plan of a top level domain without a product id, and of a change of the
+// name of the admin group, which would leave the domain without the group in its admin role during the change
+func verifyOnboardingDomain(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" && d.NewValueKnown("parent_name") && d.Get("parent_name").(string) == "" && d.Get("ypm_id").(int) == 0 {
+		return fmt.Errorf("ypm_id: the product id is required to create a top level domain")
+	}
+	if d.Id() != "" && d.HasChange("admin_group.0.name") {
+		old, _ := d.GetChange("admin_group.0.name")
+		if old.(string) != "" {
+			return fmt.Errorf("admin_group: the name of the admin group %s can't be changed", old)
+		}
+	}
+	return nil
+}
+
+func onboardingDomainName(d *schema.ResourceData) string {
+	if parent := d.Get("parent_name").(string); parent != "" {
+		return parent + SUB_DOMAIN_SEPARATOR + d.Get("name").(string)
+	}
+	return d.Get("name").(string)
+}
+
+func adminGroupOf(d *schema.ResourceData) (string, []interface{}) {
+	adminGroup, ok := d.Get("admin_group").([]interface{})
+	if !ok || len(adminGroup) == 0 || adminGroup[0] == nil {
+		return DEFAULT_ADMIN_GROUP, nil
+	}
+	m := adminGroup[0].(map[string]interface{})
+	return m["name"].(string), m["members"].(*schema.Set).List()
+}
+
+func resourceDomainOnboardingCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	parentName := d.Get("parent_name").(string)
+	domainName := d.Get("name").(string)
+	fullName := onboardingDomainName(d)
+	auditRef := d.Get("audit_ref").(string)
+	adminUsers := convertToZmsResourceNameList(d.Get("admin_users").(*schema.Set).List())
+	var templates *zms.DomainTemplateList
+	if v := d.Get("templates").(*schema.Set); v.Len() > 0 {
+		templates = &zms.DomainTemplateList{TemplateNames: convertToZmsSimpleNameList(v.List())}
+	}
+	var tags map[zms.CompoundName]*zms.TagValueList
+	if v, ok := d.GetOk("tags"); ok {
+		tags = expandTagsMap(v.(*schema.Set).List())
+	}
+
+	_, err := zmsClient.GetDomain(fullName)
+	if err == nil {
+		return diag.Errorf("the domain %s already exists, use terraform import command", fullName)
+	}
+	if !isNotFound(err) {
+		return zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Domain "+fullName, parentName, "the domain "+fullName)
+	}
+
+	// 1. the domain, with its templates
+	var domain *zms.Domain
+	if parentName == "" {
+		ypmId := int32(d.Get("ypm_id").(int))
+		domain, err = zmsClient.PostTopLevelDomain(auditRef, &zms.TopLevelDomain{
+			Name:       zms.SimpleName(domainName),
+			AdminUsers: adminUsers,
+			YpmId:      &ypmId,
+			Templates:  templates,
+			Tags:       tags,
+		})
+	} else {
+		domain, err = zmsClient.PostSubDomain(parentName, auditRef, &zms.SubDomain{
+			Name:       zms.SimpleName(domainName),
+			Parent:     zms.DomainName(parentName),
+			AdminUsers: adminUsers,
+			Templates:  templates,
+			Tags:       tags,
+		})
+	}
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error creating Athenz Domain "+fullName, parentName, "the domain "+fullName)
+	}
+	if domain == nil {
+		return emptyResponseError("the domain " + fullName)
+	}
+	// the next steps change the domain, which is in the state from now on. a failure taints it, and it's
+	// created again by the next apply
+	d.SetId(fullName)
+
+	// 2. the meta of the domain
+	if d.Get("description").(string) != "" || d.Get("org").(string) != "" || d.Get("business_service").(string) != "" {
+		if err = putOnboardingDomainMeta(zmsClient, d, fullName, auditRef); err != nil {
+			return zmsDiagnostics(ctx, meta, err, "error setting the meta of the Athenz Domain "+fullName, fullName, "the domain "+fullName)
+		}
+	}
+
+	// 3. the admin group, and its membership in the admin role
+	if diags := putAdminGroup(ctx, meta, zmsClient, d, fullName, auditRef); diags.HasError() {
+		return diags
+	}
+	return readAfterCreate(ctx, d, meta, resourceDomainOnboardingRead)
+}
+
+func putOnboardingDomainMeta(zmsClient client.ZmsClient, d *schema.ResourceData, fullName string, auditRef string) error {
+	domain, err := zmsClient.GetDomain(fullName)
+	if err != nil {
+		return err
+	}
+	domainMeta := domainMetaOf(domain)
+	domainMeta.Description = d.Get("description").(string)
+	domainMeta.Org = zms.ResourceName(d.Get("org").(string))
+	domainMeta.BusinessService = d.Get("business_service").(string)
+	domainMeta.Tags = expandTagsMap(d.Get("tags").(*schema.Set).List())
+	return zmsClient.PutDomainMeta(fullName, auditRef, domainMeta)
+}
+
+// putAdminGroup sets the members of the admin group, the group is managed as a whole by the resource, and adds it
+// to the admin role of the domain
+func putAdminGroup(ctx context.Context, meta interface{}, zmsClient client.ZmsClient, d *schema.ResourceData, fullName string, auditRef string) diag.Diagnostics {
+	gn, members := adminGroupOf(d)
+	groupName := fullName + GROUP_SEPARATOR + gn
+	group := &zms.Group{Name: zms.ResourceName(groupName), GroupMembers: expandGroupMembers(members)}
+	if err := zmsClient.PutGroup(fullName, gn, auditRef, group); err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error creating the admin group "+groupName, fullName, "the group "+groupName)
+	}
+	membership := zms.Membership{MemberName: zms.MemberName(groupName), RoleName: "admin"}
+	if err := zmsClient.PutMembership(fullName, "admin", zms.MemberName(groupName), auditRef, &membership); err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error adding the admin group "+groupName+" to the admin role", fullName, "the role "+fullName+ROLE_SEPARATOR+"admin")
+	}
+	return nil
+}
+
+func resourceDomainOnboardingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	fullName := d.Id()
+	lastModified := d.Get("modified").(string)
+	domain, err := zmsClient.GetDomain(fullName)
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		if v.Code == 404 {
+			log.Printf("[WARN] Athenz Domain %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Domain "+d.Id(), "", "the domain "+d.Id())
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+	if domain == nil {
+		return emptyResponseError("the domain " + d.Id())
+	}
+
+	parentName, domainName := splitId(fullName, SUB_DOMAIN_SEPARATOR)
+	values := map[string]interface{}{
+		"name":             domainName,
+		"parent_name":      parentName,
+		"resource_name":    fullName,
+		"description":      domain.Description,
+		"org":              string(domain.Org),
+		"business_service": domain.BusinessService,
+		"modified":         timestampToString(domain.Modified),
+		"tags":             flattenTag(domain.Tags),
+	}
+	if parentName == "" && domain.YpmId != nil {
+		values["ypm_id"] = int(*domain.YpmId)
+	}
+	for key, value := range values {
+		if err = d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	templates, err := zmsClient.GetDomainTemplateList(fullName)
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error retrieving the templates of the Athenz Domain "+d.Id(), fullName, "the domain "+d.Id())
+	}
+	templateNames := make([]interface{}, 0)
+	if templates != nil {
+		for _, name := range templates.TemplateNames {
+			templateNames = append(templateNames, string(name))
+		}
+	}
+	if err = d.Set("templates", templateNames); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the admin role and the admin group are changes of the domain, they're read when it was modified
+	if !adminRoleMayHaveChanged(lastModified, domain) {
+		return nil
+	}
+	gn, _ := adminGroupOf(d)
+	groupName := strings.ToLower(fullName + GROUP_SEPARATOR + gn)
+	adminRole, err := zmsClient.GetRole(fullName, "admin")
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error retrieving the admin role of the Athenz Domain "+d.Id(), fullName, "the role "+fullName+ROLE_SEPARATOR+"admin")
+	}
+	adminUsers := make([]*zms.RoleMember, 0, len(adminRole.RoleMembers))
+	groupIsAdmin := false
+	for _, member := range adminRole.RoleMembers {
+		if strings.EqualFold(string(member.MemberName), groupName) {
+			groupIsAdmin = true
+		} else {
+			adminUsers = append(adminUsers, member)
+		}
+	}
+	if err = d.Set("admin_users", flattenRoleMembers(adminUsers)); err != nil {
+		return diag.FromErr(err)
+	}
+	// a group that was removed, or isn't in the admin role anymore, is planned to be created again
+	adminGroup := []interface{}{}
+	if groupIsAdmin {
+		group, err := zmsClient.GetGroup(fullName, gn)
+		if err != nil && !isNotFound(err) {
+			return zmsDiagnostics(ctx, meta, err, "error retrieving the admin group "+groupName, fullName, "the group "+groupName)
+		}
+		if err == nil && group != nil {
+			adminGroup = append(adminGroup, map[string]interface{}{"name": gn, "members": flattenGroupMember(group.GroupMembers)})
+		}
+	}
+	if err = d.Set("admin_group", adminGroup); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceDomainOnboardingUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	fullName := d.Id()
+	auditRef := d.Get("audit_ref").(string)
+
+	if d.HasChanges("description", "org", "business_service", "tags") {
+		if err := putOnboardingDomainMeta(zmsClient, d, fullName, auditRef); err != nil {
+			return zmsDiagnostics(ctx, meta, err, "error updating the meta of the Athenz Domain "+fullName, fullName, "the domain "+fullName)
+		}
+	}
+	if d.HasChange("templates") {
+		oldTemplates, newTemplates := handleChange(d, "templates")
+		if added := newTemplates.Difference(oldTemplates); added.Len() > 0 {
+			template := &zms.DomainTemplate{TemplateNames: convertToZmsSimpleNameList(added.List())}
+			if err := zmsClient.PutDomainTemplate(fullName, auditRef, template); err != nil {
+				return attributeError("templates", "error applying the templates to the Athenz Domain "+fullName, err)
+			}
+		}
+		for _, name := range oldTemplates.Difference(newTemplates).List() {
+			err := zmsClient.DeleteDomainTemplate(fullName, name.(string), auditRef)
+			if err != nil && !isNotFound(err) {
+				return attributeError("templates", "error removing the template "+name.(string)+" from the Athenz Domain "+fullName, err)
+			}
+		}
+	}
+	if d.HasChange("admin_users") {
+		oldAdmins, newAdmins := handleChange(d, "admin_users")
+		// the new admins are added first, the domain always has an admin
+		for _, name := range newAdmins.Difference(oldAdmins).List() {
+			member := zms.MemberName(name.(string))
+			membership := zms.Membership{MemberName: member, RoleName: "admin"}
+			if err := zmsClient.PutMembership(fullName, "admin", member, auditRef, &membership); err != nil {
+				return attributeError("admin_users", "error adding the admin "+name.(string), err)
+			}
+		}
+		for _, name := range oldAdmins.Difference(newAdmins).List() {
+			err := zmsClient.DeleteMembership(fullName, "admin", zms.MemberName(name.(string)), auditRef)
+			if err != nil && !isNotFound(err) {
+				return attributeError("admin_users", "error removing the admin "+name.(string), err)
+			}
+		}
+	}
+	if d.HasChange("admin_group") {
+		if diags := putAdminGroup(ctx, meta, zmsClient, d, fullName, auditRef); diags.HasError() {
+			return diags
+		}
+	}
+	return resourceDomainOnboardingRead(ctx, d, meta)
+}
+
+func resourceDomainOnboardingDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	if d.Get("deletion_protection").(bool) {
+		return deletionProtectedError("the domain " + d.Id())
+	}
+	auditRef := d.Get("audit_ref").(string)
+	parentName, domainName := splitId(d.Id(), SUB_DOMAIN_SEPARATOR)
+	// the group and the roles of the domain are deleted with it
+	var err error
+	if parentName == "" {
+		err = zmsClient.DeleteTopLevelDomain(domainName, auditRef)
+	} else {
+		err = zmsClient.DeleteSubDomain(parentName, domainName, auditRef)
+	}
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Domain %s is already deleted", d.Id())
+		return nil
+	}
+	if err != nil {
+		return deleteDomainDiagnostics(ctx, meta, err, "error deleting Athenz Domain "+d.Id(), parentName, d.Id())
+	}
+	return nil
+}
+
+// importDomainOnboardingState imports the domain of the ID, with the admin group of the ID when it's
+// <domain>:group.<name>, e.g. some_domain.team:group.owners, and with the default admin group otherwise
+func importDomainOnboardingState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	dn, gn := d.Id(), DEFAULT_ADMIN_GROUP
+	if parts := strings.SplitN(dn, GROUP_SEPARATOR, 2); len(parts) == 2 {
+		dn, gn = parts[0], parts[1]
+	}
+	if !compoundNameRegex.MatchString(dn) || !simpleNameRegex.MatchString(gn) {
+		return nil, fmt.Errorf("invalid domain ID %q, expected <domain> or <domain>:group.<admin group>", d.Id())
+	}
+	d.SetId(dn)
+	if err := d.Set("admin_group", []interface{}{map[string]interface{}{"name": gn}}); err != nil {
+		return nil, err
+	}
+	return importState(ctx, d, meta)
+}
+
+func convertToZmsSimpleNameList(names []interface{}) []zms.SimpleName {
+	simpleNames := make([]zms.SimpleName, 0, len(names))
+	for _, name := range names {
+		simpleNames = append(simpleNames, zms.SimpleName(name.(string)))
+	}
+	return simpleNames
+}
@@ -0,0 +1,185 @@
+package athenz
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_resourceDomainOnboarding(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+
+	// the domain in ZMS, nil before it's created
+	var domain *zms.Domain
+	var admins []string
+	var templates []zms.SimpleName
+	var group *zms.Group
+	var calls []string
+	modify := func(call string) {
+		calls = append(calls, call)
+		domain.Modified = &rdl.Timestamp{Time: domain.Modified.Time.Add(time.Second)}
+	}
+	clientMock.EXPECT().GetDomain("some_domain.team").DoAndReturn(func(_ string) (*zms.Domain, error) {
+		if domain == nil {
+			return nil, rdl.ResourceError{Code: 404, Message: "not found"}
+		}
+		copied := *domain
+		return &copied, nil
+	}).AnyTimes()
+	clientMock.EXPECT().PostSubDomain("some_domain", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _ string, subDomain *zms.SubDomain) (*zms.Domain, error) {
+		domain = &zms.Domain{Name: "some_domain.team", Modified: &rdl.Timestamp{Time: rdl.TimestampNow().Time}}
+		for _, admin := range subDomain.AdminUsers {
+			admins = append(admins, string(admin))
+		}
+		templates = subDomain.Templates.TemplateNames
+		calls = append(calls, "post domain")
+		return domain, nil
+	})
+	clientMock.EXPECT().PutDomainMeta("some_domain.team", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _ string, meta *zms.DomainMeta) error {
+		domain.Description, domain.Org, domain.BusinessService = meta.Description, meta.Org, meta.BusinessService
+		modify("put meta")
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutGroup("some_domain.team", "admins", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _, _ string, g *zms.Group) error {
+		group = g
+		modify("put group")
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().GetGroup("some_domain.team", "admins").DoAndReturn(func(_, _ string) (*zms.Group, error) {
+		return group, nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutMembership("some_domain.team", "admin", gomock.Any(), AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _ string, member zms.MemberName, _ string, _ *zms.Membership) error {
+		admins = append(admins, string(member))
+		modify("add " + string(member))
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().DeleteMembership("some_domain.team", "admin", gomock.Any(), AUDIT_REF).DoAndReturn(func(_, _ string, member zms.MemberName, _ string) error {
+		for i, admin := range admins {
+			if admin == string(member) {
+				admins = append(admins[:i], admins[i+1:]...)
+				break
+			}
+		}
+		modify("remove " + string(member))
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().GetRole("some_domain.team", "admin").DoAndReturn(func(_, _ string) (*zms.Role, error) {
+		role := &zms.Role{Name: "some_domain.team:role.admin"}
+		for _, admin := range admins {
+			role.RoleMembers = append(role.RoleMembers, &zms.RoleMember{MemberName: zms.MemberName(admin)})
+		}
+		return role, nil
+	}).AnyTimes()
+	clientMock.EXPECT().GetDomainTemplateList("some_domain.team").DoAndReturn(func(_ string) (*zms.DomainTemplateList, error) {
+		return &zms.DomainTemplateList{TemplateNames: templates}, nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutDomainTemplate("some_domain.team", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _ string, template *zms.DomainTemplate) error {
+		templates = append(templates, template.TemplateNames...)
+		modify("apply " + string(template.TemplateNames[0]))
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().DeleteDomainTemplate("some_domain.team", gomock.Any(), AUDIT_REF).DoAndReturn(func(_, name, _ string) error {
+		for i, template := range templates {
+			if string(template) == name {
+				templates = append(templates[:i], templates[i+1:]...)
+				break
+			}
+		}
+		modify("remove " + name)
+		return nil
+	}).AnyTimes()
+
+	config := func(adminUsers []interface{}, templateNames []interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"parent_name":      "some_domain",
+			"name":             "team",
+			"org":              "some_org",
+			"business_service": "some_service",
+			"admin_users":      adminUsers,
+			"templates":        templateNames,
+			"admin_group": []interface{}{map[string]interface{}{
+				"members": []interface{}{"user.jane", "user.joe"},
+			}},
+		}
+	}
+	var state *terraform.InstanceState
+	apply := func(c map[string]interface{}) {
+		calls = nil
+		diff, err := ResourceDomainOnboarding().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), clientMock)
+		ast.NilError(t, err)
+		updated, diags := ResourceDomainOnboarding().Apply(context.Background(), state, diff, clientMock)
+		ast.Assert(t, !diags.HasError(), diags)
+		state = updated
+	}
+
+	// case: the domain is created with its templates, then its meta and its admin group are set
+	apply(config([]interface{}{"user.admin"}, []interface{}{"vipng"}))
+	ast.DeepEqual(t, calls, []string{"post domain", "put meta", "put group", "add some_domain.team:group.admins"})
+	ast.Equal(t, state.ID, "some_domain.team")
+	ast.Equal(t, string(domain.Org), "some_org")
+	ast.Equal(t, len(group.GroupMembers), 2)
+	ast.Equal(t, state.Attributes["admin_users.#"], "1")
+	ast.Equal(t, state.Attributes["admin_group.0.name"], "admins")
+	ast.Equal(t, state.Attributes["admin_group.0.members.#"], "2")
+	ast.Equal(t, state.Attributes["templates.#"], "1")
+
+	// case: the same configuration doesn't change the domain
+	diff, err := ResourceDomainOnboarding().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config([]interface{}{"user.admin"}, []interface{}{"vipng"})), clientMock)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || diff.Empty())
+
+	// case: the new admin is added before the old one is removed, and the templates are replaced
+	apply(config([]interface{}{"user.other"}, []interface{}{"zts_instance_launch_provider"}))
+	ast.DeepEqual(t, calls, []string{"apply zts_instance_launch_provider", "remove vipng", "add user.other", "remove user.admin"})
+	ast.DeepEqual(t, admins, []string{"some_domain.team:group.admins", "user.other"})
+
+	// case: the admin group removed from the admin role is planned to be added again
+	admins = []string{"user.other"}
+	domain.Modified = &rdl.Timestamp{Time: domain.Modified.Time.Add(time.Second)}
+	d := ResourceDomainOnboarding().Data(state)
+	ast.Assert(t, !resourceDomainOnboardingRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("admin_group.#"), 0)
+	state = d.State()
+	apply(config([]interface{}{"user.other"}, []interface{}{"zts_instance_launch_provider"}))
+	ast.DeepEqual(t, calls, []string{"put group", "add some_domain.team:group.admins"})
+
+	// case: the name of the admin group can't be changed
+	c := config([]interface{}{"user.other"}, []interface{}{"zts_instance_launch_provider"})
+	c["admin_group"] = []interface{}{map[string]interface{}{"name": "owners", "members": []interface{}{"user.jane"}}}
+	_, err = ResourceDomainOnboarding().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), clientMock)
+	ast.ErrorContains(t, err, "the name of the admin group admins can't be changed")
+
+	// case: a top level domain requires a product id
+	c = config([]interface{}{"user.other"}, nil)
+	delete(c, "parent_name")
+	_, err = ResourceDomainOnboarding().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(c), clientMock)
+	ast.Assert(t, err != nil && strings.Contains(err.Error(), "ypm_id"))
+}
+
+func Test_importDomainOnboardingState(t *testing.T) {
+	d := ResourceDomainOnboarding().Data(nil)
+	d.SetId("some_domain.team:group.owners")
+	_, err := importDomainOnboardingState(context.Background(), d, nil)
+	ast.NilError(t, err)
+	ast.Equal(t, d.Id(), "some_domain.team")
+	ast.Equal(t, d.Get("admin_group.0.name"), "owners")
+
+	d.SetId("some_domain.team")
+	_, err = importDomainOnboardingState(context.Background(), d, nil)
+	ast.NilError(t, err)
+	ast.Equal(t, d.Get("admin_group.0.name"), DEFAULT_ADMIN_GROUP)
+
+	d.SetId("some_domain.team:group.")
+	_, err = importDomainOnboardingState(context.Background(), d, nil)
+	ast.ErrorContains(t, err, "invalid domain ID")
+}
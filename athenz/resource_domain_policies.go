@@ -0,0 +1,23 @@
+package athenz
+
+import (
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ResourceDomainPolicies declares all the policies of a domain, so a policy created outside of terraform
+// doesn't keep granting access
+func ResourceDomainPolicies() *schema.Resource {
+	return exclusiveResource(exclusiveKind{
+		attribute: "policies",
+		object:    "Policy",
+		separator: POLICY_SEPARATOR,
+		list: func(zmsClient client.ZmsClient, dn string) ([]string, error) {
+			names, err := listPolicyNames(zmsClient, dn)
+			return convertEntityNameListToStringList(names), err
+		},
+		delete: func(zmsClient client.ZmsClient, dn string, name string, auditRef string) error {
+			return zmsClient.DeletePolicy(dn, name, auditRef)
+		},
+	})
+}
@@ -0,0 +1,22 @@
+package athenz
+
+import (
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ResourceDomainRoles declares all the roles of a domain, the roles created outside of terraform are deleted
+func ResourceDomainRoles() *schema.Resource {
+	return exclusiveResource(exclusiveKind{
+		attribute: "roles",
+		object:    "Role",
+		separator: ROLE_SEPARATOR,
+		list: func(zmsClient client.ZmsClient, dn string) ([]string, error) {
+			names, err := listRoleNames(zmsClient, dn)
+			return convertEntityNameListToStringList(names), err
+		},
+		delete: func(zmsClient client.ZmsClient, dn string, name string, auditRef string) error {
+			return zmsClient.DeleteRole(dn, name, auditRef)
+		},
+	})
+}
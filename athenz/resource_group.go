@@ -1,68 +1,134 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceGroup() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceGroupCreate,
-		Read:   resourceGroupRead,
-		Update: resourceGroupUpdate,
-		Delete: resourceGroupDelete,
+		CreateContext: resourceGroupCreate,
+		ReadContext:   resourceGroupRead,
+		UpdateContext: resourceGroupUpdate,
+		DeleteContext: resourceGroupDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, setDomainModifiedOnChange, verifyDomainExists("domain"), requireResourceCapability(capabilityGroups)),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importEntityState(GROUP_SEPARATOR),
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"domain": {
-				Type:        schema.TypeString,
-				Description: "Name of the domain that group belongs to",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the domain that group belongs to",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the standard group role",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the standard group role",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"members": {
-				Type:        schema.TypeSet,
-				Description: "Users or services to be added as members",
-				Optional:    true,
-				Computed:    false,
-				Elem: &schema.Schema{Type: schema.TypeString,
-					ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
-						value := v.(string)
-						if strings.Contains(value, ":group.") {
-							errors = append(errors, fmt.Errorf("%q. A group can't be a member of another group", v))
-						}
-						return
+				Type:          schema.TypeSet,
+				Description:   "Users or services to be added as members",
+				Optional:      true,
+				Computed:      false,
+				Elem:          &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateGroupMemberName},
+				Set:           hashCaseInsensitiveString,
+				ConflictsWith: []string{"member"},
+			},
+			"member": {
+				Type:          schema.TypeSet,
+				Description:   "Users or services to be added as members, with an optional expiration date",
+				Optional:      true,
+				ConflictsWith: []string{"members"},
+				Set:           hashGroupMember,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:             schema.TypeString,
+							Description:      "Name of the user or service",
+							Required:         true,
+							ValidateDiagFunc: validateGroupMemberName,
+						},
+						"expiration": {
+							Type:             schema.TypeString,
+							Description:      "Expiration of the membership, a date (" + MEMBER_DATE_LAYOUT + ") or a duration from now (e.g. 720h, 30d)",
+							Optional:         true,
+							ValidateFunc:     validateMemberDate,
+							DiffSuppressFunc: suppressMemberDateDiff,
+						},
 					},
-					Set: schema.HashString,
 				},
 			},
+			"force_sync": {
+				Type:        schema.TypeBool,
+				Description: "Add again the configured members whose membership expired or was disabled, even if nothing else changed",
+				Optional:    true,
+			},
+			"ignore_unmanaged_members": {
+				Type:        schema.TypeBool,
+				Description: "Keep the members that aren't in the configuration (e.g. added outside of terraform) instead of removing them",
+				Optional:    true,
+			},
+			"unmanaged_members": {
+				Type:        schema.TypeSet,
+				Description: "The members of the group that aren't in the configuration, kept when ignore_unmanaged_members is set",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         hashCaseInsensitiveString,
+			},
+			"pending_members": {
+				Type:        schema.TypeSet,
+				Description: "The configured members of the group whose membership is pending approval",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         hashCaseInsensitiveString,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the group, e.g. <domain>:group.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the group",
+				Computed:    true,
+			},
+			"domain_modified": {
+				Type:        schema.TypeString,
+				Description: "The modification timestamp of the domain in the last read, set when fast_refresh is enabled in the provider",
+				Computed:    true,
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Fail the destroy of the group, it has to be set to false and applied first",
+				Optional:    true,
+			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  AUDIT_REF,
 			},
-		},
+		}),
 	}
 }
 
-func resourceGroupCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
 	dn := d.Get("domain").(string)
 	gn := d.Get("name").(string)
@@ -79,36 +145,46 @@ func resourceGroupCreate(d *schema.ResourceData, meta interface{}) error {
 			if v, ok := d.GetOk("members"); ok && v.(*schema.Set).Len() > 0 {
 				group.GroupMembers = expandGroupMembers(v.(*schema.Set).List())
 			}
+			if v, ok := d.GetOk("member"); ok && v.(*schema.Set).Len() > 0 {
+				group.GroupMembers = expandGroupMembers(v.(*schema.Set).List())
+			}
 
 			auditRef := d.Get("audit_ref").(string)
 			if err = zmsClient.PutGroup(dn, gn, auditRef, &group); err != nil {
-				return err
+				return zmsDiagnostics(ctx, meta, err, "error creating Athenz Group "+fullResourceName, dn, "the group "+fullResourceName)
 			}
+		} else {
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Group "+fullResourceName, dn, "the group "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	case nil:
 		if groupCheck != nil {
-			return fmt.Errorf("the group %s is already exists in the domain %s use terraform import command", gn, dn)
+			return diag.Errorf("the group %s is already exists in the domain %s use terraform import command", gn, dn)
 		} else {
-			return err
+			return emptyResponseError("the group " + fullResourceName)
 		}
 	}
 	d.SetId(fullResourceName)
 
-	return resourceGroupRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourceGroupRead)
 }
 
-func resourceGroupRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
-	fullResourceName := strings.Split(d.Id(), GROUP_SEPARATOR)
-	dn, gn := fullResourceName[0], fullResourceName[1]
+	dn, gn, err := parseEntityId(d.Id(), GROUP_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("domain", dn); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err := d.Set("name", gn); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if skipRefresh(zmsClient, d, meta, dn) {
+		return nil
 	}
 
 	group, err := zmsClient.GetGroup(dn, gn)
@@ -119,47 +195,111 @@ func resourceGroupRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Group %s: %s", d.Id(), v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Group "+d.Id(), dn, "the group "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if group == nil {
-		return fmt.Errorf("error retrieving Athenz Group - Make sure your cert/key are valid")
+		return emptyResponseError("the group " + d.Id())
+	}
+	if err = d.Set("resource_name", dn+GROUP_SEPARATOR+gn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(group.Modified)); err != nil {
+		return diag.FromErr(err)
 	}
 
-	if len(group.GroupMembers) > 0 {
-		d.Set("members", flattenGroupMember(group.GroupMembers))
+	groupMembers := group.GroupMembers
+	if d.Get("force_sync").(bool) {
+		groupMembers = activeGroupMembers(groupMembers, elapsedMemberNames(d))
+	}
+	pendingMembers := make([]interface{}, 0)
+	if statePending := d.Get("pending_members").(*schema.Set); statePending.Len() > 0 {
+		pending, err := pendingGroupMembers(zmsClient, dn, gn, statePending)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		groupMembers = append(append(make([]*zms.GroupMember, 0, len(groupMembers)+len(pending)), groupMembers...), pending...)
+		pendingMembers = flattenGroupMember(pending)
+	}
+	if err = d.Set("pending_members", pendingMembers); err != nil {
+		return diag.FromErr(err)
+	}
+	managedMembers, unmanagedMembers := splitUnmanagedGroupMembers(groupMembers, managedMemberNames(d))
+	if d.Get("ignore_unmanaged_members").(bool) {
+		groupMembers = managedMembers
+	}
+	if err = d.Set("unmanaged_members", unmanagedMembers); err != nil {
+		return diag.FromErr(err)
+	}
+	// as in the role, the members are kept in the attribute used by the configuration
+	stateMembers, useMemberBlocks := d.GetOk("member")
+	if _, ok := d.GetOk("members"); !ok && !useMemberBlocks {
+		useMemberBlocks = groupMembersHaveDates(groupMembers)
+	}
+	if useMemberBlocks {
+		if err = d.Set("member", flattenGroupMemberObjects(groupMembers, stateMembers.(*schema.Set).List())); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if err = d.Set("members", flattenGroupMember(groupMembers)); err != nil {
+		return diag.FromErr(err)
 	}
 
 	return nil
 }
 
-func resourceGroupUpdate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
-	fullResourceName := strings.Split(d.Id(), GROUP_SEPARATOR)
-	dn, gn := fullResourceName[0], fullResourceName[1]
+	dn, gn, err := parseEntityId(d.Id(), GROUP_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	auditRef := d.Get("audit_ref").(string)
-	if d.HasChange("members") {
-		oldVal, newVal := d.GetChange("members")
-		err := updateGroupMembers(dn, gn, oldVal, newVal, zmsClient, auditRef)
+	var diags diag.Diagnostics
+	if d.HasChanges("members", "member") {
+		os, ns := handleChange(d, "members")
+		oms, nms := handleChange(d, "member")
+		remove := expandGroupMembers(keepUnmanagedMembers(d, append(os.Difference(ns).List(), oms.Difference(nms).List()...)))
+		add := expandGroupMembers(append(ns.Difference(os).List(), nms.Difference(oms).List()...))
+		var pending []string
+		if batchMembers(meta, len(remove)+len(add)) {
+			err = putGroupMembers(dn, gn, remove, add, zmsClient, auditRef)
+		} else {
+			pending, err = updateGroupMembers(dn, gn, remove, add, zmsClient, auditRef)
+		}
+		if len(pending) > 0 {
+			diags = setPendingMembers(d, pending)
+		}
 		if err != nil {
-			return fmt.Errorf("error updating group membership: %s", err)
+			return partialUpdate(ctx, d, meta, resourceGroupRead, append(diags, attributeError("members", "error updating group membership", err)...))
+		}
+		if err = d.Set("unmanaged_members", []string{}); err != nil {
+			return append(diags, diag.FromErr(err)...)
 		}
 	}
-	return resourceGroupRead(d, meta)
+	return append(diags, resourceGroupRead(ctx, d, meta)...)
 }
 
-func resourceGroupDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), GROUP_SEPARATOR)
-	dn, gn := fullResourceName[0], fullResourceName[1]
+func resourceGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, gn, err := parseEntityId(d.Id(), GROUP_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.Get("deletion_protection").(bool) {
+		return deletionProtectedError("the group " + d.Id())
+	}
 	auditRef := d.Get("audit_ref").(string)
-	err := zmsClient.DeleteGroup(dn, gn, auditRef)
+	err = zmsClient.DeleteGroup(dn, gn, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Group %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error deleting Athenz Group "+d.Id(), dn, "the group "+d.Id())
 	}
 	return nil
 }
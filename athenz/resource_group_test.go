@@ -50,15 +50,18 @@ func TestAccGroupBasic(t *testing.T) {
 					resource.TestCheckResourceAttr(resName, "name", groupName),
 					resource.TestCheckResourceAttr(resName, "members.#", "1"),
 					resource.TestCheckResourceAttr(resName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resName, "resource_name", domainName+GROUP_SEPARATOR+groupName),
+					resource.TestCheckResourceAttrSet(resName, "modified"),
 				),
 			},
 			{
+				// a change of the audit_ref alone isn't applied
 				Config: testAccGroupConfigBasicChangeAuditRef(groupName, domainName, member1),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckGroupExists(resName, &group),
 					resource.TestCheckResourceAttr(resName, "name", groupName),
 					resource.TestCheckResourceAttr(resName, "members.#", "1"),
-					resource.TestCheckResourceAttr(resName, "audit_ref", "done by someone"),
+					resource.TestCheckResourceAttr(resName, "audit_ref", AUDIT_REF),
 				),
 			},
 			{
@@ -81,6 +84,54 @@ func TestAccGroupBasic(t *testing.T) {
 	})
 }
 
+func TestAccGroupMemberExpiration(t *testing.T) {
+	if v := os.Getenv("TF_ACC"); v != "1" && v != "true" {
+		log.Print("TF_ACC must be set for acceptance tests")
+		return
+	}
+	if v := os.Getenv("DOMAIN"); v == "" {
+		t.Fatal("DOMAIN must be set for acceptance tests")
+	}
+	if v := os.Getenv("MEMBER_1"); v == "" {
+		t.Fatal("MEMBER_1 must be set for acceptance tests")
+	}
+	var group zms.Group
+	resName := "athenz_group.groupTest"
+	rInt := acctest.RandInt()
+	domainName := os.Getenv("DOMAIN")
+	groupName := fmt.Sprintf("test%d", rInt)
+	member1 := os.Getenv("MEMBER_1")
+	t.Cleanup(func() {
+		cleanAllAccTestGroups(domainName, []string{groupName})
+	})
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGroupConfigMemberExpiration(groupName, domainName, member1, "30d"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGroupExists(resName, &group),
+					resource.TestCheckResourceAttr(resName, "member.#", "1"),
+				),
+			},
+			{
+				// the same duration in hours doesn't change the group
+				Config:   testAccGroupConfigMemberExpiration(groupName, domainName, member1, "720h"),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccGroupConfigMemberExpiration(groupName, domainName, member1, "60d"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGroupExists(resName, &group),
+					resource.TestCheckResourceAttr(resName, "member.#", "1"),
+				),
+			},
+		},
+	})
+}
+
 func cleanAllAccTestGroups(domain string, groups []string) {
 	zmsClient := testAccProvider.Meta().(client.ZmsClient)
 	for _, groupName := range groups {
@@ -181,3 +232,16 @@ resource "athenz_group" "groupTest" {
 }
 `, name, domain, member2)
 }
+
+func testAccGroupConfigMemberExpiration(name, domain, member1, expiration string) string {
+	return fmt.Sprintf(`
+resource "athenz_group" "groupTest" {
+  name = "%s"
+  domain = "%s"
+  member {
+    name = "%s"
+    expiration = "%s"
+  }
+}
+`, name, domain, member1, expiration)
+}
@@ -1,7 +1,7 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"strings"
 
@@ -11,30 +11,36 @@ import (
 
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourcePolicy() *schema.Resource {
 	return &schema.Resource{
-		Read:   resourcePolicyRead,
-		Create: resourcePolicyCreate,
-		Update: resourcePolicyUpdate,
-		Delete: resourcePolicyDelete,
+		ReadContext:   resourcePolicyRead,
+		CreateContext: resourcePolicyCreate,
+		UpdateContext: resourcePolicyUpdate,
+		DeleteContext: resourcePolicyDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, setDomainModifiedOnChange, verifyDomainExists("domain"), verifyAssertionRolesExist),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importPolicyState,
 		},
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"domain": {
-				Type:        schema.TypeString,
-				Description: "Name of the domain that policy belongs to",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the domain that policy belongs to",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the standard policy",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the standard policy",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"assertion": {
 				Type:       schema.TypeSet,
@@ -69,26 +75,51 @@ func ResourcePolicy() *schema.Resource {
 					},
 				},
 			},
+			"exclusive_assertions": {
+				Type:        schema.TypeBool,
+				Description: "Whether the assertions of the policy that aren't configured are deleted. when false, only the configured assertions are managed, and the others are kept",
+				Optional:    true,
+				Default:     true,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the policy, e.g. <domain>:policy.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the policy",
+				Computed:    true,
+			},
+			"domain_modified": {
+				Type:        schema.TypeString,
+				Description: "The modification timestamp of the domain in the last read, set when fast_refresh is enabled in the provider",
+				Computed:    true,
+			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  AUDIT_REF,
 			},
-		},
+		}),
 	}
 }
 
-func resourcePolicyRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), POLICY_SEPARATOR)
-	dn := fullResourceName[0]
-	pn := fullResourceName[1]
+func resourcePolicyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, pn, err := parseEntityId(d.Id(), POLICY_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	if err := d.Set("domain", dn); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err := d.Set("name", pn); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if skipRefresh(zmsClient, d, meta, dn) {
+		return nil
 	}
 	policy, err := zmsClient.GetPolicy(dn, pn)
 	switch v := err.(type) {
@@ -98,24 +129,34 @@ func resourcePolicyRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Policy %s: %s", d.Id(), v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if policy == nil {
-		return fmt.Errorf("error retrieving Athenz Policy - Make sure your cert/key are valid")
+		return emptyResponseError("the policy " + d.Id())
 	}
-	if len(policy.Assertions) > 0 {
-		if err = d.Set("assertion", flattenPolicyAssertion(policy.Assertions)); err != nil {
-			return err
-		}
+	if err = d.Set("resource_name", dn+POLICY_SEPARATOR+pn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(policy.Modified)); err != nil {
+		return diag.FromErr(err)
+	}
+	assertions := policy.Assertions
+	if !d.Get("exclusive_assertions").(bool) {
+		// the assertions added outside of terraform aren't in the state, so the plan doesn't show their removal
+		managed := assertionKeys(expandPolicyAssertions(dn, d.Get("assertion").(*schema.Set).List()))
+		assertions = filterAssertions(assertions, func(key string) bool { return managed[key] })
+	}
+	if err = d.Set("assertion", flattenPolicyAssertion(assertions)); err != nil {
+		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func resourcePolicyCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourcePolicyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	dn := d.Get("domain").(string)
 	pn := d.Get("name").(string)
 	fullResourceName := dn + POLICY_SEPARATOR + pn
@@ -134,60 +175,127 @@ func resourcePolicyCreate(d *schema.ResourceData, meta interface{}) error {
 			}
 
 			auditRef := d.Get("audit_ref").(string)
-			err = zmsClient.PutPolicy(dn, pn, auditRef, &policy)
+			err = putPolicyWaitingForRoles(ctx, zmsClient, dn, pn, auditRef, &policy)
 			if err != nil {
-				return err
+				return zmsDiagnostics(ctx, meta, err, "error creating Athenz Policy "+fullResourceName, dn, "the policy "+fullResourceName)
 			}
+		} else {
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Policy "+fullResourceName, dn, "the policy "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	case nil:
 		if policyCheck != nil {
-			return fmt.Errorf("the policy %s is already exists in the domain %s use terraform import command", pn, dn)
+			return diag.Errorf("the policy %s is already exists in the domain %s use terraform import command", pn, dn)
 		} else {
-			return err
+			return emptyResponseError("the policy " + fullResourceName)
 		}
 	}
 	d.SetId(fullResourceName)
 
-	return resourcePolicyRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourcePolicyRead)
 }
-func resourcePolicyUpdate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), POLICY_SEPARATOR)
-	dn := fullResourceName[0]
-	pn := fullResourceName[1]
+func resourcePolicyUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, pn, err := parseEntityId(d.Id(), POLICY_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	policy, err := zmsClient.GetPolicy(dn, pn)
 	if err != nil {
-		return fmt.Errorf("error retrieving Athenz Policy: %s", err)
+		return diag.Errorf("error retrieving Athenz Policy: %s", err)
 	}
-	if d.HasChange("assertion") {
-		_, newVal := d.GetChange("assertion")
+	exclusive := d.Get("exclusive_assertions").(bool)
+	// the assertions that aren't configured are deleted once the policy is exclusive again
+	if d.HasChange("assertion") || (exclusive && d.HasChange("exclusive_assertions")) {
+		oldVal, newVal := d.GetChange("assertion")
 		if newVal == nil {
 			newVal = new(schema.Set)
 		}
 		ns := newVal.(*schema.Set).List()
-		policy.Assertions = expandPolicyAssertions(dn, ns)
+		if exclusive {
+			policy.Assertions = expandPolicyAssertions(dn, ns)
+		} else {
+			removed := map[string]bool{}
+			// the assertions of an exclusive policy in the state are managed by other owners from now on
+			if !d.HasChange("exclusive_assertions") && oldVal != nil {
+				removed = assertionKeys(expandPolicyAssertions(dn, oldVal.(*schema.Set).Difference(newVal.(*schema.Set)).List()))
+			}
+			policy.Assertions = addAssertions(filterAssertions(policy.Assertions, func(key string) bool { return !removed[key] }), expandPolicyAssertions(dn, ns))
+		}
 		auditRef := d.Get("audit_ref").(string)
-		err = zmsClient.PutPolicy(dn, pn, auditRef, policy)
+		err = putPolicyWaitingForRoles(ctx, zmsClient, dn, pn, auditRef, policy)
 		if err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 	}
-	return resourcePolicyRead(d, meta)
+	return resourcePolicyRead(ctx, d, meta)
 }
 
-func resourcePolicyDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), POLICY_SEPARATOR)
-	dn := fullResourceName[0]
-	pn := fullResourceName[1]
+func resourcePolicyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, pn, err := parseEntityId(d.Id(), POLICY_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	auditRef := d.Get("audit_ref").(string)
-	err := zmsClient.DeletePolicy(dn, pn, auditRef)
+	if !d.Get("exclusive_assertions").(bool) {
+		deleted, diags := deleteManagedAssertions(ctx, d, meta, dn, pn)
+		if deleted || diags.HasError() {
+			return diags
+		}
+	}
+	err = zmsClient.DeletePolicy(dn, pn, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Policy %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error deleting Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
 	}
 	return nil
 }
+
+// deleteManagedAssertions deletes only the configured assertions of a policy shared with other owners. it
+// returns false when no other assertion is left, so the policy itself is deleted
+func deleteManagedAssertions(ctx context.Context, d *schema.ResourceData, meta interface{}, dn string, pn string) (bool, diag.Diagnostics) {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	policy, err := zmsClient.GetPolicy(dn, pn)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Policy %s is already deleted", d.Id())
+		return true, nil
+	}
+	if err != nil {
+		return false, zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
+	}
+	if policy == nil {
+		return false, emptyResponseError("the policy " + d.Id())
+	}
+
+	managed := assertionKeys(expandPolicyAssertions(dn, d.Get("assertion").(*schema.Set).List()))
+	kept := filterAssertions(policy.Assertions, func(key string) bool { return !managed[key] })
+	if len(kept) == 0 {
+		return false, nil
+	}
+	log.Printf("[INFO] the Athenz Policy %s has %d assertions that aren't managed by terraform, deleting only the managed ones", d.Id(), len(kept))
+	policy.Assertions = kept
+	if err = zmsClient.PutPolicy(dn, pn, d.Get("audit_ref").(string), policy); err != nil {
+		return false, zmsDiagnostics(ctx, meta, err, "error deleting the assertions of Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
+	}
+	return true, nil
+}
+
+// importPolicyState imports the policy as exclusive, as the read of a policy that isn't exclusive keeps only
+// the assertions already in the state
+func importPolicyState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	result, err := importEntityState(POLICY_SEPARATOR)(ctx, d, meta)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.Set("exclusive_assertions", true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package athenz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_resourcePolicySharedAssertions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	allow := zms.ALLOW
+	assertion := func(role, action string) *zms.Assertion {
+		return &zms.Assertion{Role: "some_domain:role." + role, Resource: "some_domain:data", Action: action, Effect: &allow}
+	}
+	configured := func(role, action string) map[string]interface{} {
+		return map[string]interface{}{"effect": "ALLOW", "action": action, "role": role, "resource": "data"}
+	}
+	config := map[string]interface{}{
+		"domain":               "some_domain",
+		"name":                 "readers",
+		"exclusive_assertions": false,
+		"assertion":            []interface{}{configured("readers", "read"), configured("writers", "write")},
+	}
+	d := schema.TestResourceDataRaw(t, ResourcePolicy().Schema, config)
+	d.SetId("some_domain:policy.readers")
+
+	// case: the assertion added outside of terraform isn't in the state
+	clientMock.EXPECT().GetPolicy("some_domain", "readers").Return(&zms.Policy{
+		Name:       "some_domain:policy.readers",
+		Assertions: []*zms.Assertion{assertion("readers", "read"), assertion("writers", "write"), assertion("others", "read")},
+	}, nil)
+	ast.Assert(t, !resourcePolicyRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("assertion").(*schema.Set).Len(), 2)
+
+	// case: an assertion removed from the configuration is deleted, the one added outside of terraform is kept
+	state := d.State()
+	config["assertion"] = []interface{}{configured("readers", "read"), configured("readers", "list")}
+	diff, err := ResourcePolicy().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+	ast.NilError(t, err)
+	var updated *zms.Policy
+	gomock.InOrder(
+		clientMock.EXPECT().GetPolicy("some_domain", "readers").Return(&zms.Policy{
+			Name:       "some_domain:policy.readers",
+			Assertions: []*zms.Assertion{assertion("readers", "read"), assertion("writers", "write"), assertion("others", "read")},
+		}, nil),
+		clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, gomock.Any()).
+			DoAndReturn(func(_, _, _ string, policy *zms.Policy) error {
+				updated = policy
+				return nil
+			}),
+		clientMock.EXPECT().GetPolicy("some_domain", "readers").DoAndReturn(func(_, _ string) (*zms.Policy, error) {
+			return updated, nil
+		}),
+	)
+	state, diags := ResourcePolicy().Apply(context.Background(), state, diff, clientMock)
+	ast.Assert(t, !diags.HasError())
+	ast.DeepEqual(t, updated.Assertions, []*zms.Assertion{assertion("readers", "read"), assertion("others", "read"), assertion("readers", "list")})
+	ast.Equal(t, state.Attributes["assertion.#"], "2")
+
+	// case: the delete keeps the policy with the assertions added outside of terraform
+	d = ResourcePolicy().Data(state)
+	gomock.InOrder(
+		clientMock.EXPECT().GetPolicy("some_domain", "readers").Return(updated, nil),
+		clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, gomock.Any()).
+			DoAndReturn(func(_, _, _ string, policy *zms.Policy) error {
+				ast.DeepEqual(t, policy.Assertions, []*zms.Assertion{assertion("others", "read")})
+				return nil
+			}),
+	)
+	ast.Assert(t, !resourcePolicyDelete(context.Background(), d, clientMock).HasError())
+
+	// case: the policy is deleted when only the managed assertions are left
+	clientMock.EXPECT().GetPolicy("some_domain", "readers").Return(&zms.Policy{
+		Name:       "some_domain:policy.readers",
+		Assertions: []*zms.Assertion{assertion("readers", "read")},
+	}, nil)
+	clientMock.EXPECT().DeletePolicy("some_domain", "readers", AUDIT_REF).Return(nil)
+	ast.Assert(t, !resourcePolicyDelete(context.Background(), d, clientMock).HasError())
+}
+
+func Test_resourcePolicyBecomesShared(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	allow := zms.ALLOW
+	policy := &zms.Policy{
+		Name: "some_domain:policy.readers",
+		Assertions: []*zms.Assertion{
+			{Role: "some_domain:role.readers", Resource: "some_domain:data", Action: "read", Effect: &allow},
+			{Role: "some_domain:role.others", Resource: "some_domain:data", Action: "read", Effect: &allow},
+		},
+	}
+	clientMock.EXPECT().GetPolicy("some_domain", "readers").Return(policy, nil).AnyTimes()
+
+	// case: the imported policy is exclusive, all its assertions are read
+	d := ResourcePolicy().TestResourceData()
+	d.SetId("some_domain/readers")
+	imported, err := ResourcePolicy().Importer.StateContext(context.Background(), d, clientMock)
+	ast.NilError(t, err)
+	ast.Assert(t, !resourcePolicyRead(context.Background(), imported[0], clientMock).HasError())
+	ast.Equal(t, imported[0].Get("exclusive_assertions"), true)
+	ast.Equal(t, imported[0].Get("assertion").(*schema.Set).Len(), 2)
+
+	// case: the policy is no longer exclusive, the assertions that aren't configured are kept
+	config := map[string]interface{}{
+		"domain":               "some_domain",
+		"name":                 "readers",
+		"exclusive_assertions": false,
+		"assertion":            []interface{}{map[string]interface{}{"effect": "ALLOW", "action": "read", "role": "readers", "resource": "data"}},
+	}
+	state := imported[0].State()
+	diff, err := ResourcePolicy().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+	ast.NilError(t, err)
+	clientMock.EXPECT().PutPolicy("some_domain", "readers", AUDIT_REF, gomock.Any()).
+		DoAndReturn(func(_, _, _ string, updated *zms.Policy) error {
+			ast.Equal(t, len(updated.Assertions), 2)
+			return nil
+		})
+	state, diags := ResourcePolicy().Apply(context.Background(), state, diff, clientMock)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, state.Attributes["assertion.#"], "1")
+}
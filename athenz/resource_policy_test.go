@@ -47,15 +47,18 @@ func TestAccGroupPolicyBasic(t *testing.T) {
 					resource.TestCheckResourceAttr(resName, "name", name),
 					resource.TestCheckResourceAttr(resName, "assertion.#", "0"),
 					resource.TestCheckResourceAttr(resName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resName, "resource_name", domainName+POLICY_SEPARATOR+name),
+					resource.TestCheckResourceAttrSet(resName, "modified"),
 				),
 			},
 			{
+				// a change of the audit_ref alone isn't applied
 				Config: testAccGroupPolicyConfigChangeAuditRef(name, domainName),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckGroupPolicyExists(resName, &policy),
 					resource.TestCheckResourceAttr(resName, "name", name),
 					resource.TestCheckResourceAttr(resName, "assertion.#", "0"),
-					resource.TestCheckResourceAttr(resName, "audit_ref", "done by someone"),
+					resource.TestCheckResourceAttr(resName, "audit_ref", AUDIT_REF),
 				),
 			},
 			{
@@ -1,37 +1,43 @@
 package athenz
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourcePolicyVersion() *schema.Resource {
 	return &schema.Resource{
-		Read:   resourcePolicyVersionRead,
-		Create: resourcePolicyVersionCreate,
-		Update: resourcePolicyVersionUpdate,
-		Delete: resourcePolicyVersionDelete,
+		ReadContext:   resourcePolicyVersionRead,
+		CreateContext: resourcePolicyVersionCreate,
+		UpdateContext: resourcePolicyVersionUpdate,
+		DeleteContext: resourcePolicyVersionDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, setDomainModifiedOnChange, verifyDomainExists("domain"), verifyVersionAssertionRolesExist, requireResourceCapability(capabilityPolicyVersions)),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importEntityState(POLICY_SEPARATOR),
 		},
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"domain": {
-				Type:        schema.TypeString,
-				Description: "Name of the domain that policy belongs to",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the domain that policy belongs to",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the policy",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the policy",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"active_version": {
 				Type:        schema.TypeString,
@@ -59,25 +65,44 @@ func ResourcePolicyVersion() *schema.Resource {
 					},
 				},
 			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the policy, e.g. <domain>:policy.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the policy",
+				Computed:    true,
+			},
+			"domain_modified": {
+				Type:        schema.TypeString,
+				Description: "The modification timestamp of the domain in the last read, set when fast_refresh is enabled in the provider",
+				Computed:    true,
+			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  AUDIT_REF,
 			},
-		},
+		}),
 	}
 }
 
-func resourcePolicyVersionRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), POLICY_SEPARATOR)
-	dn := fullResourceName[0]
-	pn := fullResourceName[1]
+func resourcePolicyVersionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, pn, err := parseEntityId(d.Id(), POLICY_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("domain", dn); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err := d.Set("name", pn); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if skipRefresh(zmsClient, d, meta, dn) {
+		return nil
 	}
 	policyVersionList, err := getAllPolicyVersions(zmsClient, dn, pn)
 	switch v := err.(type) {
@@ -87,30 +112,36 @@ func resourcePolicyVersionRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Policy %s: %s", d.Id(), v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if policyVersionList == nil {
-		return fmt.Errorf("error retrieving Athenz Policy - Make sure your cert/key are valid")
+		return emptyResponseError("the policy " + d.Id())
 	}
 
 	activeVersion := getActiveVersionName(policyVersionList)
 	if activeVersion == "" {
-		return fmt.Errorf("not found active version for the policy: %s", fullResourceName)
+		return diag.Errorf("not found active version for the policy: %s", d.Id())
 	}
 	if err = d.Set("active_version", activeVersion); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err = d.Set("resource_name", dn+POLICY_SEPARATOR+pn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(findPolicyVersion(policyVersionList, activeVersion).Modified)); err != nil {
+		return diag.FromErr(err)
 	}
 	if err = d.Set("versions", flattenPolicyVersions(policyVersionList)); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func resourcePolicyVersionCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourcePolicyVersionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	dn := d.Get("domain").(string)
 	pn := d.Get("name").(string)
 	fullResourceName := dn + POLICY_SEPARATOR + pn
@@ -122,7 +153,7 @@ func resourcePolicyVersionCreate(d *schema.ResourceData, meta interface{}) error
 			activeVersion := d.Get("active_version").(string)
 			versions := d.Get("versions").(*schema.Set).List()
 			if err := validateSchema(activeVersion, versions); err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 			policyVersions := make([]zms.Policy, 0, len(versions))
 			var activeVersionIndex int
@@ -143,22 +174,24 @@ func resourcePolicyVersionCreate(d *schema.ResourceData, meta interface{}) error
 			//must put the active version first
 			policyVersions[0], policyVersions[activeVersionIndex] = policyVersions[activeVersionIndex], policyVersions[0]
 			for _, policyVersion := range policyVersions {
-				if err := zmsClient.PutPolicy(dn, pn, auditRef, &policyVersion); err != nil {
-					return err
+				if err := putPolicyWaitingForRoles(ctx, zmsClient, dn, pn, auditRef, &policyVersion); err != nil {
+					return zmsDiagnostics(ctx, meta, err, "error creating Athenz Policy "+fullResourceName, dn, "the policy "+fullResourceName)
 				}
 			}
+		} else {
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Policy "+fullResourceName, dn, "the policy "+fullResourceName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	case nil:
 		if policyCheck != nil {
-			return fmt.Errorf("the policy %s is already exists in the domain %s use terraform import command", pn, dn)
+			return diag.Errorf("the policy %s is already exists in the domain %s use terraform import command", pn, dn)
 		} else {
-			return err
+			return emptyResponseError("the policy " + fullResourceName)
 		}
 	}
 	d.SetId(fullResourceName)
-	return resourcePolicyVersionRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourcePolicyVersionRead)
 }
 
 func validateSchema(activeVersion string, versions []interface{}) error {
@@ -173,19 +206,19 @@ func validateSchema(activeVersion string, versions []interface{}) error {
 	}
 	return validateActiveVersion(activeVersion, versionNameList)
 }
-func resourcePolicyVersionUpdate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourcePolicyVersionUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	dn := d.Get("domain").(string)
 	pn := d.Get("name").(string)
 	policyVersionList, err := getAllPolicyVersions(zmsClient, dn, pn)
 	if err != nil {
-		return fmt.Errorf("error retrieving Athenz Policy vrsions: %s", err)
+		return diag.Errorf("error retrieving Athenz Policy vrsions: %s", err)
 	}
 	activeVersion := d.Get("active_version").(string)
 	versions := d.Get("versions").(*schema.Set).List()
 	auditRef := d.Get("audit_ref").(string)
 	if err = validateSchema(activeVersion, versions); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if d.HasChange("versions") {
 		oldVersions, newVersions := handleChange(d, "versions")
@@ -206,8 +239,8 @@ func resourcePolicyVersionUpdate(d *schema.ResourceData, meta interface{}) error
 				}
 				assertions := expandPolicyAssertions(dn, policyVersion["assertion"].(*schema.Set).List())
 				zmsPolicyVersion.Assertions = assertions
-				if err = zmsClient.PutPolicy(dn, pn, auditRef, zmsPolicyVersion); err != nil {
-					return err
+				if err = putPolicyWaitingForRoles(ctx, zmsClient, dn, pn, auditRef, zmsPolicyVersion); err != nil {
+					return diag.FromErr(err)
 				}
 			}
 		}
@@ -217,12 +250,12 @@ func resourcePolicyVersionUpdate(d *schema.ResourceData, meta interface{}) error
 				Version: zms.SimpleName(activeVersion),
 			}
 			if err = zmsClient.SetActivePolicyVersion(dn, pn, &policyOptions, auditRef); err != nil {
-				return err
+				return diag.FromErr(err)
 			}
 		}
 		for _, versionName := range versionsToDelete {
-			if err = zmsClient.DeletePolicyVersion(dn, pn, versionName, auditRef); err != nil {
-				return fmt.Errorf("can't remove the policy:%s, version:%s. the error:%s", dn+POLICY_SEPARATOR+pn, versionName, err)
+			if err = zmsClient.DeletePolicyVersion(dn, pn, versionName, auditRef); err != nil && !isNotFound(err) {
+				return diag.Errorf("can't remove the policy:%s, version:%s. the error:%s", dn+POLICY_SEPARATOR+pn, versionName, err)
 			}
 		}
 	} else if d.HasChange("active_version") {
@@ -230,10 +263,10 @@ func resourcePolicyVersionUpdate(d *schema.ResourceData, meta interface{}) error
 			Version: zms.SimpleName(activeVersion),
 		}
 		if err = zmsClient.SetActivePolicyVersion(dn, pn, &policyOptions, auditRef); err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 	}
-	return resourcePolicyVersionRead(d, meta)
+	return resourcePolicyVersionRead(ctx, d, meta)
 }
 
 func findPolicyVersion(policyVersions []*zms.Policy, lookingVersion string) *zms.Policy {
@@ -245,14 +278,18 @@ func findPolicyVersion(policyVersions []*zms.Policy, lookingVersion string) *zms
 	return nil
 }
 
-func resourcePolicyVersionDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourcePolicyVersionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	dn := d.Get("domain").(string)
 	pn := d.Get("name").(string)
 	auditRef := d.Get("audit_ref").(string)
 	err := zmsClient.DeletePolicy(dn, pn, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Policy %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error deleting Athenz Policy "+d.Id(), dn, "the policy "+d.Id())
 	}
 	return nil
 }
@@ -47,6 +47,8 @@ func TestAccGroupPolicyVersionBasic(t *testing.T) {
 					resource.TestCheckResourceAttr(resName, "name", name),
 					resource.TestCheckResourceAttr(resName, "active_version", version1),
 					resource.TestCheckResourceAttr(resName, "versions.#", "1"),
+					resource.TestCheckResourceAttr(resName, "resource_name", domainName+POLICY_SEPARATOR+name),
+					resource.TestCheckResourceAttrSet(resName, "modified"),
 				),
 			},
 			{
@@ -1,6 +1,7 @@
 package athenz
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -10,45 +11,199 @@ import (
 	"github.com/AthenZ/terraform-provider-athenz/client"
 
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceRole() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceRoleCreate,
-		Read:   resourceRoleRead,
-		Update: resourceRoleUpdate,
-		Delete: resourceRoleDelete,
+		CreateContext: resourceRoleCreate,
+		ReadContext:   resourceRoleRead,
+		UpdateContext: resourceRoleUpdate,
+		DeleteContext: resourceRoleDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, setDomainModifiedOnChange, verifyDomainExists("domain"), requireCapability(capabilityRoleTags, "tags", isNotEmpty("tags")), requireCapability(capabilityMemberReview, "member", hasMemberReview)),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importEntityState(ROLE_SEPARATOR),
+		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceRoleV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: upgradeTagsStateV0,
+				Version: 0,
+			},
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"domain": {
-				Type:        schema.TypeString,
-				Description: "Name of the domain that role belongs to",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the domain that role belongs to",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the standard group role",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the standard group role",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"members": {
-				Type:        schema.TypeSet,
-				Description: "Users or services to be added as members",
+				Type:          schema.TypeSet,
+				Description:   "Users or services to be added as members",
+				Optional:      true,
+				Computed:      false,
+				Elem:          &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateMemberName},
+				Set:           hashCaseInsensitiveString,
+				ConflictsWith: []string{"member"},
+			},
+			"member": {
+				Type:          schema.TypeSet,
+				Description:   "Users or services to be added as members, with an optional expiration and review date",
+				Optional:      true,
+				ConflictsWith: []string{"members"},
+				Set:           hashRoleMember,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:             schema.TypeString,
+							Description:      "Name of the user or service",
+							ValidateDiagFunc: validateMemberName,
+							Required:         true,
+						},
+						"expiration": {
+							Type:             schema.TypeString,
+							Description:      "Expiration of the membership, a date (" + MEMBER_DATE_LAYOUT + ") or a duration from now (e.g. 720h, 30d)",
+							Optional:         true,
+							ValidateFunc:     validateMemberDate,
+							DiffSuppressFunc: suppressMemberDateDiff,
+						},
+						"review": {
+							Type:             schema.TypeString,
+							Description:      "Review reminder of the membership, a date (" + MEMBER_DATE_LAYOUT + ") or a duration from now (e.g. 720h, 30d)",
+							Optional:         true,
+							ValidateFunc:     validateMemberDate,
+							DiffSuppressFunc: suppressMemberDateDiff,
+						},
+					},
+				},
+			},
+			"force_sync": {
+				Type:        schema.TypeBool,
+				Description: "Add again the configured members whose membership expired or was disabled, even if nothing else changed",
+				Optional:    true,
+			},
+			"ignore_unmanaged_members": {
+				Type:        schema.TypeBool,
+				Description: "Keep the members that aren't in the configuration (e.g. added outside of terraform) instead of removing them",
 				Optional:    true,
-				Computed:    false,
+			},
+			"unmanaged_members": {
+				Type:        schema.TypeSet,
+				Description: "The members of the role that aren't in the configuration, kept when ignore_unmanaged_members is set",
+				Computed:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
-				Set:         schema.HashString,
+				Set:         hashCaseInsensitiveString,
+			},
+			"pending_members": {
+				Type:        schema.TypeSet,
+				Description: "The configured members of the role whose membership is pending approval",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         hashCaseInsensitiveString,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the role, e.g. <domain>:role.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the role",
+				Computed:    true,
+			},
+			"domain_modified": {
+				Type:        schema.TypeString,
+				Description: "The modification timestamp of the domain in the last read, set when fast_refresh is enabled in the provider",
+				Computed:    true,
 			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Default:  AUDIT_REF,
 			},
+			"tags": tagsSchema(),
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Fail the destroy of the role, it has to be set to false and applied first",
+				Optional:    true,
+			},
+			"force_delete": {
+				Type:        schema.TypeBool,
+				Description: "Delete the role even if assertions of policies in the domain reference it, when check_references_on_delete is enabled in the provider",
+				Optional:    true,
+			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Description: "Adopt the role if it already exists in the domain, otherwise the create fails",
+				Optional:    true,
+			},
+		}),
+	}
+}
+
+// resourceRoleV0 - the schema version 0 of the role, the tags were a map of comma separated values
+func resourceRoleV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"members": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"member": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"expiration": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"review": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"resource_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"modified": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"audit_ref": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"tags": {
 				Type:     schema.TypeMap,
 				Optional: true,
@@ -58,56 +213,73 @@ func ResourceRole() *schema.Resource {
 	}
 }
 
-func resourceRoleCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceRoleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	dn := d.Get("domain").(string)
 	rn := d.Get("name").(string)
 	fullResourceName := dn + ROLE_SEPARATOR + rn
 
+	role := zms.Role{
+		Name:     zms.ResourceName(fullResourceName),
+		Modified: nil,
+	}
+	if v, ok := d.GetOk("members"); ok && v.(*schema.Set).Len() > 0 {
+		role.RoleMembers = expandRoleMembers(v.(*schema.Set).List())
+	}
+	if v, ok := d.GetOk("member"); ok && v.(*schema.Set).Len() > 0 {
+		role.RoleMembers = expandRoleMembers(v.(*schema.Set).List())
+	}
+	if v, ok := d.GetOk("tags"); ok {
+		role.Tags = expandRoleTags(v.(*schema.Set).List())
+	}
+
 	roleCheck, err := zmsClient.GetRole(dn, rn)
-	switch v := err.(type) {
-	case rdl.ResourceError:
-		if v.Code == 404 {
-			role := zms.Role{
-				Name:     zms.ResourceName(fullResourceName),
-				Modified: nil,
-			}
-			if v, ok := d.GetOk("members"); ok && v.(*schema.Set).Len() > 0 {
-				role.RoleMembers = expandRoleMembers(v.(*schema.Set).List())
-			}
-			auditRef := d.Get("audit_ref").(string)
-			if v, ok := d.GetOk("tags"); ok {
-				role.Tags = expandRoleTags(v.(map[string]interface{}))
-			}
-			err = zmsClient.PutRole(dn, rn, auditRef, &role)
-			if err != nil {
-				return err
-			}
-		}
-	case rdl.Any:
-		return err
-	case nil:
-		if roleCheck != nil {
-			return fmt.Errorf("the role %s is already exists in the domain %s use terraform import command", rn, dn)
-		} else {
-			return err
-		}
+	switch {
+	case isNotFound(err):
+	case err != nil:
+		return zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
+	case roleCheck == nil:
+		return emptyResponseError("the role " + fullResourceName)
+	case d.Get("adopt_existing").(bool):
+		log.Printf("[INFO] Athenz Role %s already exists, adopting it", fullResourceName)
+		// the attributes that aren't configured by the resource, e.g. its meta, are kept
+		roleCheck.RoleMembers = role.RoleMembers
+		roleCheck.Tags = role.Tags
+		role = *roleCheck
+	case sameRole(roleCheck, &role):
+		// the role was created with the same configuration in the meantime, e.g. by a concurrent apply
+		// or by a create that was retried after its response was lost
+		log.Printf("[INFO] Athenz Role %s already exists with the configured members and tags", fullResourceName)
+		d.SetId(fullResourceName)
+		return readAfterCreate(ctx, d, meta, resourceRoleRead)
+	default:
+		return diag.Errorf("the role %s already exists in the domain %s, use terraform import command or set adopt_existing", rn, dn)
+	}
+
+	auditRef := d.Get("audit_ref").(string)
+	if err = zmsClient.PutRole(dn, rn, auditRef, &role); err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error creating Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
 	}
 	d.SetId(fullResourceName)
 
-	return resourceRoleRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourceRoleRead)
 }
 
-func resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceRoleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
-	fullResourceName := strings.Split(d.Id(), ROLE_SEPARATOR)
-	dn, rn := fullResourceName[0], fullResourceName[1]
+	dn, rn, err := parseEntityId(d.Id(), ROLE_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("domain", dn); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err := d.Set("name", rn); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if skipRefresh(zmsClient, d, meta, dn) {
+		return nil
 	}
 	role, err := zmsClient.GetRole(dn, rn)
 	switch v := err.(type) {
@@ -117,69 +289,166 @@ func resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Role %s: %s", d.Id(), v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Role "+d.Id(), dn, "the role "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if role == nil {
-		return fmt.Errorf("error retrieving Athenz Role - Make sure your cert/key are valid")
+		return emptyResponseError("the role " + d.Id())
+	}
+	if err = d.Set("resource_name", dn+ROLE_SEPARATOR+rn); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(role.Modified)); err != nil {
+		return diag.FromErr(err)
 	}
 
-	if len(role.RoleMembers) > 0 {
-		if err = d.Set("members", flattenRoleMembers(role.RoleMembers)); err != nil {
-			return err
+	roleMembers := role.RoleMembers
+	if d.Get("force_sync").(bool) {
+		roleMembers = activeRoleMembers(roleMembers, elapsedMemberNames(d))
+	}
+	pendingMembers := make([]interface{}, 0)
+	if statePending := d.Get("pending_members").(*schema.Set); statePending.Len() > 0 {
+		pending, err := pendingRoleMembers(zmsClient, dn, rn, statePending)
+		if err != nil {
+			return diag.FromErr(err)
 		}
+		// a pending member is kept in the members, as it's already added
+		roleMembers = append(append(make([]*zms.RoleMember, 0, len(roleMembers)+len(pending)), roleMembers...), pending...)
+		pendingMembers = flattenRoleMembers(pending)
+	}
+	if err = d.Set("pending_members", pendingMembers); err != nil {
+		return diag.FromErr(err)
 	}
-	// added for role tag
-	if len(role.Tags) > 0 {
-		if err = d.Set("tags", flattenTag(role.Tags)); err != nil {
-			return err
+	// the unmanaged members are listed in both modes, and kept in the state for the plan to remove them
+	// unless ignore_unmanaged_members is set
+	managedMembers, unmanagedMembers := splitUnmanagedRoleMembers(roleMembers, managedMemberNames(d))
+	if d.Get("ignore_unmanaged_members").(bool) {
+		roleMembers = managedMembers
+	}
+	if err = d.Set("unmanaged_members", unmanagedMembers); err != nil {
+		return diag.FromErr(err)
+	}
+	// the members are kept in the attribute used by the configuration, an imported role
+	// with membership dates is kept in member blocks
+	// each read of a set of thousands of members is costly, so the member blocks are read once
+	stateMembers, useMemberBlocks := d.GetOk("member")
+	if _, ok := d.GetOk("members"); !ok && !useMemberBlocks {
+		useMemberBlocks = roleMembersHaveDates(roleMembers)
+	}
+	if useMemberBlocks {
+		if err = d.Set("member", flattenRoleMemberObjects(roleMembers, stateMembers.(*schema.Set).List())); err != nil {
+			return diag.FromErr(err)
 		}
+	} else if err = d.Set("members", flattenRoleMembers(roleMembers)); err != nil {
+		return diag.FromErr(err)
+	}
+	// the tags are set even when the role has none, so the tags removed outside of terraform are seen
+	if err = d.Set("tags", flattenTag(role.Tags)); err != nil {
+		return diag.FromErr(err)
 	}
 
 	return nil
 }
 
-func resourceRoleUpdate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), ROLE_SEPARATOR)
-	dn, rn := fullResourceName[0], fullResourceName[1]
+func resourceRoleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, rn, err := parseEntityId(d.Id(), ROLE_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	auditRef := d.Get("audit_ref").(string)
-	if d.HasChange("members") {
+	var diags diag.Diagnostics
+	if d.HasChange("tags") {
+		// the tags are updated with the whole role, so it mustn't have been changed since it was read
+		role, err := zmsClient.GetRole(dn, rn)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		lastModified, _ := d.GetChange("modified")
+		if err = checkNotModified(d.Id(), lastModified.(string), role.Modified); err != nil {
+			return attributeError("tags", "the role was changed outside of terraform", err)
+		}
+	}
+	if d.HasChanges("members", "member") {
 		os, ns := handleChange(d, "members")
-		remove := expandRoleMembers(os.Difference(ns).List())
-		add := expandRoleMembers(ns.Difference(os).List())
-		err := updateRoleMembers(dn, rn, remove, add, auditRef, zmsClient)
+		oms, nms := handleChange(d, "member")
+		remove := expandRoleMembers(keepUnmanagedMembers(d, append(os.Difference(ns).List(), oms.Difference(nms).List()...)))
+		add := expandRoleMembers(append(ns.Difference(os).List(), nms.Difference(oms).List()...))
+		var pending []string
+		if batchMembers(meta, len(remove)+len(add)) {
+			err = putRoleMembers(dn, rn, remove, add, auditRef, zmsClient)
+		} else {
+			pending, err = updateRoleMembers(dn, rn, remove, add, auditRef, zmsClient)
+		}
+		if len(pending) > 0 {
+			diags = setPendingMembers(d, pending)
+		}
 		if err != nil {
-			return fmt.Errorf("error updating group membership: %s", err)
+			return partialUpdate(ctx, d, meta, resourceRoleRead, append(diags, attributeError("members", "error updating role membership", err)...))
+		}
+		// all the configured members are managed, including the unmanaged ones of the previous read
+		if err = d.Set("unmanaged_members", []string{}); err != nil {
+			return append(diags, diag.FromErr(err)...)
 		}
 	}
 	if d.HasChange("tags") {
 		role, err := zmsClient.GetRole(dn, rn)
 		if err != nil {
-			return err
+			return partialUpdate(ctx, d, meta, resourceRoleRead, diag.FromErr(err))
 		}
 		_, n := d.GetChange("tags")
-		tags := expandRoleTags(n.(map[string]interface{}))
+		tags := expandRoleTags(n.(*schema.Set).List())
 		role.Tags = tags
 		err = zmsClient.PutRole(dn, rn, auditRef, role)
 		if err != nil {
-			return fmt.Errorf("error updating tags: %s", err)
+			return partialUpdate(ctx, d, meta, resourceRoleRead, attributeError("tags", "error updating tags", err))
 		}
 	}
-	return resourceRoleRead(d, meta)
+	return append(diags, resourceRoleRead(ctx, d, meta)...)
 }
 
-func resourceRoleDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	fullResourceName := strings.Split(d.Id(), ROLE_SEPARATOR)
-	dn, rn := fullResourceName[0], fullResourceName[1]
+func resourceRoleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn, rn, err := parseEntityId(d.Id(), ROLE_SEPARATOR)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.Get("deletion_protection").(bool) {
+		return deletionProtectedError("the role " + d.Id())
+	}
+	var diags diag.Diagnostics
+	if checkReferencesOnDeleteEnabled(meta) {
+		policies, err := policiesReferencingRole(zmsClient, dn, rn)
+		if err != nil {
+			return diag.Errorf("can't verify that the role %s isn't referenced by policies: %s", d.Id(), err)
+		}
+		if len(policies) > 0 {
+			summary := fmt.Sprintf("the role %s is referenced by assertions of the policies: %s", d.Id(), strings.Join(policies, ", "))
+			if !d.Get("force_delete").(bool) {
+				return diag.Diagnostics{{
+					Severity: diag.Error,
+					Summary:  summary,
+					Detail:   "remove the assertions first, or set force_delete to delete the role anyway",
+				}}
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  summary,
+				Detail:   "the role is deleted as force_delete is set, the assertions are left without a role",
+			})
+		}
+	}
 	auditRef := d.Get("audit_ref").(string)
-	err := zmsClient.DeleteRole(dn, rn, auditRef)
+	err = zmsClient.DeleteRole(dn, rn, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Role %s is already deleted", d.Id())
+		return diags
+	}
 	if err != nil {
-		return err
+		return append(diags, zmsDiagnostics(ctx, meta, err, "error deleting Athenz Role "+d.Id(), dn, "the role "+d.Id())...)
 	}
 
-	return nil
+	return diags
 }
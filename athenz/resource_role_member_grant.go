@@ -0,0 +1,246 @@
+package athenz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ResourceRoleMemberGrant grants the membership of a role for a duration, e.g. an access to debug production
+// for 24h. the membership expires in ZMS, it's granted again only when the duration or the keepers change
+func ResourceRoleMemberGrant() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRoleMemberGrantCreate,
+		ReadContext:   resourceRoleMemberGrantRead,
+		UpdateContext: resourceRoleMemberGrantUpdate,
+		DeleteContext: resourceRoleMemberGrantDelete,
+		Timeouts:      resourceTimeouts(),
+
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
+			"domain": {
+				Type:             schema.TypeString,
+				Description:      "Name of the domain of the role",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"role": {
+				Type:             schema.TypeString,
+				Description:      "Name of the role",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"member": {
+				Type:             schema.TypeString,
+				Description:      "Name of the member, e.g. user.jane",
+				ValidateDiagFunc: validateMemberName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"duration": {
+				Type:             schema.TypeString,
+				Description:      "The duration of the membership from its grant, e.g. 24h or 7d",
+				ValidateDiagFunc: validateDuration,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"keepers": {
+				Type:        schema.TypeMap,
+				Description: "Arbitrary values that grant the membership again for the duration when they change",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"expiration": {
+				Type:        schema.TypeString,
+				Description: "The expiration of the membership, in the format " + MEMBER_DATE_LAYOUT,
+				Computed:    true,
+			},
+			"expired": {
+				Type:        schema.TypeBool,
+				Description: "Whether the membership expired in the last read",
+				Computed:    true,
+			},
+			"pending": {
+				Type:        schema.TypeBool,
+				Description: "Whether the membership is pending approval, in a role with review enabled",
+				Computed:    true,
+			},
+			"audit_ref": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  AUDIT_REF,
+			},
+		}),
+	}
+}
+
+func resourceRoleMemberGrantCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn := d.Get("domain").(string)
+	rn := d.Get("role").(string)
+	member := zms.MemberName(d.Get("member").(string))
+	fullResourceName := dn + ROLE_SEPARATOR + rn
+
+	duration, err := parseDuration(d.Get("duration").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	expiration := rdl.NewTimestamp(time.Now().UTC().Add(duration).Truncate(time.Second))
+	role, err := zmsClient.GetRoleWithPendingMembers(dn, rn)
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
+	}
+	if role == nil {
+		return emptyResponseError("the role " + fullResourceName)
+	}
+	// the grant would shorten a membership the member already has, and its revoke would remove it
+	if existing := roleMemberOf(role, string(member)); existing != nil {
+		switch {
+		case existing.Expiration == nil:
+			return diag.Errorf("%s is already a member of the Athenz Role %s without an expiration, the grant would make the membership expire", member, fullResourceName)
+		case existing.Expiration.Time.After(expiration.Time):
+			return diag.Errorf("%s is already a member of the Athenz Role %s until %s, after the expiration of the grant", member, fullResourceName, flattenMemberDate(existing.Expiration))
+		}
+	}
+	membership := zms.Membership{MemberName: member, RoleName: zms.ResourceName(rn), Expiration: &expiration}
+	err = zmsClient.PutMembership(dn, rn, member, d.Get("audit_ref").(string), &membership)
+	pending := isPendingApproval(err)
+	if err != nil && !pending {
+		return zmsDiagnostics(ctx, meta, err, fmt.Sprintf("error granting the membership of %s in the Athenz Role %s", member, fullResourceName), dn, "the role "+fullResourceName)
+	}
+	d.SetId(fmt.Sprintf("%s/%s", fullResourceName, member))
+	if err = d.Set("expiration", flattenMemberDate(&expiration)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	diags := readAfterCreate(ctx, d, meta, resourceRoleMemberGrantRead)
+	if pending {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("the membership of %s in the role %s is pending approval", member, fullResourceName),
+			Detail:   fmt.Sprintf("it expires at %s whether it's approved or not", flattenMemberDate(&expiration)),
+		})
+	}
+	return diags
+}
+
+func resourceRoleMemberGrantRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn := d.Get("domain").(string)
+	rn := d.Get("role").(string)
+	member := d.Get("member").(string)
+	fullResourceName := dn + ROLE_SEPARATOR + rn
+
+	role, err := zmsClient.GetRoleWithPendingMembers(dn, rn)
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		if v.Code == 404 {
+			log.Printf("[WARN] Athenz Role %s not found, removing the grant %s from state", fullResourceName, d.Id())
+			d.SetId("")
+			return nil
+		}
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+	if role == nil {
+		return emptyResponseError("the role " + fullResourceName)
+	}
+
+	granted := roleMemberOf(role, member)
+	expiration := d.Get("expiration").(string)
+	pending := false
+	if granted != nil {
+		// the expiration may be changed outside of terraform, e.g. extended by an admin
+		if granted.Expiration != nil {
+			expiration = flattenMemberDate(granted.Expiration)
+		}
+		pending = granted.Approved != nil && !*granted.Approved
+	}
+	expiresAt, err := time.Parse(MEMBER_DATE_LAYOUT, expiration)
+	expired := err == nil && !expiresAt.After(time.Now())
+	if granted == nil && !expired {
+		// the membership was removed before its expiration, the next apply grants it again
+		log.Printf("[WARN] the member %s isn't in the Athenz Role %s, removing the grant %s from state", member, fullResourceName, d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	if err = d.Set("expiration", expiration); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("expired", expired); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("pending", pending); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceRoleMemberGrantUpdate - every attribute but audit_ref forces a new grant
+func resourceRoleMemberGrantUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceRoleMemberGrantRead(ctx, d, meta)
+}
+
+// resourceRoleMemberGrantDelete revokes the membership, even before its expiration. a membership whose
+// expiration isn't the one of the grant anymore was granted again, e.g. by another grant of the member, and
+// is kept
+func resourceRoleMemberGrantDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	dn := d.Get("domain").(string)
+	rn := d.Get("role").(string)
+	member := zms.MemberName(d.Get("member").(string))
+	fullResourceName := dn + ROLE_SEPARATOR + rn
+
+	role, err := zmsClient.GetRoleWithPendingMembers(dn, rn)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Role %s is already deleted", fullResourceName)
+		return nil
+	}
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Role "+fullResourceName, dn, "the role "+fullResourceName)
+	}
+	granted := roleMemberOf(role, string(member))
+	if granted == nil {
+		log.Printf("[WARN] the member %s is already removed from the Athenz Role %s", member, fullResourceName)
+		return nil
+	}
+	if expiration := flattenMemberDate(granted.Expiration); expiration != d.Get("expiration").(string) {
+		log.Printf("[WARN] the membership of %s in the Athenz Role %s expires at %q instead of %q, it isn't revoked with the grant %s", member, fullResourceName, expiration, d.Get("expiration").(string), d.Id())
+		return nil
+	}
+
+	err = zmsClient.DeleteMembership(dn, rn, member, d.Get("audit_ref").(string))
+	if isNotFound(err) {
+		log.Printf("[WARN] the member %s is already removed from the Athenz Role %s", member, fullResourceName)
+		return nil
+	}
+	if err != nil {
+		return zmsDiagnostics(ctx, meta, err, fmt.Sprintf("error revoking the membership of %s in the Athenz Role %s", member, fullResourceName), dn, "the role "+fullResourceName)
+	}
+	return nil
+}
+
+// roleMemberOf returns the member of the role, compared case insensitively, or nil
+func roleMemberOf(role *zms.Role, member string) *zms.RoleMember {
+	if role == nil {
+		return nil
+	}
+	for _, m := range role.RoleMembers {
+		if strings.EqualFold(string(m.MemberName), member) {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package athenz
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	ast "gotest.tools/assert"
+)
+
+func Test_resourceRoleMemberGrant(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	newResourceData := func() *schema.ResourceData {
+		return schema.TestResourceDataRaw(t, ResourceRoleMemberGrant().Schema, map[string]interface{}{
+			"domain": "some_domain", "role": "prod_debug", "member": "user.jane", "duration": "24h",
+		})
+	}
+
+	// case: the membership expires after the duration
+	var granted *zms.Membership
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{Name: "some_domain:role.prod_debug"}, nil)
+	clientMock.EXPECT().PutMembership("some_domain", "prod_debug", zms.MemberName("user.jane"), AUDIT_REF, gomock.Any()).
+		DoAndReturn(func(_, _ string, _ zms.MemberName, _ string, membership *zms.Membership) error {
+			granted = membership
+			return nil
+		})
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").DoAndReturn(func(_, _ string) (*zms.Role, error) {
+		return &zms.Role{Name: "some_domain:role.prod_debug", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Expiration: granted.Expiration}}}, nil
+	})
+	d := newResourceData()
+	ast.Assert(t, !resourceRoleMemberGrantCreate(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "some_domain:role.prod_debug/user.jane")
+	ast.Assert(t, granted.Expiration.Time.Sub(time.Now()) > 23*time.Hour)
+	ast.Equal(t, d.Get("expiration"), flattenMemberDate(granted.Expiration))
+	ast.Equal(t, d.Get("expired"), false)
+
+	// case: the expired membership was removed from the role, the grant is kept as expired
+	expired := rdl.NewTimestamp(time.Now().Add(-time.Hour))
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{Name: "some_domain:role.prod_debug"}, nil)
+	ast.NilError(t, d.Set("expiration", flattenMemberDate(&expired)))
+	ast.Assert(t, !resourceRoleMemberGrantRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "some_domain:role.prod_debug/user.jane")
+	ast.Equal(t, d.Get("expired"), true)
+
+	// case: the membership was revoked before its expiration, it's removed from the state to grant it again
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{Name: "some_domain:role.prod_debug"}, nil)
+	ast.NilError(t, d.Set("expiration", flattenMemberDate(granted.Expiration)))
+	ast.Assert(t, !resourceRoleMemberGrantRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "")
+
+	// case: the member already has the membership without an expiration, or after the expiration of the grant
+	permanent := rdl.NewTimestamp(time.Now().Add(48 * time.Hour))
+	for _, expiration := range []*rdl.Timestamp{nil, &permanent} {
+		clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{
+			Name: "some_domain:role.prod_debug", RoleMembers: []*zms.RoleMember{{MemberName: "User.Jane", Expiration: expiration}},
+		}, nil)
+		diags := resourceRoleMemberGrantCreate(context.Background(), newResourceData(), clientMock)
+		ast.Assert(t, diags.HasError())
+		ast.Assert(t, strings.HasPrefix(diags[0].Summary, "user.jane is already a member of the Athenz Role some_domain:role.prod_debug"))
+	}
+
+	// case: the membership of a role with review enabled is pending approval
+	approved := false
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{Name: "some_domain:role.prod_debug"}, nil)
+	clientMock.EXPECT().PutMembership("some_domain", "prod_debug", zms.MemberName("user.jane"), AUDIT_REF, gomock.Any()).
+		Return(rdl.ResourceError{Code: 202, Message: "pending"})
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{
+		Name: "some_domain:role.prod_debug", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Approved: &approved}},
+	}, nil)
+	d = newResourceData()
+	diags := resourceRoleMemberGrantCreate(context.Background(), d, clientMock)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, d.Get("pending"), true)
+
+	// case: the membership granted again with another expiration isn't revoked
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{
+		Name: "some_domain:role.prod_debug", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Expiration: &permanent}},
+	}, nil)
+	ast.Assert(t, !resourceRoleMemberGrantDelete(context.Background(), d, clientMock).HasError())
+
+	// case: the membership of the grant is revoked, a membership already removed succeeds
+	expiration, err := time.Parse(MEMBER_DATE_LAYOUT, d.Get("expiration").(string))
+	ast.NilError(t, err)
+	revoked := rdl.NewTimestamp(expiration)
+	clientMock.EXPECT().GetRoleWithPendingMembers("some_domain", "prod_debug").Return(&zms.Role{
+		Name: "some_domain:role.prod_debug", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane", Expiration: &revoked}},
+	}, nil)
+	clientMock.EXPECT().DeleteMembership("some_domain", "prod_debug", zms.MemberName("user.jane"), AUDIT_REF).
+		Return(rdl.ResourceError{Code: 404, Message: "not found"})
+	ast.Assert(t, !resourceRoleMemberGrantDelete(context.Background(), d, clientMock).HasError())
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
@@ -50,15 +51,18 @@ func TestAccGroupRoleBasic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "name", roleName),
 					resource.TestCheckResourceAttr(resourceName, "members.#", "1"),
 					resource.TestCheckResourceAttr(resourceName, "audit_ref", "done by someone"),
+					resource.TestCheckResourceAttr(resourceName, "resource_name", domainName+ROLE_SEPARATOR+roleName),
+					resource.TestCheckResourceAttrSet(resourceName, "modified"),
 				),
 			},
 			{
+				// a change of the audit_ref alone isn't applied
 				Config: testAccGroupRoleConfigChangeAuditRef(roleName, domainName, member1),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckGroupRoleExists(resourceName, &role),
 					resource.TestCheckResourceAttr(resourceName, "name", roleName),
 					resource.TestCheckResourceAttr(resourceName, "members.#", "1"),
-					resource.TestCheckResourceAttr(resourceName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resourceName, "audit_ref", "done by someone"),
 				),
 			},
 			{
@@ -103,6 +107,69 @@ func TestAccGroupRoleBasic(t *testing.T) {
 	})
 }
 
+func TestAccRoleMemberExpiration(t *testing.T) {
+	if v := os.Getenv("TF_ACC"); v != "1" && v != "true" {
+		log.Printf("TF_ACC must be set for acceptance tests, value is: %s", v)
+		return
+	}
+	if v := os.Getenv("DOMAIN"); v == "" {
+		t.Fatal("DOMAIN must be set for acceptance tests")
+	}
+	if v := os.Getenv("MEMBER_1"); v == "" {
+		t.Fatal("MEMBER_1 must be set for acceptance tests")
+	}
+	var role zms.Role
+	resourceName := "athenz_role.roleTest"
+	rInt := acctest.RandInt()
+	domainName := os.Getenv("DOMAIN")
+	roleName := fmt.Sprintf("test%d", rInt)
+	member1 := os.Getenv("MEMBER_1")
+	t.Cleanup(func() {
+		cleanAllAccTestRoles(domainName, []string{roleName})
+	})
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGroupRoleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRoleConfigMemberExpiration(roleName, domainName, member1, "30d"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGroupRoleExists(resourceName, &role),
+					resource.TestCheckResourceAttr(resourceName, "member.#", "1"),
+					testAccCheckRoleMemberExpiration(&role, 30*24*time.Hour),
+				),
+			},
+			{
+				// the same duration in hours doesn't change the role
+				Config:   testAccRoleConfigMemberExpiration(roleName, domainName, member1, "720h"),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccRoleConfigMemberExpiration(roleName, domainName, member1, "60d"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGroupRoleExists(resourceName, &role),
+					resource.TestCheckResourceAttr(resourceName, "member.#", "1"),
+					testAccCheckRoleMemberExpiration(&role, 60*24*time.Hour),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckRoleMemberExpiration(role *zms.Role, duration time.Duration) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if len(role.RoleMembers) != 1 || role.RoleMembers[0].Expiration == nil {
+			return fmt.Errorf("the role member has no expiration")
+		}
+		expected := time.Now().Add(duration)
+		if diff := role.RoleMembers[0].Expiration.Sub(expected); diff > time.Hour || diff < -time.Hour {
+			return fmt.Errorf("the role member expiration %s isn't about %s", role.RoleMembers[0].Expiration, expected)
+		}
+		return nil
+	}
+}
+
 func cleanAllAccTestRoles(domain string, roles []string) {
 	zmsClient := testAccProvider.Meta().(client.ZmsClient)
 	for _, roleName := range roles {
@@ -281,10 +348,14 @@ resource "athenz_role" "roleTest" {
   domain = "%s"
   members = ["%s"]
   audit_ref="done by someone"
-  tags = {
-	key1 = "v1,v2"
-	key2 = "v2,v3"
-	}
+  tags {
+    key = "key1"
+    values = ["v1", "v2"]
+  }
+  tags {
+    key = "key2"
+    values = ["v2", "v3"]
+  }
 }
 `, name, domain, member1)
 }
@@ -304,10 +375,14 @@ resource "athenz_role" "roleTest" {
   name = "%s"
   domain = "%s"
   members = ["%s"]
-  tags = {
-	key1 = "a1,a2"
-	key2 = "b1,b2"
-	}
+  tags {
+    key = "key1"
+    values = ["a1", "a2"]
+  }
+  tags {
+    key = "key2"
+    values = ["b1", "b2"]
+  }
 }
 `, name, domain, member1)
 }
@@ -317,9 +392,10 @@ resource "athenz_role" "roleTest" {
 name = "%s"
 domain = "%s"
 members = ["%s"]
-  tags = {
-	key1 = "a1,a2"
-	}
+  tags {
+    key = "key1"
+    values = ["a1", "a2"]
+  }
 }
 `, name, domain, member1)
 }
@@ -330,9 +406,10 @@ resource "athenz_role" "roleTest" {
   name = "%s"
   domain = "%s"
   members = ["%s","%s"]
-  tags = {
-	key1 = "a1,a2"
-	}
+  tags {
+    key = "key1"
+    values = ["a1", "a2"]
+  }
 }
 `, name, domain, member1, member2)
 }
@@ -343,9 +420,23 @@ resource "athenz_role" "roleTest" {
   name = "%s"
   domain = "%s"
   members = ["%s"]
-  tags = {
-	key1 = "a1,a2"
-	}
+  tags {
+    key = "key1"
+    values = ["a1", "a2"]
+  }
 }
 `, name, domain, member1)
 }
+
+func testAccRoleConfigMemberExpiration(name, domain, member1, expiration string) string {
+	return fmt.Sprintf(`
+resource "athenz_role" "roleTest" {
+  name = "%s"
+  domain = "%s"
+  member {
+    name = "%s"
+    expiration = "%s"
+  }
+}
+`, name, domain, member1, expiration)
+}
@@ -1,43 +1,65 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"sort"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceService() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceServiceCreate,
-		Read:   resourceServiceRead,
-		Update: resourceServiceUpdate,
-		Delete: resourceServiceDelete,
+		CreateContext: resourceServiceCreate,
+		ReadContext:   resourceServiceRead,
+		UpdateContext: resourceServiceUpdate,
+		DeleteContext: resourceServiceDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, setDomainModifiedOnChange, verifyDomainExists("domain"), planRetiredPublicKeys),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importState,
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"domain": {
-				Type:        schema.TypeString,
-				Description: "Name of the domain that service belongs to",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the domain that service belongs to",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the service to be added to the domain",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the service to be added to the domain",
+				ValidateDiagFunc: validateEntityName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"description": {
 				Type:        schema.TypeString,
 				Description: "A description of the service",
 				Optional:    true,
 			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the service, e.g. <domain>.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the service",
+				Computed:    true,
+			},
+			"domain_modified": {
+				Type:        schema.TypeString,
+				Description: "The modification timestamp of the domain in the last read, set when fast_refresh is enabled in the provider",
+				Computed:    true,
+			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -47,6 +69,14 @@ func ResourceService() *schema.Resource {
 				Type:       schema.TypeSet,
 				ConfigMode: schema.SchemaConfigModeAttr,
 				Optional:   true,
+				Set:        hashPublicKey,
+				Elem:       publicKeyResource(),
+			},
+			"private_keys": {
+				Type:        schema.TypeList,
+				ConfigMode:  schema.SchemaConfigModeAttr,
+				Description: "Private keys whose public keys are registered in the service, only the SHA-256 of a private key is in the state",
+				Optional:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"key_id": {
@@ -54,18 +84,62 @@ func ResourceService() *schema.Resource {
 							Required: true,
 						},
 						"key_value": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Description:  "PEM encoded RSA or EC private key",
+							Required:     true,
+							Sensitive:    true,
+							StateFunc:    hashPrivateKey,
+							ValidateFunc: validatePrivateKey,
 						},
 					},
 				},
 			},
+			"rotation_triggers": {
+				Type:        schema.TypeMap,
+				Description: "Arbitrary values, e.g. the version of the keys. When set, a key removed from public_keys stays valid in ZMS, in retired_public_keys, until the next change of a value",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"retired_public_keys": {
+				Type:        schema.TypeSet,
+				Description: "The keys removed from public_keys that are still valid in ZMS until the next change of rotation_triggers",
+				Computed:    true,
+				Set:         hashPublicKey,
+				Elem:        publicKeyResource(),
+			},
+		}),
+	}
+}
+
+func publicKeyResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"key_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key_value": {
+				Type:         schema.TypeString,
+				Description:  "PEM encoded public key, or its ybase64 encoding",
+				Required:     true,
+				ValidateFunc: validatePublicKey,
+			},
 		},
 	}
 }
 
-func resourceServiceCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+// planRetiredPublicKeys - the retired keys are known after the keys are rotated in the apply. the changed keys
+// of the diff are compared, as HasChange compares the private keys of the configuration with their hashes
+func planRetiredPublicKeys(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	changed := len(d.GetChangedKeysPrefix("public_keys")) + len(d.GetChangedKeysPrefix("private_keys")) + len(d.GetChangedKeysPrefix("rotation_triggers"))
+	if d.Id() != "" && changed > 0 {
+		return d.SetNewComputed("retired_public_keys")
+	}
+	return nil
+}
+
+func resourceServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
 	domainName := d.Get("domain").(string)
 	serviceName := d.Get("name").(string)
@@ -74,7 +148,11 @@ func resourceServiceCreate(d *schema.ResourceData, meta interface{}) error {
 	publicKeys := d.Get("public_keys").(*schema.Set).List()
 	shortName := shortName(domainName, serviceName, SERVICE_SEPARATOR)
 	longName := domainName + SERVICE_SEPARATOR + shortName
-	publicKeyList := convertToPublicKeyEntryList(publicKeys)
+	derivedKeys, err := derivePublicKeys(d.Get("private_keys").([]interface{}), nil)
+	if err != nil {
+		return attributeError("private_keys", "error creating Athenz Service "+longName, err)
+	}
+	publicKeyList := convertToPublicKeyEntryList(append(publicKeys, derivedKeys...))
 
 	serviceCheck, err := zmsClient.GetServiceIdentity(domainName, serviceName)
 	switch v := err.(type) {
@@ -89,33 +167,41 @@ func resourceServiceCreate(d *schema.ResourceData, meta interface{}) error {
 			err = zmsClient.PutServiceIdentity(domainName, shortName, auditRef, &detail)
 
 			if err != nil {
-				return err
+				return zmsDiagnostics(ctx, meta, err, "error creating Athenz Service "+longName, domainName, "the service "+longName)
 			}
+		} else {
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Service "+longName, domainName, "the service "+longName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	case nil:
 		if serviceCheck != nil {
-			return fmt.Errorf("the service %s is already exists in the domain %s use terraform import command", serviceName, domainName)
+			return diag.Errorf("the service %s is already exists in the domain %s use terraform import command", serviceName, domainName)
 		} else {
-			return err
+			return emptyResponseError("the service " + longName)
 		}
 	}
 	d.SetId(longName)
 
-	return resourceServiceRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourceServiceRead)
 }
 
-func resourceServiceRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
-	domainName, shortName := splitServiceId(d.Id())
+	domainName, shortName, err := parseDomainObjectId(d.Id(), "service")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	if err := d.Set("domain", domainName); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err := d.Set("name", shortName); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if skipRefresh(zmsClient, d, meta, domainName) {
+		return nil
 	}
 	service, err := zmsClient.GetServiceIdentity(domainName, shortName)
 
@@ -126,28 +212,68 @@ func resourceServiceRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Service: %s", v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Service "+d.Id(), domainName, "the service "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if service == nil {
-		return fmt.Errorf("error retrieving Athenz Service - Make sure your cert/key are valid")
+		return emptyResponseError("the service " + d.Id())
 	}
 	if err = d.Set("description", service.Description); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err = d.Set("resource_name", domainName+SERVICE_SEPARATOR+shortName); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(service.Modified)); err != nil {
+		return diag.FromErr(err)
+	}
+	// the keys of the private keys and the keys retired by the last rotation are read apart from the configured ones
+	privateKeys := d.Get("private_keys").([]interface{})
+	privateIds := make(map[string]bool, len(privateKeys))
+	for _, val := range privateKeys {
+		privateIds[val.(map[string]interface{})["key_id"].(string)] = true
+	}
+	retiredIds := publicKeyIds(d.Get("retired_public_keys").(*schema.Set))
+	registeredIds := make(map[string]bool, len(service.PublicKeys))
+	var publicKeys, retiredKeys []*zms.PublicKeyEntry
+	for _, key := range service.PublicKeys {
+		registeredIds[key.Id] = true
+		switch {
+		case privateIds[key.Id]:
+		case retiredIds[key.Id]:
+			retiredKeys = append(retiredKeys, key)
+		default:
+			publicKeys = append(publicKeys, key)
+		}
 	}
-	if len(service.PublicKeys) > 0 {
-		if err = d.Set("public_keys", flattenPublicKeyEntryList(service.PublicKeys)); err != nil {
-			return err
+	// a private key whose public key was removed outside of terraform is planned to be registered again
+	registeredPrivateKeys := make([]interface{}, 0, len(privateKeys))
+	for _, val := range privateKeys {
+		m := val.(map[string]interface{})
+		if registeredIds[m["key_id"].(string)] {
+			registeredPrivateKeys = append(registeredPrivateKeys, map[string]interface{}{
+				"key_id":    m["key_id"],
+				"key_value": hashPrivateKey(m["key_value"]),
+			})
 		}
 	}
+	if err = d.Set("private_keys", registeredPrivateKeys); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("public_keys", flattenPublicKeyEntryList(publicKeys)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("retired_public_keys", flattenPublicKeyEntryList(retiredKeys)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return nil
 }
 
-func resourceServiceUpdate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 
 	domainName := d.Get("domain").(string)
 	serviceName := d.Get("name").(string)
@@ -155,32 +281,133 @@ func resourceServiceUpdate(d *schema.ResourceData, meta interface{}) error {
 	shortName := shortName(domainName, serviceName, SERVICE_SEPARATOR)
 	longName := domainName + SERVICE_SEPARATOR + shortName
 	auditRef := d.Get("audit_ref").(string)
-	if d.HasChange("public_keys") {
-		_, newVal := d.GetChange("public_keys")
-		if newVal == nil {
-			newVal = new(schema.Set)
+	if d.HasChange("public_keys") || d.HasChange("private_keys") || d.HasChange("rotation_triggers") {
+		oldKeys, newKeys, err := serviceKeys(zmsClient, d, domainName, shortName)
+		if err != nil {
+			return attributeError("private_keys", "error updating Athenz Service "+longName, err)
+		}
+		retired, removed := rotatePublicKeys(d, oldKeys, newKeys)
+		// the new keys are added before the old ones are removed, so the service always has a valid key
+		for _, key := range convertToPublicKeyEntryList(newKeys.Difference(oldKeys).List()) {
+			if err := zmsClient.PutPublicKeyEntry(domainName, shortName, auditRef, key); err != nil {
+				return attributeError("public_keys", "error adding the service public key "+key.Id, err)
+			}
+		}
+		removedKeys := convertToPublicKeyEntryList(removed.List())
+		sort.Slice(removedKeys, func(i, j int) bool { return removedKeys[i].Id < removedKeys[j].Id })
+		for _, key := range removedKeys {
+			err := zmsClient.DeletePublicKeyEntry(domainName, shortName, key.Id, auditRef)
+			if err != nil && !isNotFound(err) {
+				return attributeError("public_keys", "error removing the service public key "+key.Id, err)
+			}
 		}
-		newPublicKeyList := convertToPublicKeyEntryList(newVal.(*schema.Set).List())
-		detail := zms.NewServiceIdentity()
-		detail.Name = zms.ServiceName(longName)
-		detail.PublicKeys = newPublicKeyList
-		detail.Description = description
-		err := zmsClient.PutServiceIdentity(domainName, shortName, auditRef, detail)
+		if err := d.Set("retired_public_keys", retired); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChange("description") {
+		// the keys are kept as they are in ZMS, the public keys of the private keys aren't known
+		service, err := zmsClient.GetServiceIdentity(domainName, shortName)
 		if err != nil {
-			return fmt.Errorf("error updating service membership: %s", err)
+			return zmsDiagnostics(ctx, meta, err, "error retrieving Athenz Service "+longName, domainName, "the service "+longName)
 		}
+		if service == nil {
+			return emptyResponseError("the service " + longName)
+		}
+		service.Description = description
+		err = zmsClient.PutServiceIdentity(domainName, shortName, auditRef, service)
+		if err != nil {
+			return zmsDiagnostics(ctx, meta, err, "error updating Athenz Service "+longName, domainName, "the service "+longName)
+		}
+	}
+	return resourceServiceRead(ctx, d, meta)
+}
+
+// serviceKeys returns the public keys of the service before and after the change, with the public keys of the
+// private keys. the ones of the private keys in the state are read from ZMS, the state only has their hash
+func serviceKeys(zmsClient client.ZmsClient, d *schema.ResourceData, domainName, shortName string) (*schema.Set, *schema.Set, error) {
+	oldPublic, newPublic := d.GetChange("public_keys")
+	oldPrivate, newPrivate := d.GetChange("private_keys")
+	oldKeys, newKeys := oldPublic.(*schema.Set), newPublic.(*schema.Set)
+	if len(oldPrivate.([]interface{})) == 0 && len(newPrivate.([]interface{})) == 0 {
+		return oldKeys, newKeys, nil
+	}
+	service, err := zmsClient.GetServiceIdentity(domainName, shortName)
+	if err != nil {
+		return nil, nil, err
 	}
-	return resourceServiceRead(d, meta)
+	if service == nil {
+		service = &zms.ServiceIdentity{}
+	}
+	registered := registeredPrivateKeys(oldPrivate.([]interface{}), service)
+	oldDerived, err := derivePublicKeys(oldPrivate.([]interface{}), registered)
+	if err != nil {
+		return nil, nil, err
+	}
+	newDerived, err := derivePublicKeys(newPrivate.([]interface{}), registered)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema.NewSet(hashPublicKey, append(oldKeys.List(), oldDerived...)), schema.NewSet(hashPublicKey, append(newKeys.List(), newDerived...)), nil
 }
 
-func resourceServiceDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	domainName, serviceName := splitServiceId(d.Id())
+func resourceServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	domainName, serviceName, err := parseDomainObjectId(d.Id(), "service")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	auditRef := d.Get("audit_ref").(string)
-	err := zmsClient.DeleteServiceIdentity(domainName, serviceName, auditRef)
+	err = zmsClient.DeleteServiceIdentity(domainName, serviceName, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Service %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error deleting Athenz Service "+d.Id(), domainName, "the service "+d.Id())
 	}
 
 	return nil
 }
+
+// rotatePublicKeys returns the keys retired after the change, and the keys to remove from ZMS. with rotation_triggers,
+// a key removed from public_keys or private_keys is retired, and the keys retired before are removed when a trigger changes.
+// a key replaced by a new value with the same key_id is overwritten, so a new version needs a new key_id
+func rotatePublicKeys(d *schema.ResourceData, oldKeys, newKeys *schema.Set) (*schema.Set, *schema.Set) {
+	oldRetired, _ := d.GetChange("retired_public_keys")
+	newIds := publicKeyIds(newKeys)
+	rotating := len(d.Get("rotation_triggers").(map[string]interface{})) > 0
+	triggered := d.HasChange("rotation_triggers")
+
+	retired := schema.NewSet(hashPublicKey, nil)
+	removed := schema.NewSet(hashPublicKey, nil)
+	for _, key := range oldRetired.(*schema.Set).List() {
+		switch {
+		case newIds[key.(map[string]interface{})["key_id"].(string)]:
+			// configured again
+		case rotating && !triggered:
+			retired.Add(key)
+		default:
+			removed.Add(key)
+		}
+	}
+	for _, key := range oldKeys.Difference(newKeys).List() {
+		switch {
+		case newIds[key.(map[string]interface{})["key_id"].(string)]:
+			// overwritten by the new value
+		case rotating:
+			retired.Add(key)
+		default:
+			removed.Add(key)
+		}
+	}
+	return retired, removed
+}
+
+func publicKeyIds(keys *schema.Set) map[string]bool {
+	ids := make(map[string]bool, keys.Len())
+	for _, key := range keys.List() {
+		ids[key.(map[string]interface{})["key_id"].(string)] = true
+	}
+	return ids
+}
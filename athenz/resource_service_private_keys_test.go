@@ -0,0 +1,142 @@
+package athenz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func generatePrivateKey(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.NilError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	ast.NilError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+}
+
+func Test_derivePublicKey(t *testing.T) {
+	privateKey, publicKey := generatePrivateKey(t)
+	derived, err := derivePublicKey(privateKey)
+	ast.NilError(t, err)
+	ast.Equal(t, derived, publicKey)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ast.NilError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	ast.NilError(t, err)
+	derived, err = derivePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})))
+	ast.NilError(t, err)
+	ast.Assert(t, strings.HasPrefix(derived, "-----BEGIN PUBLIC KEY-----"))
+
+	_, err = derivePublicKey(publicKey)
+	ast.ErrorContains(t, err, "unsupported PEM block PUBLIC KEY")
+	_, err = derivePublicKey("not a key")
+	ast.ErrorContains(t, err, "must be a PEM encoded private key")
+}
+
+func Test_resourceServicePrivateKeys(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+
+	// the keys of the service in ZMS, nil before it's created
+	var keys map[string]string
+	var calls []string
+	clientMock.EXPECT().GetServiceIdentity("some_domain", "api").DoAndReturn(func(_, _ string) (*zms.ServiceIdentity, error) {
+		if keys == nil {
+			return nil, rdl.ResourceError{Code: 404, Message: "not found"}
+		}
+		service := &zms.ServiceIdentity{Name: "some_domain.api"}
+		for id, key := range keys {
+			service.PublicKeys = append(service.PublicKeys, &zms.PublicKeyEntry{Id: id, Key: key})
+		}
+		sort.Slice(service.PublicKeys, func(i, j int) bool { return service.PublicKeys[i].Id < service.PublicKeys[j].Id })
+		return service, nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutServiceIdentity("some_domain", "api", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _, _ string, service *zms.ServiceIdentity) error {
+		keys = map[string]string{}
+		for _, key := range service.PublicKeys {
+			keys[key.Id] = key.Key
+		}
+		calls = append(calls, "put service")
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutPublicKeyEntry("some_domain", "api", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _, _ string, key *zms.PublicKeyEntry) error {
+		keys[key.Id] = key.Key
+		calls = append(calls, "put "+key.Id)
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().DeletePublicKeyEntry("some_domain", "api", gomock.Any(), AUDIT_REF).DoAndReturn(func(_, _, id, _ string) error {
+		delete(keys, id)
+		calls = append(calls, "delete "+id)
+		return nil
+	}).AnyTimes()
+
+	privateKey1, publicKey1 := generatePrivateKey(t)
+	privateKey2, publicKey2 := generatePrivateKey(t)
+	config := func(id, privateKey string, c map[string]interface{}) map[string]interface{} {
+		c["domain"] = "some_domain"
+		c["name"] = "api"
+		c["private_keys"] = []interface{}{map[string]interface{}{"key_id": id, "key_value": privateKey}}
+		return c
+	}
+	var state *terraform.InstanceState
+	apply := func(c map[string]interface{}) {
+		calls = nil
+		diff, err := ResourceService().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), nil)
+		ast.NilError(t, err)
+		updated, diags := ResourceService().Apply(context.Background(), state, diff, clientMock)
+		ast.Assert(t, !diags.HasError(), diags)
+		state = updated
+	}
+
+	// case: the public key of the private key is registered, the state only has the hash of the private key
+	apply(config("v1", privateKey1, map[string]interface{}{}))
+	ast.DeepEqual(t, calls, []string{"put service"})
+	ast.Equal(t, normalizePublicKey(keys["v1"]), normalizeKeyValue(publicKey1))
+	ast.Equal(t, state.Attributes["private_keys.0.key_value"], hashPrivateKey(privateKey1))
+	ast.Equal(t, state.Attributes["public_keys.#"], "0")
+	for _, value := range state.Attributes {
+		ast.Assert(t, !strings.Contains(value, "PRIVATE KEY"))
+	}
+
+	// case: the same private key doesn't change the service
+	diff, err := ResourceService().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config("v1", privateKey1, map[string]interface{}{})), nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || diff.Empty())
+
+	// case: a rotation registers the new key, the key of the replaced private key stays valid
+	rotation := map[string]interface{}{"rotation_triggers": map[string]interface{}{"version": "2"}}
+	apply(config("v2", privateKey2, rotation))
+	ast.DeepEqual(t, calls, []string{"put v2"})
+	ast.Equal(t, normalizePublicKey(keys["v2"]), normalizeKeyValue(publicKey2))
+	ast.Equal(t, state.Attributes["retired_public_keys.#"], "1")
+	ast.Equal(t, len(keys), 2)
+
+	// case: a new key_id of the same private key registers its public key read from ZMS, and the old one is removed
+	apply(config("v3", privateKey2, map[string]interface{}{}))
+	ast.DeepEqual(t, calls, []string{"put v3", "delete v1", "delete v2"})
+	ast.DeepEqual(t, keys, map[string]string{"v3": convertToKeyBase64(publicKey2)})
+	ast.Equal(t, state.Attributes["private_keys.0.key_id"], "v3")
+
+	// case: a public key removed outside of terraform is planned to be registered again
+	delete(keys, "v3")
+	d := ResourceService().Data(state)
+	ast.Assert(t, !resourceServiceRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("private_keys.#"), 0)
+}
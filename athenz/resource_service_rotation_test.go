@@ -0,0 +1,96 @@
+package athenz
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_resourceServiceKeyRotation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+
+	// the keys of the service in ZMS, and the calls changing them
+	keys := map[string]string{"v1": getKeyBase64()}
+	var calls []string
+	clientMock.EXPECT().GetServiceIdentity("some_domain", "api").DoAndReturn(func(_, _ string) (*zms.ServiceIdentity, error) {
+		service := &zms.ServiceIdentity{Name: "some_domain.api"}
+		for id, key := range keys {
+			service.PublicKeys = append(service.PublicKeys, &zms.PublicKeyEntry{Id: id, Key: key})
+		}
+		sort.Slice(service.PublicKeys, func(i, j int) bool { return service.PublicKeys[i].Id < service.PublicKeys[j].Id })
+		return service, nil
+	}).AnyTimes()
+	clientMock.EXPECT().PutPublicKeyEntry("some_domain", "api", AUDIT_REF, gomock.Any()).DoAndReturn(func(_, _, _ string, key *zms.PublicKeyEntry) error {
+		keys[key.Id] = key.Key
+		calls = append(calls, "put "+key.Id)
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().DeletePublicKeyEntry("some_domain", "api", gomock.Any(), AUDIT_REF).DoAndReturn(func(_, _, id, _ string) error {
+		delete(keys, id)
+		calls = append(calls, "delete "+id)
+		return nil
+	}).AnyTimes()
+
+	config := func(id, version string) map[string]interface{} {
+		c := map[string]interface{}{
+			"domain":      "some_domain",
+			"name":        "api",
+			"public_keys": getPublicKeys(id, getDecodedKey()),
+		}
+		if version != "" {
+			c["rotation_triggers"] = map[string]interface{}{"version": version}
+		}
+		return c
+	}
+	d := schema.TestResourceDataRaw(t, ResourceService().Schema, config("v1", "1"))
+	d.SetId("some_domain.api")
+	ast.Assert(t, !resourceServiceRead(context.Background(), d, clientMock).HasError())
+	state := d.State()
+	apply := func(c map[string]interface{}) *terraform.InstanceState {
+		calls = nil
+		diff, err := ResourceService().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), nil)
+		ast.NilError(t, err)
+		updated, diags := ResourceService().Apply(context.Background(), state, diff, clientMock)
+		ast.Assert(t, !diags.HasError())
+		return updated
+	}
+
+	// case: the new key is added, the old one is retired and still valid
+	state = apply(config("v2", "2"))
+	ast.DeepEqual(t, calls, []string{"put v2"})
+	ast.Equal(t, state.Attributes["public_keys.#"], "1")
+	ast.Equal(t, state.Attributes["retired_public_keys.#"], "1")
+	ast.Equal(t, len(keys), 2)
+
+	// case: the next rotation adds the new key before removing the key retired by the previous one
+	state = apply(config("v3", "3"))
+	ast.DeepEqual(t, calls, []string{"put v3", "delete v1"})
+	ast.Equal(t, state.Attributes["retired_public_keys.#"], "1")
+	ast.Assert(t, keys["v2"] != "" && keys["v3"] != "")
+
+	// case: a refresh keeps the retired key apart from the configured ones
+	d = ResourceService().Data(state)
+	ast.Assert(t, !resourceServiceRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("public_keys").(*schema.Set).Len(), 1)
+	ast.Equal(t, d.Get("public_keys").(*schema.Set).List()[0].(map[string]interface{})["key_id"], "v3")
+
+	// case: without rotation_triggers, the retired keys are removed
+	state = apply(config("v3", ""))
+	ast.DeepEqual(t, calls, []string{"delete v2"})
+	ast.Equal(t, state.Attributes["retired_public_keys.#"], "0")
+	ast.DeepEqual(t, keys, map[string]string{"v3": getKeyBase64()})
+
+	// case: without rotation_triggers, a replaced key is removed after the new one is added
+	apply(config("v4", ""))
+	ast.DeepEqual(t, calls, []string{"put v4", "delete v3"})
+	ast.Equal(t, len(keys), 1)
+}
@@ -40,6 +40,8 @@ func TestAccGroupServiceBasic(t *testing.T) {
 					testAccCheckGroupServiceExists(resourceName, &service),
 					resource.TestCheckResourceAttr(resourceName, "name", serviceName),
 					resource.TestCheckResourceAttr(resourceName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resourceName, "resource_name", domain+SERVICE_SEPARATOR+serviceName),
+					resource.TestCheckResourceAttrSet(resourceName, "modified"),
 				),
 			},
 			{
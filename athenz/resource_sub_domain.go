@@ -1,51 +1,85 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceSubDomain() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSubDomainCreate,
-		Read:   resourceSubDomainRead,
-		Delete: resourceSubDomainDelete,
+		CreateContext: resourceSubDomainCreate,
+		ReadContext:   resourceSubDomainRead,
+		UpdateContext: resourceSubDomainUpdate,
+		DeleteContext: resourceSubDomainDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, verifyDomainExists("parent_name")),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importState,
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"parent_name": {
-				Type:        schema.TypeString,
-				Description: "Name of the standard parent domain",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the standard parent domain",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "Name of the standard sub domain",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Name of the standard sub domain",
+				ValidateDiagFunc: validateDomainName,
+				Required:         true,
+				ForceNew:         true,
 			},
 			"admin_users": {
 				Type:        schema.TypeSet,
 				Description: "Names of the standard admin users",
 				Required:    true,
 				ForceNew:    true, // must to be true, because no update method
-				Elem:        &schema.Schema{Type: schema.TypeString},
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateMemberName},
+				Set:         hashCaseInsensitiveString,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the domain, e.g. <parent_name>.<name>",
+				Computed:    true,
+			},
+			"modified": {
+				Type:        schema.TypeString,
+				Description: "The last modification timestamp of the domain",
+				Computed:    true,
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Fail the destroy of the domain, it has to be set to false and applied first",
+				Optional:    true,
+			},
+			"recursive_destroy": {
+				Type:        schema.TypeBool,
+				Description: "Delete the sub domains of the domain, the deepest first, before the domain when it's destroyed",
+				Optional:    true,
+			},
+			"recursive_destroy_objects": {
+				Type:         schema.TypeBool,
+				Description:  "With recursive_destroy, delete also the services, the policies and the roles of every domain of the tree one by one before their domain",
+				Optional:     true,
+				RequiredWith: []string{"recursive_destroy"},
 			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true, // must to be true, because no update method
 				Default:  AUDIT_REF,
 			},
-		},
+			"tags": tagsSchema(),
+		}),
 	}
 }
 
@@ -55,8 +89,8 @@ func getSubDomainSchemaAttributes(d *schema.ResourceData) (adminUsers []interfac
 	return
 }
 
-func resourceSubDomainCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceSubDomainCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	parentDomainName := d.Get("parent_name").(string)
 	domainName := shortName(parentDomainName, d.Get("name").(string), SUB_DOMAIN_SEPARATOR)
 	adminUsers, auditRef := getSubDomainSchemaAttributes(d)
@@ -65,37 +99,47 @@ func resourceSubDomainCreate(d *schema.ResourceData, meta interface{}) error {
 		Parent:     zms.DomainName(parentDomainName),
 		AdminUsers: convertToZmsResourceNameList(adminUsers),
 	}
-	subDomainCheck, err := zmsClient.GetDomain(domainName)
+	if v, ok := d.GetOk("tags"); ok {
+		subDomainDetail.Tags = expandTagsMap(v.(*schema.Set).List())
+	}
+	fullName := parentDomainName + SUB_DOMAIN_SEPARATOR + domainName
+	subDomainCheck, err := zmsClient.GetDomain(fullName)
 	switch v := err.(type) {
 	case rdl.ResourceError:
 		if v.Code == 404 {
 			subDomain, err := zmsClient.PostSubDomain(parentDomainName, auditRef, &subDomainDetail)
 			if err != nil {
-				return err
+				return zmsDiagnostics(ctx, meta, err, "error creating Athenz Sub Domain "+fullName, parentDomainName, "the domain "+parentDomainName)
 			}
 			if subDomain == nil {
-				return fmt.Errorf("error creating Sub Domain: %s", err)
+				return emptyResponseError("the domain " + fullName)
 			}
 
+		} else {
+			return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Sub Domain "+fullName, parentDomainName, "the domain "+fullName)
 		}
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	case nil:
 		if subDomainCheck != nil {
-			return fmt.Errorf("the sub-domain %s is already exists, use terraform import command", domainName)
+			return diag.Errorf("the sub-domain %s is already exists, use terraform import command", domainName)
 		} else {
-			return err
+			return emptyResponseError("the domain " + fullName)
 		}
 	}
-	d.SetId(parentDomainName + SUB_DOMAIN_SEPARATOR + domainName)
-	return resourceSubDomainRead(d, meta)
+	d.SetId(fullName)
+	return readAfterCreate(ctx, d, meta, resourceSubDomainRead)
 }
 
-func resourceSubDomainRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceSubDomainRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	fullyQualifiedName := d.Id()
-	parentDomainName, domainName := splitServiceId(fullyQualifiedName)
+	parentDomainName, domainName, err := parseDomainObjectId(fullyQualifiedName, "sub domain")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
+	lastModified := d.Get("modified").(string)
 	subDomain, err := zmsClient.GetDomain(fullyQualifiedName)
 	switch v := err.(type) {
 	case rdl.ResourceError:
@@ -104,39 +148,75 @@ func resourceSubDomainRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Sub Domain: %s", v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Sub Domain "+d.Id(), parentDomainName, "the domain "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if subDomain == nil {
-		return fmt.Errorf("error retrieving Athenz Sub Domain - Make sure your cert/key are valid")
+		return emptyResponseError("the domain " + d.Id())
 	}
 
-	adminRole, err := zmsClient.GetRole(fullyQualifiedName, "admin")
-	if err != nil {
-		return err
-	}
-	adminUsers := flattenRoleMembers(adminRole.RoleMembers)
-	if err = d.Set("admin_users", adminUsers); err != nil {
-		return err
+	if adminRoleMayHaveChanged(lastModified, subDomain) {
+		adminRole, err := zmsClient.GetRole(fullyQualifiedName, "admin")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err = d.Set("admin_users", flattenRoleMembers(adminRole.RoleMembers)); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 	if err = d.Set("parent_name", parentDomainName); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	if err = d.Set("name", domainName); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err = d.Set("resource_name", fullyQualifiedName); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(subDomain.Modified)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("tags", flattenTag(subDomain.Tags)); err != nil {
+		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func resourceSubDomainDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
-	parentDomainName, subDomainName := splitSubDomainId(d.Id())
+func resourceSubDomainUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	auditRef := d.Get("audit_ref").(string)
-	err := zmsClient.DeleteSubDomain(parentDomainName, subDomainName, auditRef)
+	if d.HasChange("tags") {
+		if err := updateDomainTags(zmsClient, d.Id(), auditRef, d.Get("tags").(*schema.Set).List()); err != nil {
+			return attributeError("tags", "error updating tags", err)
+		}
+	}
+	return resourceSubDomainRead(ctx, d, meta)
+}
+
+func resourceSubDomainDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	parentDomainName, subDomainName, err := parseDomainObjectId(d.Id(), "sub domain")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.Get("deletion_protection").(bool) {
+		return deletionProtectedError("the domain " + d.Id())
+	}
+	auditRef := d.Get("audit_ref").(string)
+	if d.Get("recursive_destroy").(bool) {
+		if err = deleteDomainTree(zmsClient, d.Id(), d.Get("recursive_destroy_objects").(bool), auditRef); err != nil {
+			return diag.Diagnostics{{Severity: diag.Error, Summary: "error deleting the sub domains of Athenz Sub Domain " + d.Id(), Detail: err.Error()}}
+		}
+	}
+	err = zmsClient.DeleteSubDomain(parentDomainName, subDomainName, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Sub Domain %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return deleteDomainDiagnostics(ctx, meta, err, "error deleting Athenz Sub Domain "+d.Id(), parentDomainName, d.Id())
 	}
 	return nil
 }
@@ -47,6 +47,17 @@ func TestAccGroupSubDomainBasic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "name", subDomainName),
 					resource.TestCheckResourceAttr(resourceName, "admin_users.#", "1"),
 					resource.TestCheckResourceAttr(resourceName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resourceName, "resource_name", parentDomain+SUB_DOMAIN_SEPARATOR+subDomainName),
+					resource.TestCheckResourceAttrSet(resourceName, "modified"),
+					resource.TestCheckResourceAttr(resourceName, "tags.#", "0"),
+				),
+			},
+			{
+				Config: testAccSubDomainConfigTags(subDomainName, parentDomain, adminUser),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckGroupSubDomainExists(resourceName, &domain),
+					resource.TestCheckResourceAttr(resourceName, "name", subDomainName),
+					testAccCheckCorrectTags(resourceName, map[string][]string{"key1": {"a1", "a2"}}),
 				),
 			},
 		},
@@ -113,3 +124,17 @@ resource "athenz_sub_domain" "testSubDomain" {
 }
 `, name, parentName, adminUser)
 }
+
+func testAccSubDomainConfigTags(name, parentName, adminUser string) string {
+	return fmt.Sprintf(`
+resource "athenz_sub_domain" "testSubDomain" {
+  name = "%s"
+  parent_name = "%s"
+  admin_users = ["%s"]
+  tags {
+    key = "key1"
+    values = ["a1", "a2"]
+  }
+}
+`, name, parentName, adminUser)
+}
@@ -1,78 +1,132 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceTopLevelDomain() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceTopLevelDomainCreate,
-		Read:   resourceTopLevelDomainRead,
-		Delete: resourceTopLevelDomainDelete,
+		CreateContext: resourceTopLevelDomainCreate,
+		ReadContext:   resourceTopLevelDomainRead,
+		UpdateContext: resourceTopLevelDomainUpdate,
+		DeleteContext: resourceTopLevelDomainDelete,
+		CustomizeDiff: customdiff.All(setModifiedOnChange, requireCapability(capabilityGroups, "admin_groups", isNotEmpty("admin_groups"))),
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importState,
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"name": {
+				Type:             schema.TypeString,
+				Description:      "Name of the standard Top Level domain",
+				ValidateDiagFunc: validateSimpleDomainName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the domain",
+				Computed:    true,
+			},
+			"modified": {
 				Type:        schema.TypeString,
-				Description: "Name of the standard Top Level domain",
-				Required:    true,
-				ForceNew:    true,
+				Description: "The last modification timestamp of the domain",
+				Computed:    true,
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Fail the destroy of the domain, it has to be set to false and applied first",
+				Optional:    true,
+			},
+			"recursive_destroy": {
+				Type:        schema.TypeBool,
+				Description: "Delete the sub domains of the domain, the deepest first, before the domain when it's destroyed",
+				Optional:    true,
+			},
+			"recursive_destroy_objects": {
+				Type:         schema.TypeBool,
+				Description:  "With recursive_destroy, delete also the services, the policies and the roles of every domain of the tree one by one before their domain",
+				Optional:     true,
+				RequiredWith: []string{"recursive_destroy"},
 			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true, // must to be true, because no update method
 				Default:  AUDIT_REF,
 			},
+			"tags": tagsSchema(),
 			"admin_users": {
-				Type:        schema.TypeSet,
-				Description: "Names of the standard admin users",
-				Required:    true,
-				ForceNew:    true, // must to be true, because no update method
-				Elem:        &schema.Schema{Type: schema.TypeString},
+				Type:             schema.TypeSet,
+				Description:      "Names of the admin principals, e.g. users, services or groups",
+				Optional:         true,
+				ForceNew:         true, // must to be true, because no update method
+				Elem:             &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateMemberName},
+				Set:              hashCaseInsensitiveString,
+				DiffSuppressFunc: suppressAdminUsersDiff,
+				AtLeastOneOf:     []string{"admin_users", "admin_groups"},
+			},
+			"admin_groups": {
+				Type:             schema.TypeSet,
+				Description:      "Names of the admin groups, e.g. <domain>:group.<name>",
+				Optional:         true,
+				ForceNew:         true, // must to be true, because no update method
+				Elem:             &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateGroupName},
+				Set:              hashCaseInsensitiveString,
+				DiffSuppressFunc: suppressAdminUsersDiff,
+				AtLeastOneOf:     []string{"admin_users", "admin_groups"},
+			},
+			"ignore_admin_users_changes": {
+				Type:        schema.TypeBool,
+				Description: "Use admin_users and admin_groups only to create the domain, the admin role isn't read or compared afterwards (e.g. when it's managed by athenz_role)",
+				Optional:    true,
 			},
 			"ypm_id": {
 				Type:     schema.TypeInt,
 				Required: true,
 				ForceNew: true, // must to be true, because no update method
 			},
-		},
+		}),
 	}
 }
 
-func resourceTopLevelDomainCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceTopLevelDomainCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := d.Get("name").(string)
 	auditRef := d.Get("audit_ref").(string)
-	adminUsers := d.Get("admin_users").(*schema.Set).List()
+	adminUsers := append(d.Get("admin_users").(*schema.Set).List(), d.Get("admin_groups").(*schema.Set).List()...)
 	ypmId := int32(d.Get("ypm_id").(int))
 	topLevelDomainDetail := zms.TopLevelDomain{
 		Name:       zms.SimpleName(domainName),
 		AdminUsers: convertToZmsResourceNameList(adminUsers),
 		YpmId:      &ypmId,
 	}
+	if v, ok := d.GetOk("tags"); ok {
+		topLevelDomainDetail.Tags = expandTagsMap(v.(*schema.Set).List())
+	}
 	topLevelDomain, err := zmsClient.PostTopLevelDomain(auditRef, &topLevelDomainDetail)
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error creating Athenz Top Level Domain "+domainName, "", "the domain "+domainName)
 	}
 	if topLevelDomain == nil {
-		return fmt.Errorf("error creating Top Level Domain: %s", err)
+		return emptyResponseError("the domain " + domainName)
 	}
 	d.SetId(domainName)
-	return resourceTopLevelDomainRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourceTopLevelDomainRead)
 }
 
-func resourceTopLevelDomainRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceTopLevelDomainRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := d.Id()
+	lastModified := d.Get("modified").(string)
 	topLevelDomain, err := zmsClient.GetDomain(domainName)
 	switch v := err.(type) {
 	case rdl.ResourceError:
@@ -81,38 +135,84 @@ func resourceTopLevelDomainRead(d *schema.ResourceData, meta interface{}) error
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz Top level Domain: %s", v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz Top Level Domain "+d.Id(), "", "the domain "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if topLevelDomain == nil {
-		return fmt.Errorf("error retrieving Athenz Top Level Domain - Make sure your cert/key are valid")
+		return emptyResponseError("the domain " + d.Id())
 	}
 	if err = d.Set("name", domainName); err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	adminRole, err := zmsClient.GetRole(domainName, "admin")
-	if err != nil {
-		return err
+	if err = d.Set("resource_name", domainName); err != nil {
+		return diag.FromErr(err)
 	}
-	adminUsers := flattenRoleMembers(adminRole.RoleMembers)
-	if err = d.Set("admin_users", adminUsers); err != nil {
-		return err
+	if err = d.Set("modified", timestampToString(topLevelDomain.Modified)); err != nil {
+		return diag.FromErr(err)
 	}
-	if err = d.Set("ypm_id", int(*topLevelDomain.YpmId)); err != nil {
-		return err
+	if err = d.Set("tags", flattenTag(topLevelDomain.Tags)); err != nil {
+		return diag.FromErr(err)
+	}
+	if !d.Get("ignore_admin_users_changes").(bool) && adminRoleMayHaveChanged(lastModified, topLevelDomain) {
+		adminRole, err := zmsClient.GetRole(domainName, "admin")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		adminUsers, adminGroups := splitAdminMembers(adminRole.RoleMembers, d.Get("admin_users").(*schema.Set))
+		if err = d.Set("admin_users", adminUsers); err != nil {
+			return diag.FromErr(err)
+		}
+		if err = d.Set("admin_groups", adminGroups); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	ypmId := 0
+	if topLevelDomain.YpmId != nil {
+		ypmId = int(*topLevelDomain.YpmId)
+	}
+	if err = d.Set("ypm_id", ypmId); err != nil {
+		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func resourceTopLevelDomainDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceTopLevelDomainUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	auditRef := d.Get("audit_ref").(string)
+	if d.HasChange("tags") {
+		if err := updateDomainTags(zmsClient, d.Id(), auditRef, d.Get("tags").(*schema.Set).List()); err != nil {
+			return attributeError("tags", "error updating tags", err)
+		}
+	}
+	return resourceTopLevelDomainRead(ctx, d, meta)
+}
+
+func resourceTopLevelDomainDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := d.Id()
+	if d.Get("deletion_protection").(bool) {
+		return deletionProtectedError("the domain " + domainName)
+	}
 	auditRef := d.Get("audit_ref").(string)
+	if d.Get("recursive_destroy").(bool) {
+		if err := deleteDomainTree(zmsClient, domainName, d.Get("recursive_destroy_objects").(bool), auditRef); err != nil {
+			return diag.Diagnostics{{Severity: diag.Error, Summary: "error deleting the sub domains of Athenz Top Level Domain " + domainName, Detail: err.Error()}}
+		}
+	}
 	err := zmsClient.DeleteTopLevelDomain(domainName, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz Top Level Domain %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return deleteDomainDiagnostics(ctx, meta, err, "error deleting Athenz Top Level Domain "+d.Id(), "", d.Id())
 	}
 	return nil
 }
+
+// suppressAdminUsersDiff - with ignore_admin_users_changes, the admins of an existing domain aren't compared
+func suppressAdminUsersDiff(_, _, _ string, d *schema.ResourceData) bool {
+	return d.Id() != "" && d.Get("ignore_admin_users_changes").(bool)
+}
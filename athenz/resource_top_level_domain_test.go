@@ -46,7 +46,10 @@ func TestAccGroupTopLevelDomainBasic(t *testing.T) {
 					testAccCheckGroupTopLevelDomainExists(resourceName, &domain),
 					resource.TestCheckResourceAttr(resourceName, "name", topLevelDomainName),
 					resource.TestCheckResourceAttr(resourceName, "admin_users.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "admin_groups.#", "0"),
 					resource.TestCheckResourceAttr(resourceName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resourceName, "resource_name", topLevelDomainName),
+					resource.TestCheckResourceAttrSet(resourceName, "modified"),
 				),
 			},
 		},
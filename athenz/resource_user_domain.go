@@ -1,61 +1,79 @@
 package athenz
 
 import (
-	"fmt"
+	"context"
 	"log"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func ResourceUserDomain() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceUserDomainCreate,
-		Read:   resourceUserDomainRead,
-		Delete: resourceUserDomainDelete,
+		CreateContext: resourceUserDomainCreate,
+		ReadContext:   resourceUserDomainRead,
+		UpdateContext: resourceUserDomainUpdate,
+		DeleteContext: resourceUserDomainDelete,
+		CustomizeDiff: setModifiedOnChange,
+		Timeouts:      resourceTimeouts(),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importState,
 		},
 
-		Schema: map[string]*schema.Schema{
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
 			"name": {
+				Type:             schema.TypeString,
+				Description:      "Name of the standard user domain",
+				ValidateDiagFunc: validateSimpleDomainName,
+				Required:         true,
+				ForceNew:         true,
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Description: "Fully qualified name of the domain, e.g. home.<name>",
+				Computed:    true,
+			},
+			"modified": {
 				Type:        schema.TypeString,
-				Description: "Name of the standard user domain",
-				Required:    true,
-				ForceNew:    true,
+				Description: "The last modification timestamp of the domain",
+				Computed:    true,
 			},
 			"audit_ref": {
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true, // must to be true, because no update method
 				Default:  AUDIT_REF,
 			},
-		},
+			"tags": tagsSchema(),
+		}),
 	}
 }
 
-func resourceUserDomainCreate(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceUserDomainCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := d.Get("name").(string)
 	auditRef := d.Get("audit_ref").(string)
 	userDomainDetail := zms.UserDomain{
 		Name: zms.SimpleName(domainName),
 	}
+	if v, ok := d.GetOk("tags"); ok {
+		userDomainDetail.Tags = expandTagsMap(v.(*schema.Set).List())
+	}
 	userDomain, err := zmsClient.PostUserDomain(domainName, auditRef, &userDomainDetail)
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error creating Athenz User Domain "+domainName, "", "the domain "+domainName)
 	}
 	if userDomain == nil {
-		return fmt.Errorf("error creating User Domain: %s", err)
+		return emptyResponseError("the domain " + domainName)
 	}
 	d.SetId(PREFIX_USER_DOMAIN + domainName)
-	return resourceUserDomainRead(d, meta)
+	return readAfterCreate(ctx, d, meta, resourceUserDomainRead)
 }
 
-func resourceUserDomainRead(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceUserDomainRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := d.Id()
 	shortDomainName := shortName("", domainName, PREFIX_USER_DOMAIN)
 	userDomain, err := zmsClient.GetDomain(domainName)
@@ -66,27 +84,51 @@ func resourceUserDomainRead(d *schema.ResourceData, meta interface{}) error {
 			d.SetId("")
 			return nil
 		}
-		return fmt.Errorf("error retrieving Athenz User Domain: %s", v)
+		return zmsDiagnostics(ctx, meta, v, "error retrieving Athenz User Domain "+d.Id(), "", "the domain "+d.Id())
 	case rdl.Any:
-		return err
+		return diag.FromErr(err)
 	}
 
 	if userDomain == nil {
-		return fmt.Errorf("error retrieving Athenz User Domain - Make sure your cert/key are valid")
+		return emptyResponseError("the domain " + d.Id())
 	}
 	if err = d.Set("name", shortDomainName); err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err = d.Set("resource_name", domainName); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("modified", timestampToString(userDomain.Modified)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("tags", flattenTag(userDomain.Tags)); err != nil {
+		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func resourceUserDomainDelete(d *schema.ResourceData, meta interface{}) error {
-	zmsClient := meta.(client.ZmsClient)
+func resourceUserDomainUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	auditRef := d.Get("audit_ref").(string)
+	if d.HasChange("tags") {
+		if err := updateDomainTags(zmsClient, d.Id(), auditRef, d.Get("tags").(*schema.Set).List()); err != nil {
+			return attributeError("tags", "error updating tags", err)
+		}
+	}
+	return resourceUserDomainRead(ctx, d, meta)
+}
+
+func resourceUserDomainDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
 	domainName := shortName("", d.Id(), PREFIX_USER_DOMAIN)
 	auditRef := d.Get("audit_ref").(string)
 	err := zmsClient.DeleteUserDomain(domainName, auditRef)
+	if isNotFound(err) {
+		log.Printf("[WARN] Athenz User Domain %s is already deleted", d.Id())
+		return nil
+	}
 	if err != nil {
-		return err
+		return zmsDiagnostics(ctx, meta, err, "error deleting Athenz User Domain "+d.Id(), "", "the domain "+d.Id())
 	}
 	return nil
 }
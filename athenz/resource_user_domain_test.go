@@ -42,6 +42,8 @@ func TestAccGroupUserDomainBasic(t *testing.T) {
 					testAccCheckGroupUserDomainExists(resourceName, &domain),
 					resource.TestCheckResourceAttr(resourceName, "name", shortId),
 					resource.TestCheckResourceAttr(resourceName, "audit_ref", AUDIT_REF),
+					resource.TestCheckResourceAttr(resourceName, "resource_name", PREFIX_USER_DOMAIN+shortId),
+					resource.TestCheckResourceAttrSet(resourceName, "modified"),
 				),
 			},
 		},
@@ -0,0 +1,316 @@
+package athenz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// the paths of the ZMS objects of the type attribute, the other objects have a path attribute
+var rawObjectPaths = map[string]string{
+	"role":    "/domain/{domain}/role/{name}",
+	"group":   "/domain/{domain}/group/{name}",
+	"policy":  "/domain/{domain}/policy/{name}",
+	"service": "/domain/{domain}/service/{name}",
+	"entity":  "/domain/{domain}/entity/{name}",
+}
+
+var (
+	rawPathRegex      = regexp.MustCompile(`^(/[A-Za-z0-9_.:{}-]+)+$`)
+	rawPathParamRegex = regexp.MustCompile(`{([A-Za-z0-9_]+)}`)
+)
+
+// ResourceZmsRaw puts the JSON of an object of the ZMS API that has no dedicated resource, e.g. of a new
+// ZMS feature, and deletes it at the same path
+func ResourceZmsRaw() *schema.Resource {
+	types := make([]string, 0, len(rawObjectPaths))
+	for name := range rawObjectPaths {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return &schema.Resource{
+		CreateContext: resourceZmsRawCreate,
+		ReadContext:   resourceZmsRawRead,
+		UpdateContext: resourceZmsRawUpdate,
+		DeleteContext: resourceZmsRawDelete,
+		CustomizeDiff: customdiff.All(planRawResourcePath),
+		Timeouts:      resourceTimeouts(),
+		Importer: &schema.ResourceImporter{
+			StateContext: importZmsRawState,
+		},
+
+		Schema: suppressAuditRefOnlyChange(map[string]*schema.Schema{
+			"type": {
+				Type:         schema.TypeString,
+				Description:  "The type of the object, for the path of the type: " + strings.Join(types, ", "),
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(types, false),
+				ExactlyOneOf: []string{"type", "path"},
+			},
+			"path": {
+				Type:         schema.TypeString,
+				Description:  "The path of the object in the ZMS API, with the path parameters in braces, e.g. /domain/{domain}/role/{name}",
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(rawPathRegex, "must be a path of the ZMS API, e.g. /domain/{domain}/role/{name}"),
+			},
+			"path_parameters": {
+				Type:        schema.TypeMap,
+				Description: "The values of the path parameters, e.g. { domain = \"some_domain\", name = \"readers\" }",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"body": {
+				Type:         schema.TypeString,
+				Description:  "The JSON of the object, as the ZMS API accepts it",
+				Required:     true,
+				ValidateFunc: validation.StringIsJSON,
+				StateFunc:    normalizeJson,
+			},
+			"delete_on_destroy": {
+				Type:        schema.TypeBool,
+				Description: "Delete the object at its path when the resource is destroyed",
+				Optional:    true,
+				Default:     true,
+			},
+			"resource_path": {
+				Type:        schema.TypeString,
+				Description: "The path of the object, with the values of the path parameters",
+				Computed:    true,
+			},
+			"response": {
+				Type:        schema.TypeString,
+				Description: "The JSON of the object read from ZMS, with the attributes set by ZMS",
+				Computed:    true,
+			},
+			"audit_ref": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  AUDIT_REF,
+			},
+		}),
+	}
+}
+
+func normalizeJson(v interface{}) string {
+	normalized, err := structure.NormalizeJsonString(v)
+	if err != nil {
+		return v.(string)
+	}
+	return normalized
+}
+
+// rawResourcePath returns the path of the type or of the path attribute, with the escaped values of the path
+// parameters. every parameter is required, and every value is of a parameter
+func rawResourcePath(objectType string, path string, parameters map[string]interface{}) (string, error) {
+	if objectType != "" {
+		path = rawObjectPaths[objectType]
+	}
+	used := make(map[string]bool)
+	var missing []string
+	resolved := rawPathParamRegex.ReplaceAllStringFunc(path, func(param string) string {
+		name := strings.Trim(param, "{}")
+		used[name] = true
+		value, ok := parameters[name].(string)
+		if !ok || value == "" {
+			missing = append(missing, name)
+			return param
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("path_parameters: missing the value of %s of the path %s", strings.Join(missing, ", "), path)
+	}
+	for name := range parameters {
+		if !used[name] {
+			return "", fmt.Errorf("path_parameters: %s isn't a parameter of the path %s", name, path)
+		}
+	}
+	return resolved, nil
+}
+
+// planRawResourcePath plans the path of the object, a new path replaces the object. the type, the path and the
+// path parameters can be changed to a type with the same path, e.g. after an import
+func planRawResourcePath(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("type") || !d.NewValueKnown("path") || !d.NewValueKnown("path_parameters") {
+		if d.Id() == "" {
+			return d.SetNewComputed("resource_path")
+		}
+		if d.HasChange("type") || d.HasChange("path") || d.HasChange("path_parameters") {
+			if err := d.SetNewComputed("resource_path"); err != nil {
+				return err
+			}
+			return d.ForceNew("resource_path")
+		}
+		return nil
+	}
+	path, err := rawResourcePath(d.Get("type").(string), d.Get("path").(string), d.Get("path_parameters").(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+	if d.Id() == "" {
+		return d.SetNew("resource_path", path)
+	}
+	if old := d.Get("resource_path").(string); old != path {
+		if err = d.SetNew("resource_path", path); err != nil {
+			return err
+		}
+		return d.ForceNew("resource_path")
+	}
+	return nil
+}
+
+func resourceZmsRawCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	path, err := rawResourcePath(d.Get("type").(string), d.Get("path").(string), d.Get("path_parameters").(map[string]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	dn, _ := url.PathUnescape(domainOfRawPath(path))
+	if err = zmsClient.PutRaw(path, d.Get("audit_ref").(string), []byte(normalizeJson(d.Get("body")))); err != nil {
+		return zmsDiagnostics(ctx, meta, err, "error putting the ZMS object "+path, dn, "the object "+path)
+	}
+	d.SetId(path)
+	return readAfterCreate(ctx, d, meta, resourceZmsRawRead)
+}
+
+func resourceZmsRawRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	content, err := zmsClient.GetRaw(d.Id())
+	switch v := err.(type) {
+	case rdl.ResourceError:
+		if v.Code == 404 {
+			log.Printf("[WARN] the ZMS object %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		// the objects that ZMS puts but doesn't return, e.g. a meta, are only put
+		if v.Code == 405 {
+			log.Printf("[DEBUG] the ZMS object %s can't be read, keeping its state", d.Id())
+			return nil
+		}
+		return zmsDiagnostics(ctx, meta, v, "error retrieving the ZMS object "+d.Id(), "", "the object "+d.Id())
+	case rdl.Any:
+		return diag.FromErr(err)
+	}
+
+	var response interface{}
+	if err = json.Unmarshal(content, &response); err != nil {
+		return diag.Errorf("the ZMS object %s isn't JSON: %s", d.Id(), err)
+	}
+	// the body is the response limited to the configured attributes, so only a change of them is a drift
+	body := response
+	if configured := d.Get("body").(string); configured != "" {
+		var config interface{}
+		if err = json.Unmarshal([]byte(configured), &config); err == nil {
+			body = projectJson(config, response)
+		}
+	}
+	bodyJson, err := json.Marshal(body)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("body", normalizeJson(string(bodyJson))); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("response", normalizeJson(string(content))); err != nil {
+		return diag.FromErr(err)
+	}
+	if err = d.Set("resource_path", d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// projectJson returns the attributes of the actual object that are in the configured one. the elements of
+// arrays of the same length are projected one by one, and the other values are the actual ones
+func projectJson(config interface{}, actual interface{}) interface{} {
+	switch c := config.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return actual
+		}
+		projected := make(map[string]interface{}, len(c))
+		for key, value := range c {
+			if actualValue, ok := a[key]; ok {
+				projected[key] = projectJson(value, actualValue)
+			}
+		}
+		return projected
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(c) {
+			return actual
+		}
+		projected := make([]interface{}, len(a))
+		for i := range a {
+			projected[i] = projectJson(c[i], a[i])
+		}
+		return projected
+	}
+	return actual
+}
+
+func resourceZmsRawUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	if d.HasChange("body") {
+		dn, _ := url.PathUnescape(domainOfRawPath(d.Id()))
+		if err := zmsClient.PutRaw(d.Id(), d.Get("audit_ref").(string), []byte(normalizeJson(d.Get("body")))); err != nil {
+			return zmsDiagnostics(ctx, meta, err, "error putting the ZMS object "+d.Id(), dn, "the object "+d.Id())
+		}
+	}
+	return resourceZmsRawRead(ctx, d, meta)
+}
+
+func resourceZmsRawDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.Get("delete_on_destroy").(bool) {
+		log.Printf("[INFO] the ZMS object %s isn't deleted, delete_on_destroy is false", d.Id())
+		return nil
+	}
+	zmsClient := meta.(client.ZmsClient).WithContext(ctx)
+	err := zmsClient.DeleteRaw(d.Id(), d.Get("audit_ref").(string))
+	if isNotFound(err) {
+		log.Printf("[WARN] the ZMS object %s is already deleted", d.Id())
+		return nil
+	}
+	if err != nil {
+		dn, _ := url.PathUnescape(domainOfRawPath(d.Id()))
+		return zmsDiagnostics(ctx, meta, err, "error deleting the ZMS object "+d.Id(), dn, "the object "+d.Id())
+	}
+	return nil
+}
+
+// importZmsRawState imports the object of the path, e.g. /domain/some_domain/role/readers. its body is the
+// whole object read from ZMS, until the configured body replaces it with the next apply
+func importZmsRawState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if !rawPathRegex.MatchString(d.Id()) || rawPathParamRegex.MatchString(d.Id()) {
+		return nil, fmt.Errorf("invalid ZMS object ID %q, expected its path, e.g. /domain/some_domain/role/readers", d.Id())
+	}
+	if err := d.Set("delete_on_destroy", true); err != nil {
+		return nil, err
+	}
+	return importState(ctx, d, meta)
+}
+
+// domainOfRawPath returns the domain of a path of a domain object, e.g. some_domain of
+// /domain/some_domain/role/readers, or an empty string
+func domainOfRawPath(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "domain" {
+		return parts[1]
+	}
+	return ""
+}
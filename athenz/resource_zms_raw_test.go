@@ -0,0 +1,140 @@
+package athenz
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_resourceZmsRaw(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+
+	// the objects in ZMS by path, ZMS adds the modified time to the objects it returns
+	objects := map[string]map[string]interface{}{}
+	var calls []string
+	clientMock.EXPECT().PutRaw(gomock.Any(), AUDIT_REF, gomock.Any()).DoAndReturn(func(path, _ string, body []byte) error {
+		var object map[string]interface{}
+		ast.NilError(t, json.Unmarshal(body, &object))
+		object["modified"] = "2024-01-01T00:00:00.000Z"
+		objects[path] = object
+		calls = append(calls, "put "+path)
+		return nil
+	}).AnyTimes()
+	clientMock.EXPECT().GetRaw(gomock.Any()).DoAndReturn(func(path string) ([]byte, error) {
+		object, ok := objects[path]
+		if !ok {
+			return nil, rdl.ResourceError{Code: 404, Message: "not found"}
+		}
+		return json.Marshal(object)
+	}).AnyTimes()
+	clientMock.EXPECT().DeleteRaw(gomock.Any(), AUDIT_REF).DoAndReturn(func(path, _ string) error {
+		delete(objects, path)
+		calls = append(calls, "delete "+path)
+		return nil
+	}).AnyTimes()
+
+	config := func(name string, body string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":            "role",
+			"path_parameters": map[string]interface{}{"domain": "some_domain", "name": name},
+			"body":            body,
+		}
+	}
+	var state *terraform.InstanceState
+	apply := func(c map[string]interface{}) {
+		calls = nil
+		diff, err := ResourceZmsRaw().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), clientMock)
+		ast.NilError(t, err)
+		updated, diags := ResourceZmsRaw().Apply(context.Background(), state, diff, clientMock)
+		ast.Assert(t, !diags.HasError(), diags)
+		state = updated
+	}
+
+	// case: the object is put at the path of its type
+	apply(config("readers", `{"name": "some_domain:role.readers", "selfServe": true}`))
+	ast.DeepEqual(t, calls, []string{"put /domain/some_domain/role/readers"})
+	ast.Equal(t, state.ID, "/domain/some_domain/role/readers")
+	ast.Equal(t, state.Attributes["body"], `{"name":"some_domain:role.readers","selfServe":true}`)
+	ast.Equal(t, state.Attributes["response"], `{"modified":"2024-01-01T00:00:00.000Z","name":"some_domain:role.readers","selfServe":true}`)
+
+	// case: the attributes set by ZMS aren't a change, reordered attributes neither
+	diff, err := ResourceZmsRaw().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config("readers", `{"selfServe": true, "name": "some_domain:role.readers"}`)), clientMock)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || diff.Empty())
+
+	// case: a configured attribute changed in ZMS is a drift, that the apply puts again
+	objects["/domain/some_domain/role/readers"]["selfServe"] = false
+	d := ResourceZmsRaw().Data(state)
+	ast.Assert(t, !resourceZmsRawRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("body"), `{"name":"some_domain:role.readers","selfServe":false}`)
+	state = d.State()
+	apply(config("readers", `{"name": "some_domain:role.readers", "selfServe": true}`))
+	ast.DeepEqual(t, calls, []string{"put /domain/some_domain/role/readers"})
+	ast.Equal(t, objects["/domain/some_domain/role/readers"]["selfServe"], true)
+
+	// case: a new path replaces the object
+	apply(config("writers", `{"name": "some_domain:role.writers"}`))
+	ast.DeepEqual(t, calls, []string{"delete /domain/some_domain/role/readers", "put /domain/some_domain/role/writers"})
+
+	// case: the path parameters must match the path
+	c := config("writers", `{}`)
+	c["path_parameters"] = map[string]interface{}{"domain": "some_domain"}
+	_, err = ResourceZmsRaw().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), clientMock)
+	ast.ErrorContains(t, err, "missing the value of name")
+	c["path_parameters"] = map[string]interface{}{"domain": "some_domain", "name": "writers", "service": "api"}
+	_, err = ResourceZmsRaw().Diff(context.Background(), state, terraform.NewResourceConfigRaw(c), clientMock)
+	ast.ErrorContains(t, err, "service isn't a parameter")
+
+	// case: the object isn't deleted when delete_on_destroy is false
+	state.Attributes["delete_on_destroy"] = "false"
+	calls = nil
+	ast.Assert(t, !resourceZmsRawDelete(context.Background(), ResourceZmsRaw().Data(state), clientMock).HasError())
+	ast.Equal(t, len(calls), 0)
+}
+
+func Test_rawResourcePath(t *testing.T) {
+	path, err := rawResourcePath("", "/domain/{domain}/service/{service}/publickey/{id}", map[string]interface{}{
+		"domain": "some_domain", "service": "api", "id": "v 1",
+	})
+	ast.NilError(t, err)
+	ast.Equal(t, path, "/domain/some_domain/service/api/publickey/v%201")
+
+	path, err = rawResourcePath("group", "", map[string]interface{}{"domain": "some_domain", "name": "devs"})
+	ast.NilError(t, err)
+	ast.Equal(t, path, "/domain/some_domain/group/devs")
+}
+
+func Test_projectJson(t *testing.T) {
+	var config, actual interface{}
+	ast.NilError(t, json.Unmarshal([]byte(`{"name": "a", "members": [{"memberName": "user.jane"}], "meta": {"tags": {}}}`), &config))
+	ast.NilError(t, json.Unmarshal([]byte(`{"name": "a", "modified": "now", "members": [{"memberName": "user.jane", "active": true}], "meta": {"tags": {"a": 1}, "org": "o"}}`), &actual))
+	projected, err := json.Marshal(projectJson(config, actual))
+	ast.NilError(t, err)
+	ast.Equal(t, string(projected), `{"members":[{"memberName":"user.jane"}],"meta":{"tags":{}},"name":"a"}`)
+
+	// the elements of arrays of another length are the actual ones, and show as a change
+	ast.NilError(t, json.Unmarshal([]byte(`{"members": [{"memberName": "user.jane", "active": true}, {"memberName": "user.joe"}]}`), &actual))
+	projected, err = json.Marshal(projectJson(config, actual))
+	ast.NilError(t, err)
+	ast.Equal(t, string(projected), `{"members":[{"active":true,"memberName":"user.jane"},{"memberName":"user.joe"}]}`)
+}
+
+func Test_importZmsRawState(t *testing.T) {
+	d := ResourceZmsRaw().Data(nil)
+	d.SetId("/domain/some_domain/role/readers")
+	_, err := importZmsRawState(context.Background(), d, nil)
+	ast.NilError(t, err)
+	ast.Equal(t, d.Get("delete_on_destroy"), true)
+
+	d.SetId("/domain/{domain}/role/readers")
+	_, err = importZmsRawState(context.Background(), d, nil)
+	ast.ErrorContains(t, err, "invalid ZMS object ID")
+}
@@ -0,0 +1,180 @@
+package athenz
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// the acceptance tests name the objects they create test<random number>, e.g. test1234567
+const sweepPrefix = "test"
+
+// run the sweepers with: go test ./athenz -v -sweep=<domain>[,<domain>...]
+// the objects with the test prefix in the domains are deleted, and the sub domains of the domains with the prefix
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func init() {
+	resource.AddTestSweepers("athenz_policy", &resource.Sweeper{
+		Name: "athenz_policy",
+		F:    sweepPolicies,
+	})
+	// the policies are deleted first, so the roles aren't referenced by their assertions
+	resource.AddTestSweepers("athenz_role", &resource.Sweeper{
+		Name:         "athenz_role",
+		Dependencies: []string{"athenz_policy"},
+		F:            sweepRoles,
+	})
+	resource.AddTestSweepers("athenz_group", &resource.Sweeper{
+		Name:         "athenz_group",
+		Dependencies: []string{"athenz_role"},
+		F:            sweepGroups,
+	})
+	resource.AddTestSweepers("athenz_service", &resource.Sweeper{
+		Name: "athenz_service",
+		F:    sweepServices,
+	})
+	resource.AddTestSweepers("athenz_sub_domain", &resource.Sweeper{
+		Name: "athenz_sub_domain",
+		F:    sweepSubDomains,
+	})
+}
+
+// sweeperClient returns a zms client configured by the environment variables used by the provider
+func sweeperClient() (client.ZmsClient, error) {
+	url := os.Getenv("ATHENZ_ZMS_URL")
+	if url == "" {
+		return nil, fmt.Errorf("ATHENZ_ZMS_URL must be set for the sweepers")
+	}
+	cert := os.Getenv("ATHENZ_CERT")
+	if cert == "" {
+		cert = os.Getenv("HOME") + "/.athenz/cert"
+	}
+	key := os.Getenv("ATHENZ_KEY")
+	if key == "" {
+		key = os.Getenv("HOME") + "/.athenz/key"
+	}
+	return client.NewClient(url, cert, key, os.Getenv("ATHENZ_CA_CERT"))
+}
+
+func isSweepable(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), sweepPrefix)
+}
+
+func sweepPolicies(domain string) error {
+	zmsClient, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	policyNames, err := listPolicyNames(zmsClient, domain)
+	if err != nil {
+		return fmt.Errorf("error listing the policies of %s: %s", domain, err)
+	}
+	for _, name := range policyNames {
+		if !isSweepable(string(name)) {
+			continue
+		}
+		log.Printf("[INFO] deleting Policy %s%s%s", domain, POLICY_SEPARATOR, name)
+		if err = zmsClient.DeletePolicy(domain, string(name), AUDIT_REF); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting Policy %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func sweepRoles(domain string) error {
+	zmsClient, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	roleNames, err := listRoleNames(zmsClient, domain)
+	if err != nil {
+		return fmt.Errorf("error listing the roles of %s: %s", domain, err)
+	}
+	for _, name := range roleNames {
+		if !isSweepable(string(name)) {
+			continue
+		}
+		log.Printf("[INFO] deleting Role %s%s%s", domain, ROLE_SEPARATOR, name)
+		if err = zmsClient.DeleteRole(domain, string(name), AUDIT_REF); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting Role %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func sweepGroups(domain string) error {
+	zmsClient, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	groups, err := zmsClient.GetGroups(domain, nil)
+	if err != nil {
+		return fmt.Errorf("error listing the groups of %s: %s", domain, err)
+	}
+	for _, group := range groups.List {
+		name := shortName(domain, string(group.Name), GROUP_SEPARATOR)
+		if !isSweepable(name) {
+			continue
+		}
+		log.Printf("[INFO] deleting Group %s", group.Name)
+		if err = zmsClient.DeleteGroup(domain, name, AUDIT_REF); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting Group %s: %s", group.Name, err)
+		}
+	}
+	return nil
+}
+
+func sweepServices(domain string) error {
+	zmsClient, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	serviceNames, err := listServiceNames(zmsClient, domain)
+	if err != nil {
+		return fmt.Errorf("error listing the services of %s: %s", domain, err)
+	}
+	for _, name := range serviceNames {
+		if !isSweepable(string(name)) {
+			continue
+		}
+		log.Printf("[INFO] deleting Service %s%s%s", domain, SERVICE_SEPARATOR, name)
+		if err = zmsClient.DeleteServiceIdentity(domain, string(name), AUDIT_REF); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting Service %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func sweepSubDomains(domain string) error {
+	zmsClient, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	names, err := listDomainNames(zmsClient, domain+SUB_DOMAIN_SEPARATOR+sweepPrefix)
+	if err != nil {
+		return fmt.Errorf("error listing the sub domains of %s: %s", domain, err)
+	}
+	// a domain can't be deleted while it has sub domains, so the deeper ones are deleted first
+	sort.Slice(names, func(i, j int) bool {
+		return strings.Count(names[i], SUB_DOMAIN_SEPARATOR) > strings.Count(names[j], SUB_DOMAIN_SEPARATOR)
+	})
+	for _, name := range names {
+		parent, subDomain, err := parseDomainObjectId(name, "sub domain")
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] deleting Sub Domain %s", name)
+		if err = zmsClient.DeleteSubDomain(parent, subDomain, AUDIT_REF); err != nil && !isNotFound(err) {
+			return fmt.Errorf("error deleting Sub Domain %s: %s", name, err)
+		}
+	}
+	return nil
+}
@@ -1,39 +1,137 @@
 package athenz
 
 import (
+	"context"
+	"sort"
 	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// tagsSchema - a tag has a key and a list of values, as in ZMS. the tags are validated as ZMS does
+// with its default limits
+func tagsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		MaxItems: maxTags,
+		Set:      hashTag,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:             schema.TypeString,
+					Required:         true,
+					ValidateDiagFunc: validateTagKey,
+				},
+				"values": {
+					Type:     schema.TypeSet,
+					Required: true,
+					Elem:     &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: validateTagValue},
+					Set:      hashCaseInsensitiveString,
+				},
+			},
+		},
+	}
+}
+
+// dataSourceTagsSchema - the tags read by a data source, they aren't configured
+func dataSourceTagsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Computed: true,
+		Set:      hashTag,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"values": {
+					Type:     schema.TypeSet,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+					Set:      hashCaseInsensitiveString,
+				},
+			},
+		},
+	}
+}
+
+// hashTag - ZMS stores the tags in lowercase, so the case of the key and the values isn't considered as a change
+func hashTag(v interface{}) int {
+	m := v.(map[string]interface{})
+	var list []interface{}
+	switch values := m["values"].(type) {
+	case *schema.Set:
+		list = values.List()
+	case []interface{}:
+		list = values
+	}
+	values := make([]string, 0, len(list))
+	for _, value := range list {
+		values = append(values, strings.ToLower(value.(string)))
+	}
+	sort.Strings(values)
+	return schema.HashString(strings.ToLower(m["key"].(string)) + "=" + strings.Join(values, ","))
+}
+
+// sameTags compares the tags regardless of the case and the order of the values
+func sameTags(tags1, tags2 map[zms.CompoundName]*zms.TagValueList) bool {
+	hashes := func(tags map[zms.CompoundName]*zms.TagValueList) map[int]bool {
+		result := make(map[int]bool, len(tags))
+		for _, tag := range flattenTag(tags) {
+			result[hashTag(tag)] = true
+		}
+		return result
+	}
+	h1, h2 := hashes(tags1), hashes(tags2)
+	if len(h1) != len(h2) {
+		return false
+	}
+	for h := range h1 {
+		if !h2[h] {
+			return false
+		}
+	}
+	return true
+}
+
 // flattenTag - takes the tag form the zms and return a tag schema
-func flattenTag(tagsMap map[zms.CompoundName]*zms.TagValueList) map[string]interface{} {
-	tags := map[string]interface{}{}
+func flattenTag(tagsMap map[zms.CompoundName]*zms.TagValueList) []interface{} {
+	tags := make([]interface{}, 0, len(tagsMap))
 	for tagKey, valueList := range tagsMap {
-		tagValue := ""
-		for _, m := range valueList.List {
-			if tagValue != "" {
-				tagValue = tagValue + "," + string(m)
-			} else {
-				tagValue = string(m)
-			}
+		if valueList == nil || len(valueList.List) == 0 {
+			continue
 		}
-		if tagValue != "" {
-			tags[string(tagKey)] = tagValue
+		values := make([]interface{}, 0, len(valueList.List))
+		for _, m := range valueList.List {
+			values = append(values, string(m))
 		}
+		tags = append(tags, map[string]interface{}{
+			"key":    string(tagKey),
+			"values": values,
+		})
 	}
 	return tags
 }
 
-func expandRoleTags(tagsMap map[string]interface{}) map[zms.CompoundName]*zms.TagValueList {
-	return expandTagsMap(tagsMap)
+func expandRoleTags(tags []interface{}) map[zms.CompoundName]*zms.TagValueList {
+	return expandTagsMap(tags)
 }
-func expandTagsMap(tagsMap map[string]interface{}) map[zms.CompoundName]*zms.TagValueList {
+func expandTagsMap(tags []interface{}) map[zms.CompoundName]*zms.TagValueList {
 	roleTags := map[zms.CompoundName]*zms.TagValueList{}
-	for key, listVal := range tagsMap {
-		tags := makeTagsValue(listVal.(string))
-		if len(tags.List) > 0 {
-			roleTags[zms.CompoundName(key)] = tags
+	for _, val := range tags {
+		m := val.(map[string]interface{})
+		tagValues := m["values"].(*schema.Set).List()
+		list := make([]zms.TagCompoundValue, 0, len(tagValues))
+		for _, tagValue := range tagValues {
+			if tagValue.(string) != "" {
+				list = append(list, zms.TagCompoundValue(tagValue.(string)))
+			}
+		}
+		if len(list) > 0 {
+			roleTags[zms.CompoundName(m["key"].(string))] = &zms.TagValueList{List: list}
 		}
 	}
 	if len(roleTags) > 0 {
@@ -53,6 +151,24 @@ func makeTagsValue(tagsValues string) *zms.TagValueList {
 	return &zms.TagValueList{List: tagsList}
 }
 
+// upgradeTagsStateV0 converts the tags of schema version 0, a map of comma separated values, to a set of tags
+func upgradeTagsStateV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	tags := make([]interface{}, 0)
+	if tagsMap, ok := rawState["tags"].(map[string]interface{}); ok {
+		for key, tagValue := range tagsMap {
+			values := make([]interface{}, 0)
+			for _, value := range makeTagsValue(tagValue.(string)).List {
+				values = append(values, string(value))
+			}
+			if len(values) > 0 {
+				tags = append(tags, map[string]interface{}{"key": key, "values": values})
+			}
+		}
+	}
+	rawState["tags"] = tags
+	return rawState, nil
+}
+
 func convertTagComponentValueListToStringList(list []zms.TagCompoundValue) []string {
 	finalList := []string{}
 	for _, valueToRemove := range list {
@@ -1,6 +1,7 @@
 package athenz
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 
@@ -32,20 +33,21 @@ func Test_makeTagsValue(t *testing.T) {
 	ast.DeepEqual(t, makeTagsValue(""), &zms.TagValueList{List: []zms.TagCompoundValue{}})
 }
 
-//works if changing in line 59 from .(*schema.Set).List() to .([]interface)
 func Test_expandRoleTags(t *testing.T) {
-	actual := expandRoleTags(map[string]interface{}{"key1": "v1k1,v2k1", "key2": "v1k2,v2k2,v3k2"})
+	actual := expandRoleTags(makeTagsSchemaArr([]string{"key1", "key2"}, []int{2, 3}, []string{"v1k1", "v2k1", "v1k2", "v2k2", "v3k2"}))
 	expected := buildMapForSchemaTest([]string{"key1", "key2"}, []int{2, 3}, []string{"v1k1", "v2k1", "v1k2", "v2k2", "v3k2"})
 	checkMapEquals(t, expected, actual)
-	actual = expandRoleTags(map[string]interface{}{"key1": "", "key2": "v1k2,v2k2,v3k2"})
+	actual = expandRoleTags(makeTagsSchemaArr([]string{"key1", "key2"}, []int{0, 3}, []string{"v1k2", "v2k2", "v3k2"}))
 	expected = buildMapForSchemaTest([]string{"key2"}, []int{3}, []string{"v1k2", "v2k2", "v3k2"})
 	checkMapEquals(t, expected, actual)
-	actual = expandRoleTags(map[string]interface{}{"key1": "v1k2,v2k2,v3k2", "key2": ""})
-	expected = buildMapForSchemaTest([]string{"key1"}, []int{3}, []string{"v1k2", "v2k2", "v3k2"})
-	checkMapEquals(t, expected, actual)
-	actual = expandRoleTags(map[string]interface{}{"key1": "v1k2,v2k2,v3k2", "key2": "yy,yy1", "key3": "zz1,zz2"})
+	assert.Len(t, actual, 1)
+	actual = expandRoleTags(makeTagsSchemaArr([]string{"key1", "key2", "key3"}, []int{3, 2, 2}, []string{"v1k2", "v2k2", "v3k2", "yy", "yy1", "zz1", "zz2"}))
 	expected = buildMapForSchemaTest([]string{"key1", "key2", "key3"}, []int{3, 2, 2}, []string{"v1k2", "v2k2", "v3k2", "yy", "yy1", "zz1", "zz2"})
 	checkMapEquals(t, expected, actual)
+	// a value may contain a comma
+	actual = expandRoleTags(makeTagsSchemaArr([]string{"key1"}, []int{2}, []string{"a,b", "c"}))
+	expected = buildMapForSchemaTest([]string{"key1"}, []int{2}, []string{"a,b", "c"})
+	checkMapEquals(t, expected, actual)
 }
 
 func checkMapEquals(t *testing.T, expected map[zms.CompoundName]*zms.TagValueList, actual map[zms.CompoundName]*zms.TagValueList) {
@@ -55,17 +57,35 @@ func checkMapEquals(t *testing.T, expected map[zms.CompoundName]*zms.TagValueLis
 }
 
 func Test_flattenTag(t *testing.T) {
-	assert.EqualValues(t,
-		map[string]interface{}{
-			"key1": "v1k1,v2k1",
-			"key2": "v1k2,v2k2,v3k2"},
+	assert.ElementsMatch(t,
+		[]interface{}{
+			map[string]interface{}{"key": "key1", "values": []interface{}{"v1k1", "v2k1"}},
+			map[string]interface{}{"key": "key2", "values": []interface{}{"v1k2", "v2k2", "v3k2"}},
+		},
 		flattenTag(buildMapForSchemaTest([]string{"key1", "key2"}, []int{2, 3}, []string{"v1k1", "v2k1", "v1k2", "v2k2", "v3k2"})))
 
 	assert.EqualValues(t,
-		map[string]interface{}{"key2": "v1k2,v2k2,v3k2"},
+		[]interface{}{map[string]interface{}{"key": "key2", "values": []interface{}{"v1k2", "v2k2", "v3k2"}}},
 		flattenTag(buildMapForSchemaTest([]string{"key1", "key2"}, []int{0, 3}, []string{"v1k2", "v2k2", "v3k2"})))
 }
 
+func Test_upgradeTagsStateV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"name": "role1",
+		"tags": map[string]interface{}{"key1": "v1,v2", "key2": ""},
+	}
+	actual, err := upgradeTagsStateV0(context.Background(), rawState, nil)
+	ast.NilError(t, err)
+	assert.EqualValues(t, map[string]interface{}{
+		"name": "role1",
+		"tags": []interface{}{map[string]interface{}{"key": "key1", "values": []interface{}{"v1", "v2"}}},
+	}, actual)
+
+	actual, err = upgradeTagsStateV0(context.Background(), map[string]interface{}{"name": "role1"}, nil)
+	ast.NilError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"name": "role1", "tags": []interface{}{}}, actual)
+}
+
 func buildMapForSchemaTest(keys []string, sizes []int, val []string) map[zms.CompoundName]*zms.TagValueList {
 	finalMap := map[zms.CompoundName]*zms.TagValueList{}
 	finalValues := makeZmsTagValueList(sizes, val)
@@ -113,3 +133,30 @@ func makeTagsSchemaArr(keys []string, sizes []int, values []string) []interface{
 	}
 	return finalArr
 }
+
+func Test_hashTag(t *testing.T) {
+	configTag := map[string]interface{}{
+		"key":    "Owner",
+		"values": schema.NewSet(hashCaseInsensitiveString, []interface{}{"Team-A", "team-b"}),
+	}
+	zmsTag := map[string]interface{}{
+		"key":    "owner",
+		"values": []interface{}{"team-b", "team-a"},
+	}
+	ast.Equal(t, hashTag(configTag), hashTag(zmsTag))
+
+	zmsTag["values"] = []interface{}{"team-a"}
+	ast.Assert(t, hashTag(configTag) != hashTag(zmsTag))
+}
+
+func Test_dataSourceTagsSchema(t *testing.T) {
+	for name, tags := range map[string]*schema.Schema{
+		"athenz_domain": DataSourceDomain().Schema["tags"],
+		"athenz_role":   DataSourceRole().Schema["tags"],
+		"athenz_roles":  DataSourceRoles().Schema["roles"].Elem.(*schema.Resource).Schema["tags"],
+	} {
+		// the tags of a data source are read, they can't be configured
+		ast.Assert(t, tags.Computed && !tags.Optional, name)
+		ast.Equal(t, tags.MaxItems, 0, name)
+	}
+}
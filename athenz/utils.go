@@ -1,14 +1,22 @@
 package athenz
 
 import (
+	"context"
 	b64 "encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
 )
 
 func getGroupsNames(zmsGroupList []*zms.Group) []string {
@@ -26,6 +34,14 @@ func convertEntityNameListToStringList(entityNames []zms.EntityName) []string {
 	return rolesNames
 }
 
+func expandStringSet(set *schema.Set) []string {
+	values := make([]string, 0, set.Len())
+	for _, value := range set.List() {
+		values = append(values, value.(string))
+	}
+	return values
+}
+
 func shortName(domainName string, en string, separator string) string {
 	shortName := en
 	if strings.HasPrefix(shortName, domainName+separator) {
@@ -38,12 +54,11 @@ func splitServiceId(serviceId string) (string, string) {
 	return splitId(serviceId, SERVICE_SEPARATOR)
 }
 
-func splitSubDomainId(subDomainId string) (string, string) {
-	return splitId(subDomainId, SUB_DOMAIN_SEPARATOR)
-}
-
 func splitId(id, separator string) (string, string) {
 	indexOfPrefixEnd := strings.LastIndex(id, separator)
+	if indexOfPrefixEnd < 0 {
+		return "", id
+	}
 	prefix := id[:indexOfPrefixEnd]
 	shortName := id[indexOfPrefixEnd+1:]
 	return prefix, shortName
@@ -54,11 +69,21 @@ func splitId(id, separator string) (string, string) {
 // Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
 func expandRoleMembers(configured []interface{}) []*zms.RoleMember {
 	roleMembers := make([]*zms.RoleMember, 0, len(configured))
-	for _, v := range configured {
-		val, ok := v.(string)
-		if ok && val != "" {
-			roleMember := zms.NewRoleMember()
-			roleMember.MemberName = zms.MemberName(val)
+	// the members are allocated at once, a role may have thousands of them
+	allocated := make([]zms.RoleMember, len(configured))
+	for i, v := range configured {
+		switch val := v.(type) {
+		case string:
+			if val != "" {
+				roleMember := zms.NewRoleMember(&allocated[i])
+				roleMember.MemberName = zms.MemberName(val)
+				roleMembers = append(roleMembers, roleMember)
+			}
+		case map[string]interface{}:
+			roleMember := zms.NewRoleMember(&allocated[i])
+			roleMember.MemberName = zms.MemberName(val["name"].(string))
+			roleMember.Expiration = expandMemberDate(val["expiration"].(string))
+			roleMember.ReviewReminder = expandMemberDate(val["review"].(string))
 			roleMembers = append(roleMembers, roleMember)
 		}
 	}
@@ -73,6 +98,230 @@ func flattenRoleMembers(list []*zms.RoleMember) []interface{} {
 	return roleMembers
 }
 
+// splitAdminMembers returns the members of the admin role split to admin_users and admin_groups.
+// a group that is configured in admin_users is kept there
+func splitAdminMembers(list []*zms.RoleMember, stateAdminUsers *schema.Set) ([]interface{}, []interface{}) {
+	adminUsers := make([]interface{}, 0, len(list))
+	adminGroups := make([]interface{}, 0)
+	for _, m := range list {
+		name := string(m.MemberName)
+		if strings.Contains(name, GROUP_SEPARATOR) && !stateAdminUsers.Contains(name) {
+			adminGroups = append(adminGroups, name)
+		} else {
+			adminUsers = append(adminUsers, name)
+		}
+	}
+	return adminUsers, adminGroups
+}
+
+// managedMemberNames returns the names of the members in the members attribute or the member blocks of a role or a group,
+// without the unmanaged members of the previous read: they're in the state when ignore_unmanaged_members isn't set
+func managedMemberNames(d *schema.ResourceData) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range d.Get("members").(*schema.Set).List() {
+		names[strings.ToLower(v.(string))] = true
+	}
+	for _, v := range d.Get("member").(*schema.Set).List() {
+		names[strings.ToLower(v.(map[string]interface{})["name"].(string))] = true
+	}
+	for _, v := range d.Get("unmanaged_members").(*schema.Set).List() {
+		delete(names, strings.ToLower(v.(string)))
+	}
+	return names
+}
+
+// keepUnmanagedMembers returns the members to remove without the unmanaged members of the previous read when
+// ignore_unmanaged_members is set, e.g. by the same apply: the state has the unmanaged members until then
+func keepUnmanagedMembers(d *schema.ResourceData, remove []interface{}) []interface{} {
+	if !d.Get("ignore_unmanaged_members").(bool) {
+		return remove
+	}
+	o, _ := d.GetChange("unmanaged_members")
+	unmanaged := o.(*schema.Set)
+	kept := make([]interface{}, 0, len(remove))
+	for _, v := range remove {
+		name, ok := v.(string)
+		if !ok {
+			name = v.(map[string]interface{})["name"].(string)
+		}
+		if unmanaged.Contains(name) {
+			log.Printf("[INFO] keeping the unmanaged member %s, ignore_unmanaged_members is set", name)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	return kept
+}
+
+// membershipLapsed returns true for a membership that is in ZMS but doesn't grant access anymore: it's expired
+// or disabled by the system, e.g. as the principal was deleted
+func membershipLapsed(expiration *rdl.Timestamp, systemDisabled *int32) bool {
+	return (expiration != nil && expiration.Time.Before(time.Now())) || (systemDisabled != nil && *systemDisabled != 0)
+}
+
+// activeRoleMembers returns the members of the role without the lapsed memberships, so force_sync adds them again.
+// the members configured with an elapsed date are kept: added again, they would lapse again and show on every plan
+func activeRoleMembers(list []*zms.RoleMember, elapsed map[string]bool) []*zms.RoleMember {
+	members := make([]*zms.RoleMember, 0, len(list))
+	for _, m := range list {
+		if elapsed[strings.ToLower(string(m.MemberName))] || !membershipLapsed(m.Expiration, m.SystemDisabled) {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// elapsedMemberNames returns the names of the member blocks in the state whose expiration is a date, not a
+// duration, that is already elapsed
+func elapsedMemberNames(d *schema.ResourceData) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range d.Get("member").(*schema.Set).List() {
+		m := v.(map[string]interface{})
+		expiration := m["expiration"].(string)
+		if expiration == "" || isDuration(expiration) {
+			continue
+		}
+		if date, err := parseMemberDate(expiration); err == nil && date.Before(time.Now()) {
+			names[strings.ToLower(m["name"].(string))] = true
+		}
+	}
+	return names
+}
+
+// splitUnmanagedRoleMembers splits the members of a role to the managed members and the names of the others,
+// e.g. members that were added outside of terraform
+func splitUnmanagedRoleMembers(list []*zms.RoleMember, managed map[string]bool) ([]*zms.RoleMember, []interface{}) {
+	members := make([]*zms.RoleMember, 0, len(list))
+	unmanaged := make([]interface{}, 0)
+	for _, m := range list {
+		if managed[strings.ToLower(string(m.MemberName))] {
+			members = append(members, m)
+		} else {
+			unmanaged = append(unmanaged, string(m.MemberName))
+		}
+	}
+	return members, unmanaged
+}
+
+// flattenRoleMemberObjects returns the member blocks of the role, the dates of members that are already
+// in the state are taken from there as long as they match ZMS (see stateMemberDate)
+func flattenRoleMemberObjects(list []*zms.RoleMember, stateMembers []interface{}) []interface{} {
+	state := make(map[string]map[string]interface{}, len(stateMembers))
+	for _, v := range stateMembers {
+		m := v.(map[string]interface{})
+		state[strings.ToLower(m["name"].(string))] = m
+	}
+	roleMembers := make([]interface{}, 0, len(list))
+	for _, m := range list {
+		name := string(m.MemberName)
+		roleMember := map[string]interface{}{
+			"name":       name,
+			"expiration": flattenMemberDate(m.Expiration),
+			"review":     flattenMemberDate(m.ReviewReminder),
+		}
+		if stateMember, ok := state[strings.ToLower(name)]; ok {
+			roleMember["expiration"] = stateMemberDate(stateMember["expiration"].(string), m.Expiration)
+			roleMember["review"] = stateMemberDate(stateMember["review"].(string), m.ReviewReminder)
+		}
+		roleMembers = append(roleMembers, roleMember)
+	}
+	return roleMembers
+}
+
+func roleMembersHaveDates(list []*zms.RoleMember) bool {
+	for _, m := range list {
+		if m.Expiration != nil || m.ReviewReminder != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hashRoleMember hashes the normalized dates, so a date written in another format isn't considered as a change
+func hashRoleMember(v interface{}) int {
+	m := v.(map[string]interface{})
+	return schema.HashString(strings.ToLower(m["name"].(string)) + "," + normalizeMemberDate(m["expiration"].(string)) + "," + normalizeMemberDate(m["review"].(string)))
+}
+
+// isPendingApproval returns true when ZMS accepted a membership that has to be approved, e.g. a member
+// added by a principal that isn't an admin of a role with review enabled
+func isPendingApproval(err error) bool {
+	v, ok := err.(rdl.ResourceError)
+	return ok && v.Code == 202
+}
+
+// setPendingMembers adds the members whose membership is pending approval to pending_members of a role or
+// a group, so they aren't added again by the next plan, and returns a warning about them
+func setPendingMembers(d *schema.ResourceData, pending []string) diag.Diagnostics {
+	names := d.Get("pending_members").(*schema.Set).List()
+	for _, name := range pending {
+		names = append(names, name)
+	}
+	if err := d.Set("pending_members", names); err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("the membership of %s in %s is pending approval", strings.Join(pending, ", "), d.Id()),
+		Detail:   "the members are kept in pending_members until an admin approves or rejects the membership, a rejected member is added again by the next apply",
+	}}
+}
+
+// pendingRoleMembers returns the members of the role that are still pending approval, out of the pending members
+// in the state
+func pendingRoleMembers(zmsClient client.ZmsClient, dn string, rn string, statePending *schema.Set) ([]*zms.RoleMember, error) {
+	role, err := zmsClient.GetRoleWithPendingMembers(dn, rn)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*zms.RoleMember, 0)
+	for _, m := range role.RoleMembers {
+		if m.Approved != nil && !*m.Approved && statePending.Contains(string(m.MemberName)) {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// isNotFound returns true for a 404 response of ZMS, e.g. an object that was already deleted
+func isNotFound(err error) bool {
+	v, ok := err.(rdl.ResourceError)
+	return ok && v.Code == 404
+}
+
+// sameRole returns true when the existing role has the members and the tags of the expected role.
+// the membership dates aren't compared, as a duration is computed again on every create
+func sameRole(existing *zms.Role, expected *zms.Role) bool {
+	if len(existing.RoleMembers) != len(expected.RoleMembers) {
+		return false
+	}
+	members := make(map[string]bool, len(existing.RoleMembers))
+	for _, m := range existing.RoleMembers {
+		members[strings.ToLower(string(m.MemberName))] = true
+	}
+	for _, m := range expected.RoleMembers {
+		if !members[strings.ToLower(string(m.MemberName))] {
+			return false
+		}
+	}
+	return sameTags(existing.Tags, expected.Tags)
+}
+
+// checkNotModified returns an error when an object was modified after the time it was last read, as a whole
+// object update would override the change
+func checkNotModified(id string, lastModified string, modified *rdl.Timestamp) error {
+	if lastModified != "" && timestampToString(modified) != lastModified {
+		return fmt.Errorf("%s was modified at %s, after it was last read (%s). refresh the state and plan again to see the changes", id, timestampToString(modified), lastModified)
+	}
+	return nil
+}
+
+// hashCaseInsensitiveString - ZMS stores the principal names in lowercase, so "User.John" in the
+// configuration is the same element as "user.john" returned by ZMS
+func hashCaseInsensitiveString(v interface{}) int {
+	return schema.HashString(strings.ToLower(v.(string)))
+}
+
 func convertToPublicKeyEntryList(publicKeys []interface{}) []*zms.PublicKeyEntry {
 	publicKeyEntryList := make([]*zms.PublicKeyEntry, 0, len(publicKeys))
 	for _, val := range publicKeys {
@@ -99,41 +348,123 @@ func flattenPublicKeyEntryList(publicKeysEntry []*zms.PublicKeyEntry) []interfac
 	return publicKeys
 }
 
+// ZMS expects public keys as a PEM block encoded with the "ybase64" alphabet
+var ybase64Encoding = b64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789._").WithPadding('-')
+
+// convertToKeyBase64 accepts either a PEM encoded public key (e.g. the output of tls_private_key)
+// or a key that is already ybase64 encoded, and returns the ybase64 value ZMS expects
 func convertToKeyBase64(keyValue string) string {
-	keyBytes := []byte(strings.Trim(keyValue, "\n"))
-	encodeChars := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789._"
-	return b64.NewEncoding(encodeChars).WithPadding('-').EncodeToString(keyBytes)
+	keyValue = normalizeKeyValue(keyValue)
+	if !isPemKey(keyValue) {
+		return keyValue
+	}
+	return ybase64Encoding.EncodeToString([]byte(keyValue))
 }
 
+// convertToDecodedKey returns the PEM encoded public key of a ybase64 value returned from ZMS.
+// keys registered with standard base64 encoding are decoded as well
 func convertToDecodedKey(keyValue string) string {
-	keyBytes, _ := b64.StdEncoding.DecodeString(keyValue)
+	keyBytes, err := ybase64Encoding.DecodeString(keyValue)
+	if err != nil {
+		keyBytes, _ = b64.StdEncoding.DecodeString(keyValue)
+	}
 	return string(keyBytes)
 }
 
+func isPemKey(keyValue string) bool {
+	return strings.HasPrefix(keyValue, "-----BEGIN ")
+}
+
+// normalizeKeyValue removes the line ending differences (surrounding whitespaces, CRLF) between key values
+func normalizeKeyValue(keyValue string) string {
+	return strings.TrimSpace(strings.ReplaceAll(keyValue, "\r\n", "\n"))
+}
+
+// normalizePublicKey returns the PEM form of a public key given either as PEM or as ybase64
+func normalizePublicKey(keyValue string) string {
+	keyValue = normalizeKeyValue(keyValue)
+	if isPemKey(keyValue) {
+		return keyValue
+	}
+	return normalizeKeyValue(convertToDecodedKey(keyValue))
+}
+
+func validatePublicKey(val interface{}, key string) (ws []string, errs []error) {
+	block, _ := pem.Decode([]byte(normalizePublicKey(val.(string))))
+	if block == nil || !strings.HasSuffix(block.Type, "PUBLIC KEY") {
+		errs = append(errs, fmt.Errorf("%s must be a PEM encoded public key (or its ybase64 encoding)", key))
+	}
+	return
+}
+
+// hashPublicKey - the set function of the public keys, so the same key given as PEM or as ybase64,
+// with or without a trailing new line, isn't considered as a change
+func hashPublicKey(v interface{}) int {
+	m := v.(map[string]interface{})
+	return schema.HashString(m["key_id"].(string) + "," + normalizePublicKey(m["key_value"].(string)))
+}
+
 // adapted from https://github.com/terraform-providers/terraform-provider-aws/blob/master/aws/resource_aws_autoscaling_group.go
-func updateRoleMembers(dn string, rn string, remove []*zms.RoleMember, add []*zms.RoleMember, auditRef string, zmsClient client.ZmsClient) error {
+// updateRoleMembers returns the names of the added members whose membership is pending approval
+func updateRoleMembers(dn string, rn string, remove []*zms.RoleMember, add []*zms.RoleMember, auditRef string, zmsClient client.ZmsClient) ([]string, error) {
+	// a member whose dates were changed is updated by adding it again
+	added := make(map[zms.MemberName]bool, len(add))
+	for _, m := range add {
+		added[zms.MemberName(strings.ToLower(string(m.MemberName)))] = true
+	}
 	if len(remove) > 0 {
 		for _, m := range remove {
 			name := m.MemberName
+			if added[zms.MemberName(strings.ToLower(string(name)))] {
+				continue
+			}
 			err := zmsClient.DeleteMembership(dn, rn, name, auditRef)
-			if err != nil {
-				return fmt.Errorf("error removing membership: %s", err)
+			if err != nil && !isNotFound(err) {
+				return nil, fmt.Errorf("error removing membership: %s", err)
 			}
 		}
 	}
+	pending := make([]string, 0)
 	if len(add) > 0 {
 		for _, m := range add {
 			var member zms.Membership
 			name := m.MemberName
 			member.MemberName = name
 			member.RoleName = zms.ResourceName(rn)
+			member.Expiration = m.Expiration
+			member.ReviewReminder = m.ReviewReminder
 			err := zmsClient.PutMembership(dn, rn, name, auditRef, &member)
+			if isPendingApproval(err) {
+				pending = append(pending, string(name))
+				continue
+			}
 			if err != nil {
-				return err
+				return pending, err
 			}
 		}
 	}
-	return nil
+	return pending, nil
+}
+
+// putRoleMembers applies the member changes with a single PutRole of the role with its whole member list,
+// instead of a request per member. the members that aren't changed are kept as they are in ZMS
+func putRoleMembers(dn string, rn string, remove []*zms.RoleMember, add []*zms.RoleMember, auditRef string, zmsClient client.ZmsClient) error {
+	role, err := zmsClient.GetRole(dn, rn)
+	if err != nil {
+		return err
+	}
+	changed := make(map[string]bool, len(remove)+len(add))
+	for _, m := range append(append([]*zms.RoleMember{}, remove...), add...) {
+		changed[strings.ToLower(string(m.MemberName))] = true
+	}
+	members := make([]*zms.RoleMember, 0, len(role.RoleMembers)+len(add))
+	for _, m := range role.RoleMembers {
+		if !changed[strings.ToLower(string(m.MemberName))] {
+			members = append(members, m)
+		}
+	}
+	role.RoleMembers = append(members, add...)
+	return zmsClient.PutRole(dn, rn, auditRef, role)
 }
 
 //no double values
@@ -141,18 +472,14 @@ func compareStringSets(set1 []string, set2 []string) bool {
 	if len(set1) != len(set2) {
 		return false
 	}
-	check := false
+	values := make(map[string]bool, len(set2))
+	for _, val := range set2 {
+		values[val] = true
+	}
 	for _, val := range set1 {
-		for _, val2 := range set2 {
-			if val == val2 {
-				check = true
-				break
-			}
-		}
-		if !check {
+		if !values[val] {
 			return false
 		}
-		check = false
 	}
 	return true
 }
@@ -192,3 +519,119 @@ func handleChange(d *schema.ResourceData, key string) (*schema.Set, *schema.Set)
 	ns := n.(*schema.Set)
 	return os, ns
 }
+
+// suppressAuditRefOnlyChange handles the audit_ref as a metadata of the change. when the audit_ref is
+// the only changed attribute, no diff is shown, and the new audit_ref is sent with the next change of the resource
+func suppressAuditRefOnlyChange(resourceSchema map[string]*schema.Schema) map[string]*schema.Schema {
+	keys := make([]string, 0, len(resourceSchema))
+	for key, attribute := range resourceSchema {
+		if key != "audit_ref" && (attribute.Required || attribute.Optional) {
+			keys = append(keys, key)
+		}
+	}
+	resourceSchema["audit_ref"].DiffSuppressFunc = func(_, _, _ string, d *schema.ResourceData) bool {
+		return d.Id() != "" && !d.HasChanges(keys...)
+	}
+	return resourceSchema
+}
+
+// setModifiedOnChange - any change of the resource updates its modified timestamp in ZMS
+func setModifiedOnChange(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() != "" && len(d.GetChangedKeysPrefix("")) > 0 {
+		return d.SetNewComputed("modified")
+	}
+	return nil
+}
+
+// setDomainModifiedOnChange - a change of the resource updates the modified timestamp of its domain, which is
+// kept in the state in the fast refresh mode
+func setDomainModifiedOnChange(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() != "" && d.Get("domain_modified").(string) != "" && len(d.GetChangedKeysPrefix("")) > 0 {
+		return d.SetNewComputed("domain_modified")
+	}
+	return nil
+}
+
+// skipRefresh returns true in the fast refresh mode when the domain dn wasn't changed since the last read of
+// the resource, so the resource is kept as it is in the state. ZMS updates the modification timestamp of a
+// domain with every change in the domain. otherwise the current timestamp is kept in the state with the read
+func skipRefresh(zmsClient client.ZmsClient, d *schema.ResourceData, meta interface{}, dn string) bool {
+	if !fastRefreshEnabled(meta) {
+		return false
+	}
+	domain, err := zmsClient.GetDomain(dn)
+	if err != nil || domain == nil {
+		// the error is returned by the read of the resource
+		return false
+	}
+	modified := timestampToString(domain.Modified)
+	if modified != "" && modified == d.Get("domain_modified").(string) {
+		log.Printf("[DEBUG] the domain %s isn't modified since the last read of %s, it isn't read again", dn, d.Id())
+		return true
+	}
+	if err = d.Set("domain_modified", modified); err != nil {
+		log.Printf("[WARN] can't set the domain_modified of %s: %s", d.Id(), err)
+	}
+	return false
+}
+
+// partialUpdate returns the error of an update that may have failed halfway (e.g. after some members were removed
+// and before the others were added). without it the planned values are kept in the state as if they were applied,
+// so the resource is read again to record what was actually changed. if it can't be read, the previous state is kept
+func partialUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}, read schema.ReadContextFunc, diags diag.Diagnostics) diag.Diagnostics {
+	id := d.Id()
+	if readDiags := read(ctx, d, meta); readDiags.HasError() || d.Id() == "" {
+		log.Printf("[WARN] can't read %s after a failed update, keeping the previous state", id)
+		d.SetId(id)
+		d.Partial(true)
+	}
+	return diags
+}
+
+// readAfterCreate retries the read of a created resource while it isn't found, as the read may reach
+// a ZMS server that the creation wasn't replicated to yet. the resource is kept in the state meanwhile
+func readAfterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}, read schema.ReadContextFunc) diag.Diagnostics {
+	id := d.Id()
+	var diags diag.Diagnostics
+	err := resource.RetryContext(ctx, READ_AFTER_CREATE_TIMEOUT, func() *resource.RetryError {
+		diags = read(ctx, d, meta)
+		if !diags.HasError() && d.Id() == "" {
+			d.SetId(id)
+			return resource.RetryableError(fmt.Errorf("the created resource %s is not found yet", id))
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
+}
+
+func resourceTimeouts() *schema.ResourceTimeout {
+	return &schema.ResourceTimeout{
+		Create: schema.DefaultTimeout(DEFAULT_TIMEOUT),
+		Read:   schema.DefaultTimeout(DEFAULT_TIMEOUT),
+		Update: schema.DefaultTimeout(DEFAULT_TIMEOUT),
+		Delete: schema.DefaultTimeout(DEFAULT_TIMEOUT),
+	}
+}
+
+// attributeError returns the error of an attribute change with the path of the attribute, so terraform
+// shows it next to the attribute in the configuration
+func attributeError(attribute string, summary string, err error) diag.Diagnostics {
+	return diag.Diagnostics{
+		{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        err.Error(),
+			AttributePath: cty.GetAttrPath(attribute),
+		},
+	}
+}
+
+func timestampToString(timestamp *rdl.Timestamp) string {
+	if timestamp == nil {
+		return ""
+	}
+	return timestamp.String()
+}
@@ -0,0 +1,119 @@
+package athenz
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+)
+
+// the domain meta is replaced as a whole, so the current attributes of the domain are sent with the change
+func domainMetaOf(domain *zms.Domain) *zms.DomainMeta {
+	return &zms.DomainMeta{
+		Description:           domain.Description,
+		Org:                   domain.Org,
+		Enabled:               domain.Enabled,
+		AuditEnabled:          domain.AuditEnabled,
+		Account:               domain.Account,
+		YpmId:                 domain.YpmId,
+		ApplicationId:         domain.ApplicationId,
+		CertDnsDomain:         domain.CertDnsDomain,
+		MemberExpiryDays:      domain.MemberExpiryDays,
+		TokenExpiryMins:       domain.TokenExpiryMins,
+		ServiceCertExpiryMins: domain.ServiceCertExpiryMins,
+		RoleCertExpiryMins:    domain.RoleCertExpiryMins,
+		SignAlgorithm:         domain.SignAlgorithm,
+		ServiceExpiryDays:     domain.ServiceExpiryDays,
+		GroupExpiryDays:       domain.GroupExpiryDays,
+		UserAuthorityFilter:   domain.UserAuthorityFilter,
+		AzureSubscription:     domain.AzureSubscription,
+		Tags:                  domain.Tags,
+		BusinessService:       domain.BusinessService,
+	}
+}
+
+func updateDomainTags(zmsClient client.ZmsClient, domainName string, auditRef string, tags []interface{}) error {
+	domain, err := zmsClient.GetDomain(domainName)
+	if err != nil {
+		return err
+	}
+	domainMeta := domainMetaOf(domain)
+	domainMeta.Tags = expandTagsMap(tags)
+	return zmsClient.PutDomainMeta(domainName, auditRef, domainMeta)
+}
+
+// adminRoleMayHaveChanged returns false when the admin role of the domain can't have changed since the
+// last read, so it isn't read again. ZMS updates the modification timestamp of a domain with every change
+// in the domain, including the members of its admin role. lastModified is the timestamp in the state
+// before the read, empty after a create or an import
+func adminRoleMayHaveChanged(lastModified string, domain *zms.Domain) bool {
+	return lastModified == "" || lastModified != timestampToString(domain.Modified)
+}
+
+// deleteDomainTree deletes the sub domains of the domain, the deepest first, as ZMS doesn't delete a domain
+// with sub domains. with objects, the services, the policies and the roles of every domain of the tree,
+// including the domain itself, are deleted one by one before their domain, so a failure names the object
+func deleteDomainTree(zmsClient client.ZmsClient, dn string, objects bool, auditRef string) error {
+	subDomains, err := listDomainNames(zmsClient, dn+SUB_DOMAIN_SEPARATOR)
+	if err != nil {
+		return fmt.Errorf("can't list the sub domains of %s: %s", dn, err)
+	}
+	sort.Strings(subDomains)
+	sort.SliceStable(subDomains, func(i, j int) bool {
+		return strings.Count(subDomains[i], SUB_DOMAIN_SEPARATOR) > strings.Count(subDomains[j], SUB_DOMAIN_SEPARATOR)
+	})
+	for _, name := range subDomains {
+		parent, subDomain, err := parseDomainObjectId(name, "sub domain")
+		if err != nil {
+			return err
+		}
+		if objects {
+			if err = deleteDomainObjects(zmsClient, name, auditRef); err != nil {
+				return err
+			}
+		}
+		log.Printf("[INFO] deleting the sub domain %s of the domain %s, recursive_destroy is set", name, dn)
+		err = zmsClient.DeleteSubDomain(parent, subDomain, auditRef)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("can't delete the sub domain %s: %s", name, err)
+		}
+	}
+	if objects {
+		return deleteDomainObjects(zmsClient, dn, auditRef)
+	}
+	return nil
+}
+
+// deleteDomainObjects deletes the services, the policies and then the roles of the domain, the policies first
+// as their assertions reference the roles. the admin role and policy can only be deleted with the domain
+func deleteDomainObjects(zmsClient client.ZmsClient, dn string, auditRef string) error {
+	kinds := []struct {
+		kind      string
+		separator string
+		list      func(zmsClient client.ZmsClient, dn string) ([]zms.EntityName, error)
+		delete    func(dn string, name string, auditRef string) error
+	}{
+		{"service", SERVICE_SEPARATOR, listServiceNames, zmsClient.DeleteServiceIdentity},
+		{"policy", POLICY_SEPARATOR, listPolicyNames, zmsClient.DeletePolicy},
+		{"role", ROLE_SEPARATOR, listRoleNames, zmsClient.DeleteRole},
+	}
+	for _, k := range kinds {
+		names, err := k.list(zmsClient, dn)
+		if err != nil {
+			return fmt.Errorf("can't list the %s names of the domain %s: %s", k.kind, dn, err)
+		}
+		for _, name := range names {
+			if k.kind != "service" && name == "admin" {
+				continue
+			}
+			log.Printf("[INFO] deleting the %s %s%s%s, recursive_destroy_objects is set", k.kind, dn, k.separator, name)
+			if err = k.delete(dn, string(name), auditRef); err != nil && !isNotFound(err) {
+				return fmt.Errorf("can't delete the %s %s%s%s: %s", k.kind, dn, k.separator, name, err)
+			}
+		}
+	}
+	return nil
+}
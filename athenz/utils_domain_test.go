@@ -0,0 +1,148 @@
+package athenz
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_domainMetaOf(t *testing.T) {
+	enabled := true
+	ypmId := int32(12)
+	domain := &zms.Domain{
+		Name:        "home.someone",
+		Description: "some domain",
+		Enabled:     &enabled,
+		YpmId:       &ypmId,
+		Tags:        makeZmsTags("key1", "v1", "v2"),
+	}
+	domainMeta := domainMetaOf(domain)
+	ast.Equal(t, domainMeta.Description, "some domain")
+	ast.Equal(t, *domainMeta.Enabled, true)
+	ast.Equal(t, *domainMeta.YpmId, int32(12))
+	ast.DeepEqual(t, domainMeta.Tags, makeZmsTags("key1", "v1", "v2"))
+}
+
+func makeZmsTags(key string, values ...zms.TagCompoundValue) map[zms.CompoundName]*zms.TagValueList {
+	return map[zms.CompoundName]*zms.TagValueList{zms.CompoundName(key): {List: values}}
+}
+
+func Test_ignoreAdminUsersChanges(t *testing.T) {
+	hash := strconv.Itoa(hashCaseInsensitiveString("user.someone"))
+	state := &terraform.InstanceState{
+		ID: "some_domain",
+		Attributes: map[string]string{
+			"name":                       "some_domain",
+			"ypm_id":                     "12",
+			"audit_ref":                  AUDIT_REF,
+			"admin_users.#":              "1",
+			"admin_users." + hash:        "user.someone",
+			"ignore_admin_users_changes": "true",
+			"admin_groups.#":             "0",
+			"tags.#":                     "0",
+		},
+	}
+	config := map[string]interface{}{
+		"name":                       "some_domain",
+		"ypm_id":                     12,
+		"admin_users":                []interface{}{"user.other"},
+		"ignore_admin_users_changes": true,
+	}
+	diff, err := ResourceTopLevelDomain().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || !diff.RequiresNew())
+
+	// case: the admins are compared, so the domain is replaced
+	state.Attributes["ignore_admin_users_changes"] = "false"
+	config["ignore_admin_users_changes"] = false
+	diff, err = ResourceTopLevelDomain().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff.RequiresNew())
+}
+
+func Test_resourceTopLevelDomainReadUnchangedAdminRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	modified := rdl.TimestampNow()
+	ypmId := int32(0)
+	clientMock.EXPECT().GetDomain("some_domain").Return(&zms.Domain{Name: "some_domain", Modified: &modified, YpmId: &ypmId}, nil).AnyTimes()
+	d := schema.TestResourceDataRaw(t, ResourceTopLevelDomain().Schema, map[string]interface{}{
+		"name":        "some_domain",
+		"admin_users": []interface{}{"user.jane"},
+	})
+	d.SetId("some_domain")
+
+	// case: the domain wasn't changed since the last read, the admin role isn't read
+	ast.NilError(t, d.Set("modified", timestampToString(&modified)))
+	ast.Assert(t, !resourceTopLevelDomainRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("admin_users").(*schema.Set).Len(), 1)
+
+	// case: the domain was changed
+	clientMock.EXPECT().GetRole("some_domain", "admin").Return(&zms.Role{
+		RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}, {MemberName: "user.john"}},
+	}, nil)
+	ast.NilError(t, d.Set("modified", "2021-06-01T10:00:00.000Z"))
+	ast.Assert(t, !resourceTopLevelDomainRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("admin_users").(*schema.Set).Len(), 2)
+}
+
+func Test_deleteDomainTree(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	var deleted []string
+	clientMock.EXPECT().GetDomainList("some_domain.", gomock.Any(), "").Return(&zms.DomainList{
+		Names: []zms.DomainName{"some_domain.a", "some_domain.a.b", "some_domain.c", "some_domain.a.b.d"},
+	}, nil)
+	clientMock.EXPECT().DeleteSubDomain(gomock.Any(), gomock.Any(), AUDIT_REF).DoAndReturn(func(parent, name, _ string) error {
+		deleted = append(deleted, parent+"."+name)
+		return nil
+	}).Times(4)
+
+	// case: the deepest sub domains are deleted first
+	ast.NilError(t, deleteDomainTree(clientMock, "some_domain", false, AUDIT_REF))
+	ast.DeepEqual(t, deleted, []string{"some_domain.a.b.d", "some_domain.a.b", "some_domain.a", "some_domain.c"})
+
+	// case: the objects of each domain are deleted before it, except the admin role and policy
+	deleted = nil
+	clientMock.EXPECT().GetDomainList("some_domain.", gomock.Any(), "").Return(&zms.DomainList{Names: []zms.DomainName{"some_domain.a"}}, nil)
+	for _, dn := range []string{"some_domain", "some_domain.a"} {
+		clientMock.EXPECT().GetServiceIdentityList(dn, gomock.Any(), "").Return(&zms.ServiceIdentityList{Names: []zms.EntityName{"api"}}, nil)
+		clientMock.EXPECT().GetPolicyList(dn, gomock.Any(), "").Return(&zms.PolicyList{Names: []zms.EntityName{"admin", "readers"}}, nil)
+		clientMock.EXPECT().GetRoleList(dn, gomock.Any(), "").Return(&zms.RoleList{Names: []zms.EntityName{"admin", "readers"}}, nil)
+	}
+	clientMock.EXPECT().DeleteServiceIdentity(gomock.Any(), "api", AUDIT_REF).DoAndReturn(func(dn, name, _ string) error {
+		deleted = append(deleted, dn+SERVICE_SEPARATOR+name)
+		return nil
+	}).Times(2)
+	clientMock.EXPECT().DeletePolicy(gomock.Any(), "readers", AUDIT_REF).DoAndReturn(func(dn, name, _ string) error {
+		deleted = append(deleted, dn+POLICY_SEPARATOR+name)
+		return nil
+	}).Times(2)
+	clientMock.EXPECT().DeleteRole(gomock.Any(), "readers", AUDIT_REF).DoAndReturn(func(dn, name, _ string) error {
+		deleted = append(deleted, dn+ROLE_SEPARATOR+name)
+		return rdl.ResourceError{Code: 404, Message: "not found"}
+	}).Times(2)
+	clientMock.EXPECT().DeleteSubDomain("some_domain", "a", AUDIT_REF).DoAndReturn(func(parent, name, _ string) error {
+		deleted = append(deleted, parent+"."+name)
+		return nil
+	})
+	ast.NilError(t, deleteDomainTree(clientMock, "some_domain", true, AUDIT_REF))
+	ast.DeepEqual(t, deleted, []string{
+		"some_domain.a.api", "some_domain.a:policy.readers", "some_domain.a:role.readers", "some_domain.a",
+		"some_domain.api", "some_domain:policy.readers", "some_domain:role.readers",
+	})
+
+	// case: a sub domain that can't be deleted fails the destroy
+	clientMock.EXPECT().GetDomainList("some_domain.", gomock.Any(), "").Return(&zms.DomainList{Names: []zms.DomainName{"some_domain.a"}}, nil)
+	clientMock.EXPECT().DeleteSubDomain("some_domain", "a", AUDIT_REF).Return(rdl.ResourceError{Code: 403, Message: "forbidden"})
+	ast.ErrorContains(t, deleteDomainTree(clientMock, "some_domain", false, AUDIT_REF), "can't delete the sub domain some_domain.a: 403 forbidden")
+}
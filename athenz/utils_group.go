@@ -2,6 +2,7 @@ package athenz
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
@@ -10,11 +11,19 @@ import (
 
 func expandGroupMembers(configured []interface{}) []*zms.GroupMember {
 	groupMembers := make([]*zms.GroupMember, 0, len(configured))
-	for _, v := range configured {
-		val, ok := v.(string)
-		if ok && val != "" {
-			groupMember := zms.NewGroupMember()
-			groupMember.MemberName = zms.GroupMemberName(val)
+	allocated := make([]zms.GroupMember, len(configured))
+	for i, v := range configured {
+		switch val := v.(type) {
+		case string:
+			if val != "" {
+				groupMember := zms.NewGroupMember(&allocated[i])
+				groupMember.MemberName = zms.GroupMemberName(val)
+				groupMembers = append(groupMembers, groupMember)
+			}
+		case map[string]interface{}:
+			groupMember := zms.NewGroupMember(&allocated[i])
+			groupMember.MemberName = zms.GroupMemberName(val["name"].(string))
+			groupMember.Expiration = expandMemberDate(val["expiration"].(string))
 			groupMembers = append(groupMembers, groupMember)
 		}
 	}
@@ -27,40 +36,139 @@ func flattenGroupMember(list []*zms.GroupMember) []interface{} {
 	}
 	return groupMember
 }
-func updateGroupMembers(dn string, gn string, oldVal interface{}, newVal interface{}, zmsClient client.ZmsClient, auditRef string) error {
-	if oldVal == nil {
-		oldVal = new(schema.Set)
+
+// flattenGroupMemberObjects returns the member blocks of the group, like flattenRoleMemberObjects
+func flattenGroupMemberObjects(list []*zms.GroupMember, stateMembers []interface{}) []interface{} {
+	state := make(map[string]map[string]interface{}, len(stateMembers))
+	for _, v := range stateMembers {
+		m := v.(map[string]interface{})
+		state[strings.ToLower(m["name"].(string))] = m
+	}
+	groupMembers := make([]interface{}, 0, len(list))
+	for _, m := range list {
+		name := string(m.MemberName)
+		groupMember := map[string]interface{}{
+			"name":       name,
+			"expiration": flattenMemberDate(m.Expiration),
+		}
+		if stateMember, ok := state[strings.ToLower(name)]; ok {
+			groupMember["expiration"] = stateMemberDate(stateMember["expiration"].(string), m.Expiration)
+		}
+		groupMembers = append(groupMembers, groupMember)
 	}
-	if newVal == nil {
-		newVal = new(schema.Set)
+	return groupMembers
+}
+
+func groupMembersHaveDates(list []*zms.GroupMember) bool {
+	for _, m := range list {
+		if m.Expiration != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func splitUnmanagedGroupMembers(list []*zms.GroupMember, managed map[string]bool) ([]*zms.GroupMember, []interface{}) {
+	members := make([]*zms.GroupMember, 0, len(list))
+	unmanaged := make([]interface{}, 0)
+	for _, m := range list {
+		if managed[strings.ToLower(string(m.MemberName))] {
+			members = append(members, m)
+		} else {
+			unmanaged = append(unmanaged, string(m.MemberName))
+		}
+	}
+	return members, unmanaged
+}
+
+func hashGroupMember(v interface{}) int {
+	m := v.(map[string]interface{})
+	return schema.HashString(strings.ToLower(m["name"].(string)) + "," + normalizeMemberDate(m["expiration"].(string)))
+}
+
+// activeGroupMembers is activeRoleMembers for a group
+func activeGroupMembers(list []*zms.GroupMember, elapsed map[string]bool) []*zms.GroupMember {
+	members := make([]*zms.GroupMember, 0, len(list))
+	for _, m := range list {
+		if elapsed[strings.ToLower(string(m.MemberName))] || !membershipLapsed(m.Expiration, m.SystemDisabled) {
+			members = append(members, m)
+		}
 	}
+	return members
+}
 
-	os := oldVal.(*schema.Set)
-	ns := newVal.(*schema.Set)
-	remove := expandGroupMembers(os.Difference(ns).List())
-	add := expandGroupMembers(ns.Difference(os).List())
+// pendingGroupMembers is pendingRoleMembers for a group
+func pendingGroupMembers(zmsClient client.ZmsClient, dn string, gn string, statePending *schema.Set) ([]*zms.GroupMember, error) {
+	group, err := zmsClient.GetGroupWithPendingMembers(dn, gn)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*zms.GroupMember, 0)
+	for _, m := range group.GroupMembers {
+		if m.Approved != nil && !*m.Approved && statePending.Contains(string(m.MemberName)) {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
 
+// updateGroupMembers returns the names of the added members whose membership is pending approval
+func updateGroupMembers(dn string, gn string, remove []*zms.GroupMember, add []*zms.GroupMember, zmsClient client.ZmsClient, auditRef string) ([]string, error) {
+	// a member whose expiration was changed is updated by adding it again
+	added := make(map[zms.GroupMemberName]bool, len(add))
+	for _, m := range add {
+		added[zms.GroupMemberName(strings.ToLower(string(m.MemberName)))] = true
+	}
 	if len(remove) > 0 {
 		for _, member := range remove {
 			name := member.MemberName
+			if added[zms.GroupMemberName(strings.ToLower(string(name)))] {
+				continue
+			}
 			err := zmsClient.DeleteGroupMembership(dn, gn, name, auditRef)
-			if err != nil {
-				return fmt.Errorf("Error removing membership: %s", err)
+			if err != nil && !isNotFound(err) {
+				return nil, fmt.Errorf("Error removing membership: %s", err)
 			}
 		}
 	}
 
+	pending := make([]string, 0)
 	if len(add) > 0 {
 		for _, m := range add {
 			var member zms.GroupMembership
 			name := m.MemberName
 			member.MemberName = name
 			member.GroupName = zms.ResourceName(gn)
+			member.Expiration = m.Expiration
 			err := zmsClient.PutGroupMembership(dn, gn, name, auditRef, &member)
+			if isPendingApproval(err) {
+				pending = append(pending, string(name))
+				continue
+			}
 			if err != nil {
-				return err
+				return pending, err
 			}
 		}
 	}
-	return nil
+	return pending, nil
+}
+
+// putGroupMembers applies the member changes with a single PutGroup, as putRoleMembers does for a role
+func putGroupMembers(dn string, gn string, remove []*zms.GroupMember, add []*zms.GroupMember, zmsClient client.ZmsClient, auditRef string) error {
+	group, err := zmsClient.GetGroup(dn, gn)
+	if err != nil {
+		return err
+	}
+	changed := make(map[string]bool, len(remove)+len(add))
+	for _, m := range append(append([]*zms.GroupMember{}, remove...), add...) {
+		changed[strings.ToLower(string(m.MemberName))] = true
+	}
+	members := make([]*zms.GroupMember, 0, len(group.GroupMembers)+len(add))
+	for _, m := range group.GroupMembers {
+		if !changed[strings.ToLower(string(m.MemberName))] {
+			members = append(members, m)
+		}
+	}
+	group.GroupMembers = append(members, add...)
+	return zmsClient.PutGroup(dn, gn, auditRef, group)
 }
@@ -1,10 +1,12 @@
 package athenz
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
 	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
 	"github.com/golang/mock/gomock"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	ast "gotest.tools/assert"
@@ -48,3 +50,98 @@ func Test_expandGroupMembers(t *testing.T) {
 func Test_flattenGroupMember(t *testing.T) {
 	ast.DeepEqual(t, flattenGroupMember(getZmsGroupMembers()), getFlattedGroupMembers())
 }
+
+func Test_flattenGroupMemberObjects(t *testing.T) {
+	timestamp, err := rdl.TimestampParse("2022-05-29T23:59:59.000Z")
+	ast.NilError(t, err)
+	list := []*zms.GroupMember{
+		zms.NewGroupMember(&zms.GroupMember{MemberName: "member1", Expiration: &timestamp}),
+		zms.NewGroupMember(&zms.GroupMember{MemberName: "member2", Expiration: &timestamp}),
+	}
+	stateMembers := []interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "30d"},
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "30d"},
+		map[string]interface{}{"name": "member2", "expiration": "2022-05-29 23:59:59"},
+	}
+	ast.DeepEqual(t, flattenGroupMemberObjects(list, stateMembers), expected)
+
+	groupMembers := expandGroupMembers(expected)
+	ast.Equal(t, len(groupMembers), 2)
+	ast.Assert(t, groupMembers[0].Expiration != nil)
+	ast.Equal(t, groupMembers[1].Expiration.String(), "2022-05-29T23:59:59.000Z")
+}
+
+func Test_updateGroupMembersChangedExpiration(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	// member1 is updated with its new expiration, without removing it first
+	clientMock.EXPECT().DeleteGroupMembership("home.someone", "group1", zms.GroupMemberName("member3"), AUDIT_REF).Return(nil)
+	clientMock.EXPECT().PutGroupMembership("home.someone", "group1", zms.GroupMemberName("member1"), AUDIT_REF, gomock.Any()).Return(nil)
+	clientMock.EXPECT().PutGroupMembership("home.someone", "group1", zms.GroupMemberName("member2"), AUDIT_REF, gomock.Any()).Return(nil)
+
+	remove := expandGroupMembers([]interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "30d"},
+		map[string]interface{}{"name": "member3", "expiration": ""},
+	})
+	add := expandGroupMembers([]interface{}{
+		map[string]interface{}{"name": "member1", "expiration": "60d"},
+		map[string]interface{}{"name": "member2", "expiration": ""},
+	})
+	pending, err := updateGroupMembers("home.someone", "group1", remove, add, clientMock, AUDIT_REF)
+	ast.NilError(t, err)
+	ast.Equal(t, len(pending), 0)
+}
+
+func Test_updateGroupMembersPendingApproval(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().PutGroupMembership("home.someone", "group1", zms.GroupMemberName("member1"), AUDIT_REF, gomock.Any()).Return(rdl.ResourceError{Code: 202, Message: "Accepted"})
+	clientMock.EXPECT().PutGroupMembership("home.someone", "group1", zms.GroupMemberName("member2"), AUDIT_REF, gomock.Any()).Return(nil)
+
+	pending, err := updateGroupMembers("home.someone", "group1", nil, expandGroupMembers(getFlattedGroupMembers()), clientMock, AUDIT_REF)
+	ast.NilError(t, err)
+	ast.DeepEqual(t, pending, []string{"member1"})
+}
+
+func Test_putGroupMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().GetGroup("home.someone", "group1").Return(&zms.Group{
+		Name: "home.someone:group.group1",
+		GroupMembers: []*zms.GroupMember{
+			{MemberName: "user.jane"},
+			{MemberName: "user.john"},
+			{MemberName: "user.unmanaged"},
+		},
+	}, nil)
+	clientMock.EXPECT().PutGroup("home.someone", "group1", AUDIT_REF, gomock.Any()).DoAndReturn(
+		func(_ string, _ string, _ string, group *zms.Group) error {
+			ast.DeepEqual(t, flattenGroupMember(group.GroupMembers), []interface{}{"user.jane", "user.unmanaged", "user.bob"})
+			return nil
+		})
+
+	remove := expandGroupMembers([]interface{}{"user.john"})
+	add := expandGroupMembers([]interface{}{"user.bob"})
+	ast.NilError(t, putGroupMembers("home.someone", "group1", remove, add, clientMock, AUDIT_REF))
+}
+
+func Test_batchMembers(t *testing.T) {
+	meta := &providerConfig{memberBatchThreshold: 100}
+	ast.Assert(t, !batchMembers(meta, 100))
+	ast.Assert(t, batchMembers(meta, 101))
+	ast.Assert(t, !batchMembers(&providerConfig{}, 2000))
+}
+
+func Benchmark_expandGroupMembers(b *testing.B) {
+	names := make([]interface{}, 0, 10000)
+	for i := 0; i < cap(names); i++ {
+		names = append(names, fmt.Sprintf("user.member%d", i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expandGroupMembers(names)
+	}
+}
@@ -1,9 +1,21 @@
 package athenz
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/terraform-provider-athenz/client"
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/golang/mock/gomock"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	ast "gotest.tools/assert"
 )
 
@@ -73,6 +85,10 @@ func Test_flattenPolicyAssertion(t *testing.T) {
 	roleName := dName + ":role.foo"
 	resourceName := dName + ":foo_"
 	ast.DeepEqual(t, flattenPolicyAssertion(getZmsAssertions(roleName, resourceName)), getFlattedAssertions("foo", "foo_"))
+
+	// case: the resource name has a separator, the name is kept as is so it's expanded to the same resource
+	resourceName = dName + ":foo_:bar"
+	ast.DeepEqual(t, flattenPolicyAssertion(getZmsAssertions(roleName, resourceName)), getFlattedAssertions("foo", resourceName))
 }
 
 func Test_expandPolicyAssertions(t *testing.T) {
@@ -139,3 +155,506 @@ func Test_convertToKeyBase64(t *testing.T) {
 func Test_convertToDecodedKey(t *testing.T) {
 	ast.Equal(t, convertToDecodedKey(getKeyBase64()), getDecodedKey())
 }
+
+func Test_convertToKeyBase64AlreadyEncoded(t *testing.T) {
+	ast.Equal(t, convertToKeyBase64(getKeyBase64()), getKeyBase64())
+	ast.Equal(t, convertToKeyBase64(getKeyBase64()+"\n"), getKeyBase64())
+}
+
+func Test_convertToKeyBase64RoundTrip(t *testing.T) {
+	// keys that are encoded with the ybase64 special chars ('.', '_' and the '-' padding)
+	key := getDecodedKey() + "\n?>"
+	ast.Equal(t, convertToDecodedKey(convertToKeyBase64(key)), strings.TrimSpace(key))
+	ast.Equal(t, convertToDecodedKey(convertToKeyBase64(getDecodedKey()+"\n")), getDecodedKey())
+}
+
+func Test_normalizePublicKey(t *testing.T) {
+	ast.Equal(t, normalizePublicKey(getDecodedKey()+"\n"), getDecodedKey())
+	ast.Equal(t, normalizePublicKey(strings.ReplaceAll(getDecodedKey(), "\n", "\r\n")), getDecodedKey())
+	ast.Equal(t, normalizePublicKey(getKeyBase64()), getDecodedKey())
+}
+
+func Test_hashPublicKey(t *testing.T) {
+	pemKey := map[string]interface{}{"key_id": "v0", "key_value": getDecodedKey() + "\n"}
+	encodedKey := map[string]interface{}{"key_id": "v0", "key_value": getKeyBase64()}
+	otherId := map[string]interface{}{"key_id": "v1", "key_value": getDecodedKey()}
+	ast.Equal(t, hashPublicKey(pemKey), hashPublicKey(encodedKey))
+	ast.Assert(t, hashPublicKey(pemKey) != hashPublicKey(otherId))
+}
+
+func Test_validatePublicKey(t *testing.T) {
+	_, errs := validatePublicKey(getDecodedKey(), "key_value")
+	ast.Equal(t, len(errs), 0)
+	_, errs = validatePublicKey(getKeyBase64(), "key_value")
+	ast.Equal(t, len(errs), 0)
+	_, errs = validatePublicKey("not a key", "key_value")
+	ast.Equal(t, len(errs), 1)
+}
+
+func Test_suppressAuditRefOnlyChange(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID:         "home.someone.sub",
+		Attributes: map[string]string{"parent_name": "home.someone", "name": "sub", "audit_ref": AUDIT_REF, "admin_users.#": "0"},
+	}
+
+	// case: only the audit_ref changed
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"parent_name": "home.someone", "name": "sub", "audit_ref": "some ticket"})
+	diff, err := ResourceSubDomain().Diff(context.Background(), state, config, nil)
+	ast.NilError(t, err)
+	ast.Assert(t, diff == nil || diff.Attributes["audit_ref"] == nil)
+
+	// case: the audit_ref changed with another attribute
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{"parent_name": "home.someone", "name": "sub2", "audit_ref": "some ticket"})
+	diff, err = ResourceSubDomain().Diff(context.Background(), state, config, nil)
+	ast.NilError(t, err)
+	ast.Equal(t, diff.Attributes["audit_ref"].New, "some ticket")
+}
+
+func Test_timestampToString(t *testing.T) {
+	timestamp, err := rdl.TimestampParse("2022-05-29T23:59:59.000Z")
+	ast.NilError(t, err)
+	ast.Equal(t, timestampToString(&timestamp), "2022-05-29T23:59:59.000Z")
+	ast.Equal(t, timestampToString(nil), "")
+}
+
+func Test_splitAdminMembers(t *testing.T) {
+	list := []*zms.RoleMember{
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "user.someone"}),
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "sys.auth.zms"}),
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "home.someone:group.admins"}),
+		zms.NewRoleMember(&zms.RoleMember{MemberName: "home.someone:group.others"}),
+	}
+	stateAdminUsers := schema.NewSet(schema.HashString, []interface{}{"home.someone:group.others"})
+	adminUsers, adminGroups := splitAdminMembers(list, stateAdminUsers)
+	ast.DeepEqual(t, adminUsers, []interface{}{"user.someone", "sys.auth.zms", "home.someone:group.others"})
+	ast.DeepEqual(t, adminGroups, []interface{}{"home.someone:group.admins"})
+}
+
+func Test_attributeError(t *testing.T) {
+	diags := attributeError("tags", "error updating tags", fmt.Errorf("some error"))
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, diags[0].Severity, diag.Error)
+	ast.Equal(t, diags[0].Summary, "error updating tags")
+	ast.Equal(t, diags[0].Detail, "some error")
+	ast.Assert(t, diags[0].AttributePath.Equals(cty.GetAttrPath("tags")))
+}
+
+func Test_readAfterCreate(t *testing.T) {
+	d := ResourceRole().TestResourceData()
+	d.SetId("home.someone:role.test")
+	reads := 0
+	// case: the role is found on the second read
+	read := func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		reads++
+		if reads == 1 {
+			d.SetId("")
+		}
+		return nil
+	}
+	diags := readAfterCreate(context.Background(), d, nil, read)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, reads, 2)
+	ast.Equal(t, d.Id(), "home.someone:role.test")
+
+	// case: an error isn't retried
+	reads = 0
+	read = func(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+		reads++
+		return diag.Errorf("some error")
+	}
+	diags = readAfterCreate(context.Background(), d, nil, read)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, reads, 1)
+}
+
+func Test_hashCaseInsensitiveString(t *testing.T) {
+	ast.Equal(t, hashCaseInsensitiveString("User.John"), hashCaseInsensitiveString("user.john"))
+	ast.Assert(t, hashCaseInsensitiveString("user.john") != hashCaseInsensitiveString("user.jane"))
+
+	configMembers := schema.NewSet(hashCaseInsensitiveString, []interface{}{"User.John", "sys.auth.ZMS"})
+	zmsMembers := schema.NewSet(hashCaseInsensitiveString, []interface{}{"user.john", "sys.auth.zms"})
+	ast.Equal(t, configMembers.Difference(zmsMembers).Len(), 0)
+	ast.Equal(t, zmsMembers.Difference(configMembers).Len(), 0)
+
+	withDates := map[string]interface{}{"name": "User.John", "expiration": "30d", "review": ""}
+	lowercase := map[string]interface{}{"name": "user.john", "expiration": "720h", "review": ""}
+	ast.Equal(t, hashRoleMember(withDates), hashRoleMember(lowercase))
+}
+
+func Test_isNotFound(t *testing.T) {
+	ast.Assert(t, isNotFound(rdl.ResourceError{Code: 404, Message: "not found"}))
+	ast.Assert(t, !isNotFound(rdl.ResourceError{Code: 403, Message: "forbidden"}))
+	ast.Assert(t, !isNotFound(fmt.Errorf("some error")))
+	ast.Assert(t, !isNotFound(nil))
+}
+
+func Test_resourceRoleDeleteNotFound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	d := ResourceRole().TestResourceData()
+	d.SetId("home.someone:role.test")
+
+	// case: the role was already deleted
+	clientMock.EXPECT().DeleteRole("home.someone", "test", gomock.Any()).Return(rdl.ResourceError{Code: 404, Message: "not found"})
+	ast.Assert(t, !resourceRoleDelete(context.Background(), d, clientMock).HasError())
+
+	// case: other errors are returned
+	clientMock.EXPECT().DeleteRole("home.someone", "test", gomock.Any()).Return(rdl.ResourceError{Code: 403, Message: "forbidden"})
+	ast.Assert(t, resourceRoleDelete(context.Background(), d, clientMock).HasError())
+}
+
+func Test_sameRole(t *testing.T) {
+	existing := &zms.Role{
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "user.john"})},
+		Tags:        map[zms.CompoundName]*zms.TagValueList{"owner": {List: []zms.TagCompoundValue{"team-a"}}},
+	}
+	expected := &zms.Role{
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "User.John"})},
+		Tags:        map[zms.CompoundName]*zms.TagValueList{"Owner": {List: []zms.TagCompoundValue{"Team-A"}}},
+	}
+	ast.Assert(t, sameRole(existing, expected))
+
+	expected.RoleMembers = append(expected.RoleMembers, zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"}))
+	ast.Assert(t, !sameRole(existing, expected))
+
+	expected.RoleMembers = expected.RoleMembers[:1]
+	expected.Tags = nil
+	ast.Assert(t, !sameRole(existing, expected))
+}
+
+func Test_resourceRoleCreateExisting(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	selfServe := true
+	existing := &zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "user.john"})},
+		SelfServe:   &selfServe,
+	}
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(existing, nil).AnyTimes()
+	newResourceData := func(raw map[string]interface{}) *schema.ResourceData {
+		raw["domain"] = "home.someone"
+		raw["name"] = "test"
+		return schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	}
+
+	// case: the role exists with the configured members, e.g. it was created by a concurrent apply
+	d := newResourceData(map[string]interface{}{"members": []interface{}{"user.john"}})
+	ast.Assert(t, !resourceRoleCreate(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "home.someone:role.test")
+
+	// case: the role exists with other members
+	d = newResourceData(map[string]interface{}{"members": []interface{}{"user.jane"}})
+	diags := resourceRoleCreate(context.Background(), d, clientMock)
+	ast.Assert(t, diags.HasError())
+	ast.Assert(t, strings.Contains(diags[0].Summary, "already exists"))
+
+	// case: the existing role is adopted and updated to the configured members, its other attributes are kept
+	d = newResourceData(map[string]interface{}{"members": []interface{}{"user.jane"}, "adopt_existing": true})
+	clientMock.EXPECT().PutRole("home.someone", "test", gomock.Any(), gomock.Any()).DoAndReturn(func(_, _, _ string, role *zms.Role) error {
+		ast.Equal(t, len(role.RoleMembers), 1)
+		ast.Equal(t, string(role.RoleMembers[0].MemberName), "user.jane")
+		ast.Assert(t, role.SelfServe != nil && *role.SelfServe)
+		return nil
+	})
+	ast.Assert(t, !resourceRoleCreate(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Id(), "home.someone:role.test")
+}
+
+func Test_resourceRoleUpdatePartialFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	raw := map[string]interface{}{
+		"domain":  "home.someone",
+		"name":    "test",
+		"members": []interface{}{"user.jane", "user.john"},
+	}
+
+	// case: only one of the members was added, the state has the members found in ZMS
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	clientMock.EXPECT().PutMembership("home.someone", "test", zms.MemberName("user.jane"), gomock.Any(), gomock.Any()).Return(nil).MaxTimes(1)
+	clientMock.EXPECT().PutMembership("home.someone", "test", zms.MemberName("user.john"), gomock.Any(), gomock.Any()).Return(rdl.ResourceError{Code: 500, Message: "error"}).MaxTimes(1)
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"})},
+	}, nil)
+	ast.Assert(t, resourceRoleUpdate(context.Background(), d, clientMock).HasError())
+	state := d.State()
+	ast.Equal(t, state.Attributes["members.#"], "1")
+	ast.Equal(t, state.Attributes["members."+strconv.Itoa(hashCaseInsensitiveString("user.jane"))], "user.jane")
+
+	// case: the role can't be read, the previous state is kept
+	d = schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	clientMock.EXPECT().PutMembership(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(rdl.ResourceError{Code: 500, Message: "error"})
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(nil, rdl.ResourceError{Code: 500, Message: "error"})
+	ast.Assert(t, resourceRoleUpdate(context.Background(), d, clientMock).HasError())
+	state = d.State()
+	ast.Equal(t, state.ID, "home.someone:role.test")
+	ast.Equal(t, state.Attributes["members.#"], "")
+}
+
+func Test_resourceRoleReadUnmanagedMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name: "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.john"}),
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"}),
+		},
+	}, nil).AnyTimes()
+	raw := map[string]interface{}{
+		"domain":  "home.someone",
+		"name":    "test",
+		"members": []interface{}{"User.John"},
+	}
+
+	// case: the members added outside of terraform are kept aside
+	raw["ignore_unmanaged_members"] = true
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("members").(*schema.Set).List(), []interface{}{"user.john"})
+	ast.DeepEqual(t, d.Get("unmanaged_members").(*schema.Set).List(), []interface{}{"user.jane"})
+
+	// case: the role has all the members, so the others are removed on the next apply, and they're listed
+	raw["ignore_unmanaged_members"] = false
+	d = schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("members").(*schema.Set).Len(), 2)
+	ast.DeepEqual(t, d.Get("unmanaged_members").(*schema.Set).List(), []interface{}{"user.jane"})
+
+	// case: the unmanaged members aren't managed by the next read either
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("unmanaged_members").(*schema.Set).List(), []interface{}{"user.jane"})
+
+	// case: the apply setting ignore_unmanaged_members keeps the unmanaged members in the state
+	state := d.State()
+	raw["ignore_unmanaged_members"] = true
+	diff, err := ResourceRole().Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), clientMock)
+	ast.NilError(t, err)
+	state, diags := ResourceRole().Apply(context.Background(), state, diff, clientMock)
+	ast.Assert(t, !diags.HasError(), diags)
+	ast.Equal(t, state.Attributes["members.#"], "1")
+	ast.Equal(t, state.Attributes["unmanaged_members.#"], "1")
+}
+
+func Test_checkNotModified(t *testing.T) {
+	modified := rdl.NewTimestamp(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC))
+	ast.NilError(t, checkNotModified("home.someone:role.test", timestampToString(&modified), &modified))
+	// case: the role wasn't read yet
+	ast.NilError(t, checkNotModified("home.someone:role.test", "", &modified))
+
+	lastRead := rdl.NewTimestamp(time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC))
+	ast.ErrorContains(t, checkNotModified("home.someone:role.test", timestampToString(&lastRead), &modified), "after it was last read")
+}
+
+func Test_resourceRolePendingMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, map[string]interface{}{
+		"domain":  "home.someone",
+		"name":    "test",
+		"members": []interface{}{"user.jane", "user.john"},
+	})
+	d.SetId("home.someone:role.test")
+	notApproved := false
+	clientMock.EXPECT().PutMembership("home.someone", "test", zms.MemberName("user.jane"), gomock.Any(), gomock.Any()).Return(nil)
+	clientMock.EXPECT().PutMembership("home.someone", "test", zms.MemberName("user.john"), gomock.Any(), gomock.Any()).Return(rdl.ResourceError{Code: 202, Message: "Accepted"})
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"})},
+	}, nil).AnyTimes()
+	clientMock.EXPECT().GetRoleWithPendingMembers("home.someone", "test").Return(&zms.Role{
+		Name: "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"}),
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.john", Approved: &notApproved}),
+		},
+	}, nil)
+
+	// case: the pending member is kept in the members, with a warning
+	diags := resourceRoleUpdate(context.Background(), d, clientMock)
+	ast.Assert(t, !diags.HasError())
+	ast.Equal(t, len(diags), 1)
+	ast.Equal(t, diags[0].Severity, diag.Warning)
+	ast.Equal(t, d.Get("members").(*schema.Set).Len(), 2)
+	ast.DeepEqual(t, d.Get("pending_members").(*schema.Set).List(), []interface{}{"user.john"})
+
+	// case: the membership was rejected, so the member is removed from the state and added again by the next apply
+	clientMock.EXPECT().GetRoleWithPendingMembers("home.someone", "test").Return(&zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"})},
+	}, nil)
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("members").(*schema.Set).List(), []interface{}{"user.jane"})
+	ast.Equal(t, d.Get("pending_members").(*schema.Set).Len(), 0)
+}
+
+func Test_membershipLapsed(t *testing.T) {
+	expired := rdl.NewTimestamp(time.Now().Add(-time.Hour))
+	valid := rdl.NewTimestamp(time.Now().Add(time.Hour))
+	disabled := int32(1)
+	ast.Assert(t, membershipLapsed(&expired, nil))
+	ast.Assert(t, membershipLapsed(&valid, &disabled))
+	ast.Assert(t, !membershipLapsed(&valid, nil))
+	ast.Assert(t, !membershipLapsed(nil, nil))
+}
+
+func Test_resourceRoleReadForceSync(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	expired := rdl.NewTimestamp(time.Now().Add(-time.Hour))
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name: "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.jane"}),
+			zms.NewRoleMember(&zms.RoleMember{MemberName: "user.john", Expiration: &expired}),
+		},
+	}, nil).AnyTimes()
+	raw := map[string]interface{}{
+		"domain":  "home.someone",
+		"name":    "test",
+		"members": []interface{}{"user.jane", "user.john"},
+	}
+
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("members").(*schema.Set).Len(), 2)
+
+	// case: the expired member isn't in the state, so the next plan adds it again
+	raw["force_sync"] = true
+	d = schema.TestResourceDataRaw(t, ResourceRole().Schema, raw)
+	d.SetId("home.someone:role.test")
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.DeepEqual(t, d.Get("members").(*schema.Set).List(), []interface{}{"user.jane"})
+
+	// case: the member configured with an elapsed date is kept, adding it again wouldn't renew it
+	d = schema.TestResourceDataRaw(t, ResourceRole().Schema, map[string]interface{}{
+		"domain":     "home.someone",
+		"name":       "test",
+		"force_sync": true,
+		"member": []interface{}{
+			map[string]interface{}{"name": "user.jane"},
+			map[string]interface{}{"name": "user.john", "expiration": flattenMemberDate(&expired)},
+		},
+	})
+	d.SetId("home.someone:role.test")
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, clientMock).HasError())
+	ast.Equal(t, d.Get("member").(*schema.Set).Len(), 2)
+}
+
+func Test_putRoleMembers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}, {MemberName: "user.john"}},
+		Tags:        map[zms.CompoundName]*zms.TagValueList{"key": {List: []zms.TagCompoundValue{"value"}}},
+	}, nil)
+	clientMock.EXPECT().PutRole("home.someone", "test", AUDIT_REF, gomock.Any()).DoAndReturn(
+		func(_ string, _ string, _ string, role *zms.Role) error {
+			// the member with a new expiration is replaced, and the tags are kept
+			ast.Equal(t, len(role.RoleMembers), 2)
+			ast.Equal(t, role.RoleMembers[1].MemberName, zms.MemberName("user.john"))
+			ast.Assert(t, role.RoleMembers[1].Expiration != nil)
+			ast.Equal(t, len(role.Tags), 1)
+			return nil
+		})
+
+	remove := expandRoleMembers([]interface{}{map[string]interface{}{"name": "user.john", "expiration": "", "review": ""}})
+	add := expandRoleMembers([]interface{}{map[string]interface{}{"name": "user.john", "expiration": "30d", "review": ""}})
+	ast.NilError(t, putRoleMembers("home.someone", "test", remove, add, AUDIT_REF, clientMock))
+}
+
+func Test_resourceRoleReadFastRefresh(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	modified := rdl.TimestampNow()
+	clientMock.EXPECT().GetDomain("home.someone").Return(&zms.Domain{Name: "home.someone", Modified: &modified}, nil).AnyTimes()
+	meta := &providerConfig{ZmsClient: clientMock, fastRefresh: true}
+	d := schema.TestResourceDataRaw(t, ResourceRole().Schema, map[string]interface{}{
+		"domain":  "home.someone",
+		"name":    "test",
+		"members": []interface{}{"user.jane"},
+	})
+	d.SetId("home.someone:role.test")
+
+	// case: the first read sets the modification timestamp of the domain
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{
+		Name:        "home.someone:role.test",
+		RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}},
+	}, nil).Times(1)
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, meta).HasError())
+	ast.Equal(t, d.Get("domain_modified"), timestampToString(&modified))
+
+	// case: the domain wasn't changed since, the role isn't read again
+	ast.Assert(t, !resourceRoleRead(context.Background(), d, meta).HasError())
+	ast.Equal(t, d.Get("members").(*schema.Set).Len(), 1)
+}
+
+// largeRoleMembers returns the members of a role with count members, and their member blocks
+func largeRoleMembers(count int) ([]*zms.RoleMember, []interface{}) {
+	members := make([]*zms.RoleMember, 0, count)
+	blocks := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("user.member%d", i)
+		members = append(members, zms.NewRoleMember(&zms.RoleMember{MemberName: zms.MemberName(name)}))
+		blocks = append(blocks, map[string]interface{}{"name": name, "expiration": "", "review": ""})
+	}
+	return members, blocks
+}
+
+func Benchmark_resourceRoleRead(b *testing.B) {
+	members, blocks := largeRoleMembers(10000)
+	mockCtrl := gomock.NewController(b)
+	clientMock := client.NewMockZmsClient(mockCtrl)
+	clientMock.EXPECT().WithContext(gomock.Any()).Return(clientMock).AnyTimes()
+	clientMock.EXPECT().GetRole("home.someone", "test").Return(&zms.Role{Name: "home.someone:role.test", RoleMembers: members}, nil).AnyTimes()
+	d := ResourceRole().TestResourceData()
+	d.SetId("home.someone:role.test")
+	if err := d.Set("member", blocks); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if diags := resourceRoleRead(context.Background(), d, clientMock); diags.HasError() {
+			b.Fatal(diags)
+		}
+	}
+}
+
+func Benchmark_roleMemberChanges(b *testing.B) {
+	_, blocks := largeRoleMembers(10000)
+	changed := append(append([]interface{}{}, blocks[1:]...), map[string]interface{}{"name": "user.added", "expiration": "30d", "review": ""})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		os, ns := schema.NewSet(hashRoleMember, blocks), schema.NewSet(hashRoleMember, changed)
+		remove, add := expandRoleMembers(os.Difference(ns).List()), expandRoleMembers(ns.Difference(os).List())
+		if len(remove) != 1 || len(add) != 1 {
+			b.Fatalf("unexpected changes, %d removed and %d added", len(remove), len(add))
+		}
+	}
+}
+
+func Benchmark_expandRoleMembers(b *testing.B) {
+	_, blocks := largeRoleMembers(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expandRoleMembers(blocks)
+	}
+}
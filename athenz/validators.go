@@ -0,0 +1,187 @@
+package athenz
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// the name patterns of the ZMS schema
+const (
+	simpleNamePattern   = `[a-zA-Z0-9_][a-zA-Z0-9_-]*`
+	compoundNamePattern = `(` + simpleNamePattern + `\.)*` + simpleNamePattern
+	// a user or a service is a name in its domain, e.g. user.john
+	userNamePattern = compoundNamePattern + `\.` + simpleNamePattern
+)
+
+// the default tag limits of ZMS
+const (
+	maxTags           = 25
+	maxTagKeyLength   = 64
+	maxTagValueLength = 256
+)
+
+var (
+	tagValueRegex     = regexp.MustCompile(`^([a-zA-Z0-9_:,/][a-zA-Z0-9_:,/-]*\.)*[a-zA-Z0-9_:,/][a-zA-Z0-9_:,/-]*$`)
+	simpleNameRegex   = regexp.MustCompile(`^` + simpleNamePattern + `$`)
+	compoundNameRegex = regexp.MustCompile(`^` + compoundNamePattern + `$`)
+	groupNameRegex    = regexp.MustCompile(`^` + compoundNamePattern + regexp.QuoteMeta(GROUP_SEPARATOR) + compoundNamePattern + `$`)
+	userNameRegex     = regexp.MustCompile(`^` + userNamePattern + `$`)
+	// a user, a service or a group
+	principalNameRegex = regexp.MustCompile(`^(` + userNamePattern + `|` + compoundNamePattern + regexp.QuoteMeta(GROUP_SEPARATOR) + compoundNamePattern + `)$`)
+	// a user or service, a wildcard, or a group
+	memberNameRegex = regexp.MustCompile(`^(\*|` + compoundNamePattern + `\.\*|` + userNamePattern + `\*?|` +
+		compoundNamePattern + regexp.QuoteMeta(GROUP_SEPARATOR) + compoundNamePattern + `)$`)
+)
+
+func validateName(v interface{}, path cty.Path, regex *regexp.Regexp, kind, example string) diag.Diagnostics {
+	value, ok := v.(string)
+	if !ok {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("expected the %s to be a string", kind),
+			AttributePath: path,
+		}}
+	}
+	if !regex.MatchString(value) {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("invalid %s %q", kind, value),
+			Detail:        fmt.Sprintf("a %s must be in the format %s", kind, example),
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
+
+// validateSimpleDomainName validates a domain name without a parent, e.g. a top level domain
+func validateSimpleDomainName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, simpleNameRegex, "domain name", "<name>, without dots")
+}
+
+func validateDomainName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, compoundNameRegex, "domain name", "<name> or <parent>.<name>, e.g. some_domain.sub_domain")
+}
+
+// validateEntityName validates the name of a role, policy, group or service within its domain
+func validateEntityName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, compoundNameRegex, "name", "<name>, e.g. readers")
+}
+
+func validateGroupName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, groupNameRegex, "group name", "<domain>"+GROUP_SEPARATOR+"<name>")
+}
+
+func validateMemberName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, memberNameRegex, "member name", "<domain>.<name> (e.g. user.john), <domain>.* or <domain>"+GROUP_SEPARATOR+"<name>")
+}
+
+// validatePrincipalName validates a single principal, a wildcard isn't one
+func validatePrincipalName(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateName(v, path, principalNameRegex, "principal name", "<domain>.<name> (e.g. user.john) or <domain>"+GROUP_SEPARATOR+"<name>")
+}
+
+// validateGroupMemberName validates a member of a group, which can't be another group
+func validateGroupMemberName(v interface{}, path cty.Path) diag.Diagnostics {
+	if value, ok := v.(string); ok && strings.Contains(value, GROUP_SEPARATOR) {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("invalid member name %q", value),
+			Detail:        "a group can't be a member of another group",
+			AttributePath: path,
+		}}
+	}
+	return validateName(v, path, userNameRegex, "member name", "<domain>.<name>, e.g. user.john")
+}
+
+// validateZmsUrl validates the ZMS API URL, e.g. https://zms.example.com:4443/zms/v1
+func validateZmsUrl(v interface{}, path cty.Path) diag.Diagnostics {
+	return validateApiUrl(v, path, "zms")
+}
+
+// validateZtsUrl validates the ZTS API URL, e.g. https://zts.example.com:4443/zts/v1. it's optional
+func validateZtsUrl(v interface{}, path cty.Path) diag.Diagnostics {
+	if value, _ := v.(string); value == "" {
+		return nil
+	}
+	return validateApiUrl(v, path, "zts")
+}
+
+// validateMsdUrl validates the MSD API URL, e.g. https://msd.example.com:4443/msd/v1. it's optional
+func validateMsdUrl(v interface{}, path cty.Path) diag.Diagnostics {
+	if value, _ := v.(string); value == "" {
+		return nil
+	}
+	return validateApiUrl(v, path, "msd")
+}
+
+func validateApiUrl(v interface{}, path cty.Path, service string) diag.Diagnostics {
+	value, _ := v.(string)
+	u, err := url.Parse(value)
+	var problem string
+	switch {
+	case err != nil:
+		problem = err.Error()
+	case u.Scheme != "https":
+		problem = "the scheme must be https"
+	case u.Host == "":
+		problem = "the host is missing"
+	case !strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/"+service+"/v1"):
+		problem = fmt.Sprintf("the path must end with /%s/v1", service)
+	default:
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       fmt.Sprintf("invalid %s URL %q", strings.ToUpper(service), value),
+		Detail:        fmt.Sprintf("%s, e.g. https://%s.example.com:4443/%s/v1", problem, service, service),
+		AttributePath: path,
+	}}
+}
+
+// validateDuration validates a positive duration, e.g. 10m or 1d
+func validateDuration(v interface{}, path cty.Path) diag.Diagnostics {
+	value, _ := v.(string)
+	if value == "" {
+		return nil
+	}
+	if duration, err := parseDuration(value); err != nil || duration <= 0 {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("invalid duration %q", value),
+			Detail:        "the duration must be positive, e.g. 10m, 12h or 1d",
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
+
+func validateTagKey(v interface{}, path cty.Path) diag.Diagnostics {
+	if diags := validateLength(v, path, maxTagKeyLength, "tag key"); diags != nil {
+		return diags
+	}
+	return validateName(v, path, compoundNameRegex, "tag key", "<name> or <prefix>.<name>, e.g. zms.owner")
+}
+
+func validateTagValue(v interface{}, path cty.Path) diag.Diagnostics {
+	if diags := validateLength(v, path, maxTagValueLength, "tag value"); diags != nil {
+		return diags
+	}
+	return validateName(v, path, tagValueRegex, "tag value", "of letters, digits and the characters _:,/-. e.g. team/payments")
+}
+
+func validateLength(v interface{}, path cty.Path, max int, kind string) diag.Diagnostics {
+	if value, ok := v.(string); ok && len(value) > max {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("the %s %q is too long", kind, value),
+			Detail:        fmt.Sprintf("a %s can have up to %d characters", kind, max),
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
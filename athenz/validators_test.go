@@ -0,0 +1,128 @@
+package athenz
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	ast "gotest.tools/assert"
+)
+
+func Test_validateMemberName(t *testing.T) {
+	path := cty.GetAttrPath("members")
+	for _, value := range []string{"user.john", "sys.auth.zms", "user.*", "*", "user.jo*", "some_domain:group.admins"} {
+		ast.Assert(t, !validateMemberName(value, path).HasError(), value)
+	}
+	for _, value := range []string{"user:john", "", "user.", "user john", "some_domain:role.readers", "john", "jo*"} {
+		diags := validateMemberName(value, path)
+		ast.Assert(t, diags.HasError(), value)
+		ast.Assert(t, diags[0].AttributePath.Equals(path))
+	}
+}
+
+func Test_validatePrincipalName(t *testing.T) {
+	path := cty.GetAttrPath("principal")
+	for _, value := range []string{"user.john", "sys.auth.zms", "some_domain:group.admins"} {
+		ast.Assert(t, !validatePrincipalName(value, path).HasError(), value)
+	}
+	for _, value := range []string{"john", "user.*", "*", "user:john", "some_domain:role.readers"} {
+		ast.Assert(t, validatePrincipalName(value, path).HasError(), value)
+	}
+}
+
+func Test_validateGroupMemberName(t *testing.T) {
+	path := cty.GetAttrPath("members")
+	ast.Assert(t, !validateGroupMemberName("user.john", path).HasError())
+	ast.Assert(t, validateGroupMemberName("user:john", path).HasError())
+	ast.Assert(t, validateGroupMemberName("john", path).HasError())
+	diags := validateGroupMemberName("some_domain:group.admins", path)
+	ast.Assert(t, diags.HasError())
+	ast.Equal(t, diags[0].Detail, "a group can't be a member of another group")
+}
+
+func Test_validateDomainName(t *testing.T) {
+	path := cty.GetAttrPath("domain")
+	ast.Assert(t, !validateDomainName("some_domain.sub-domain", path).HasError())
+	ast.Assert(t, validateDomainName("some_domain:sub", path).HasError())
+	ast.Assert(t, validateDomainName(".some_domain", path).HasError())
+	ast.Assert(t, !validateSimpleDomainName("some_domain", path).HasError())
+	ast.Assert(t, validateSimpleDomainName("some_domain.sub", path).HasError())
+}
+
+func Test_validateGroupName(t *testing.T) {
+	path := cty.GetAttrPath("admin_groups")
+	ast.Assert(t, !validateGroupName("some_domain:group.admins", path).HasError())
+	ast.Assert(t, validateGroupName("some_domain.admins", path).HasError())
+	ast.Assert(t, validateGroupName("some_domain:role.admins", path).HasError())
+}
+
+func Test_validateZmsUrl(t *testing.T) {
+	path := cty.GetAttrPath("zms_url")
+	for _, value := range []string{"https://zms.example.com:4443/zms/v1", "https://localhost:4443/zms/v1/"} {
+		ast.Assert(t, !validateZmsUrl(value, path).HasError(), value)
+	}
+	for value, detail := range map[string]string{
+		"http://zms.example.com:4443/zms/v1": "the scheme must be https",
+		"https://zms.example.com:4443":       "the path must end with /zms/v1",
+		"zms.example.com/zms/v1":             "the scheme must be https",
+		"https:///zms/v1":                    "the host is missing",
+	} {
+		diags := validateZmsUrl(value, path)
+		ast.Assert(t, diags.HasError(), value)
+		ast.Assert(t, strings.Contains(diags[0].Detail, detail), diags[0].Detail)
+	}
+}
+
+func Test_validateZtsUrl(t *testing.T) {
+	path := cty.GetAttrPath("zts_url")
+	ast.Assert(t, !validateZtsUrl("", path).HasError())
+	ast.Assert(t, !validateZtsUrl("https://zts.example.com:4443/zts/v1", path).HasError())
+	diags := validateZtsUrl("https://zms.example.com:4443/zms/v1", path)
+	ast.Assert(t, diags.HasError())
+	ast.Assert(t, strings.Contains(diags[0].Detail, "the path must end with /zts/v1"), diags[0].Detail)
+}
+
+func Test_validateMsdUrl(t *testing.T) {
+	path := cty.GetAttrPath("msd_url")
+	ast.Assert(t, !validateMsdUrl("", path).HasError())
+	ast.Assert(t, !validateMsdUrl("https://msd.example.com:4443/msd/v1", path).HasError())
+	diags := validateMsdUrl("https://zts.example.com:4443/zts/v1", path)
+	ast.Assert(t, diags.HasError())
+	ast.Assert(t, strings.Contains(diags[0].Detail, "the path must end with /msd/v1"), diags[0].Detail)
+}
+
+func Test_validateDuration(t *testing.T) {
+	path := cty.GetAttrPath("data_source_cache_ttl")
+	for _, value := range []string{"", "10m", "1d", "1d12h"} {
+		ast.Assert(t, !validateDuration(value, path).HasError(), value)
+	}
+	for _, value := range []string{"10", "0s", "-1h", "ten minutes"} {
+		ast.Assert(t, validateDuration(value, path).HasError(), value)
+	}
+}
+
+func Test_validateTags(t *testing.T) {
+	path := cty.GetAttrPath("tags")
+	for _, value := range []string{"zms.owner", "owner", "cost-center"} {
+		ast.Assert(t, !validateTagKey(value, path).HasError(), value)
+	}
+	for _, value := range []string{"zms:owner", "owner/team", "", strings.Repeat("a", maxTagKeyLength+1)} {
+		ast.Assert(t, validateTagKey(value, path).HasError(), value)
+	}
+	for _, value := range []string{"team/payments", "a:b,c", "v1.2"} {
+		ast.Assert(t, !validateTagValue(value, path).HasError(), value)
+	}
+	for _, value := range []string{"two words", "a*", "", strings.Repeat("a", maxTagValueLength+1)} {
+		ast.Assert(t, validateTagValue(value, path).HasError(), value)
+	}
+
+	// case: too many tags
+	tags := make([]interface{}, 0, maxTags+1)
+	for i := 0; i <= maxTags; i++ {
+		tags = append(tags, map[string]interface{}{"key": fmt.Sprintf("key%d", i), "values": []interface{}{"value"}})
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"domain": "some_domain", "name": "test", "tags": tags})
+	ast.Assert(t, ResourceRole().Validate(config).HasError())
+}
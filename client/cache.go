@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// readCacheTransport keeps the successful responses of the reads of a domain for the life of the
+// provider, i.e. a single plan or apply, so the resources reading the same domain or role don't repeat
+// the request. the concurrent reads of the same URL wait for a single request. a write drops the
+// responses of its domain and of its sub domains, and a write that isn't of a single domain (e.g. the
+// creation of a sub domain) drops all of them. the responses that aren't successful, e.g. an
+// object that isn't found yet, aren't kept
+type readCacheTransport struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	entries   map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	domain string
+	done   chan struct{}
+	// set when the request is done, ok is false when the response can't be shared
+	ok     bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+func newReadCacheTransport(transport http.RoundTripper) *readCacheTransport {
+	return &readCacheTransport{
+		transport: transport,
+		entries:   make(map[string]*cacheEntry),
+	}
+}
+
+func (t *readCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	domain := domainOf(req.URL.Path)
+	if !isReadOnly(req.Method) {
+		// the sub domain and the user domain requests have the parent or the user name in the path
+		if !strings.Contains(req.URL.Path, "/domain/") {
+			domain = ""
+		}
+		t.invalidate(domain)
+		defer t.invalidate(domain)
+		return t.transport.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet || domain == "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	t.mu.Lock()
+	entry, found := t.entries[key]
+	if !found {
+		entry = &cacheEntry{domain: domain, done: make(chan struct{})}
+		t.entries[key] = entry
+	}
+	t.mu.Unlock()
+
+	if found {
+		select {
+		case <-entry.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if entry.ok {
+			return entry.response(req), nil
+		}
+		return t.transport.RoundTrip(req)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		var body []byte
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			entry.ok, entry.status, entry.header, entry.body = true, resp.StatusCode, resp.Header, body
+			resp = entry.response(req)
+		}
+	}
+	if !entry.ok {
+		t.remove(key, entry)
+	}
+	close(entry.done)
+	return resp, err
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func (t *readCacheTransport) remove(key string, entry *cacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries[key] == entry {
+		delete(t.entries, key)
+	}
+}
+
+func (t *readCacheTransport) invalidate(domain string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, entry := range t.entries {
+		if domain == "" || entry.domain == domain || strings.HasPrefix(entry.domain, domain+".") {
+			delete(t.entries, key)
+		}
+	}
+}
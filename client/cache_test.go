@@ -0,0 +1,78 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	ast "gotest.tools/assert"
+)
+
+func Test_readCacheTransport(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch {
+		case r.Method != http.MethodGet:
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte("some body"))
+		}
+	}))
+	defer server.Close()
+	transport := newReadCacheTransport(http.DefaultTransport)
+	request := func(method string, path string) (int, string) {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		ast.NilError(t, err)
+		resp, err := transport.RoundTrip(req)
+		ast.NilError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// case: the concurrent reads of the same object are a single request
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, body := request(http.MethodGet, "/zms/v1/domain/some_domain/role/readers")
+			ast.Equal(t, status, http.StatusOK)
+			ast.Equal(t, body, "some body")
+		}()
+	}
+	wg.Wait()
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(1))
+	request(http.MethodGet, "/zms/v1/domain/some_domain.sub")
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(2))
+
+	// case: a response that isn't successful isn't kept
+	request(http.MethodGet, "/zms/v1/domain/some_domain/role/missing")
+	status, _ := request(http.MethodGet, "/zms/v1/domain/some_domain/role/missing")
+	ast.Equal(t, status, http.StatusNotFound)
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(4))
+
+	// case: the reads of other domains are kept after a write
+	request(http.MethodGet, "/zms/v1/domain/other_domain")
+	request(http.MethodPut, "/zms/v1/domain/other_domain/role/readers")
+	request(http.MethodGet, "/zms/v1/domain/some_domain/role/readers")
+	request(http.MethodGet, "/zms/v1/domain/some_domain.sub")
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(6))
+
+	// case: a write drops the reads of the domain and of its sub domains
+	request(http.MethodDelete, "/zms/v1/domain/some_domain/role/readers")
+	request(http.MethodGet, "/zms/v1/domain/some_domain/role/readers")
+	request(http.MethodGet, "/zms/v1/domain/some_domain.sub")
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(9))
+
+	// case: the creation of a sub domain drops all the reads
+	request(http.MethodPost, "/zms/v1/subdomain/some_domain")
+	request(http.MethodGet, "/zms/v1/domain/some_domain/role/readers")
+	ast.Equal(t, atomic.LoadInt32(&calls), int32(11))
+}
@@ -1,17 +1,24 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
+	"github.com/AthenZ/athenz/clients/go/msd"
 	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/athenz/clients/go/zts"
+	"github.com/ardielle/ardielle-go/rdl"
 )
 
 type ZmsClient interface {
 	GetRole(domain string, roleName string) (*zms.Role, error)
+	GetRoleWithPendingMembers(domain string, roleName string) (*zms.Role, error)
 	DeleteRole(domain string, roleName string, auditRef string) error
 	PutRole(domain string, roleName string, auditRef string, role *zms.Role) error
 	PutMembership(domain string, roleName string, memberName zms.MemberName, auditRef string, membership *zms.Membership) error
@@ -20,6 +27,7 @@ type ZmsClient interface {
 	GetPolicy(domain string, policy string) (*zms.Policy, error)
 	DeletePolicy(domain string, policyName string, auditRef string) error
 	GetGroup(domain string, groupName string) (*zms.Group, error)
+	GetGroupWithPendingMembers(domain string, groupName string) (*zms.Group, error)
 	DeleteGroup(domain string, groupName string, auditRef string) error
 	PutGroup(domain string, groupName string, auditRef string, group *zms.Group) error
 	DeleteGroupMembership(domain string, groupName string, member zms.GroupMemberName, auditRef string) error
@@ -27,7 +35,11 @@ type ZmsClient interface {
 	GetServiceIdentity(domain string, serviceName string) (*zms.ServiceIdentity, error)
 	PutServiceIdentity(domain string, serviceName string, auditRef string, detail *zms.ServiceIdentity) error
 	DeleteServiceIdentity(domain string, serviceName string, auditRef string) error
+	PutPublicKeyEntry(domain string, serviceName string, auditRef string, publicKeyEntry *zms.PublicKeyEntry) error
+	DeletePublicKeyEntry(domain string, serviceName string, keyId string, auditRef string) error
 	GetDomain(domainName string) (*zms.Domain, error)
+	GetUserList(domainName string) (*zms.UserList, error)
+	GetPrincipalRoles(principal string, domainName string) (*zms.DomainRoleMember, error)
 	PostUserDomain(domainName string, auditRef string, detail *zms.UserDomain) (*zms.Domain, error)
 	DeleteUserDomain(domainName string, auditRef string) error
 	PostSubDomain(parentDomain string, auditRef string, detail *zms.SubDomain) (*zms.Domain, error)
@@ -35,6 +47,11 @@ type ZmsClient interface {
 	PostTopLevelDomain(auditRef string, detail *zms.TopLevelDomain) (*zms.Domain, error)
 	DeleteTopLevelDomain(name string, auditRef string) error
 	PutDomainMeta(name string, auditRef string, detail *zms.DomainMeta) error
+	PutDomainTemplate(domainName string, auditRef string, template *zms.DomainTemplate) error
+	GetDomainTemplateList(domainName string) (*zms.DomainTemplateList, error)
+	DeleteDomainTemplate(domainName string, templateName string, auditRef string) error
+	GetStatus() (*zms.Status, error)
+	GetDomainList(prefix string, limit *int32, skip string) (*zms.DomainList, error)
 	GetRoleList(domainName string, limit *int32, skip string) (*zms.RoleList, error)
 	GetPolicyList(domainName string, limit *int32, skip string) (*zms.PolicyList, error)
 	GetServiceIdentityList(domainName string, limit *int32, skip string) (*zms.ServiceIdentityList, error)
@@ -48,11 +65,38 @@ type ZmsClient interface {
 	DeletePolicyVersion(domainName string, policyName string, version string, auditRef string) error
 	DeleteAssertionPolicyVersion(domainName string, policyName string, version string, assertionId int64, auditRef string) error
 	GetPolicies(domainName string, assertions bool, includeNonActive bool) (*zms.Policies, error)
+	GetSignedDomain(domainName string) (*zms.DomainData, error)
+	GetDomainSignedPolicyData(domainName string) (*zts.DomainSignedPolicyData, error)
+	GetJWSPolicyData(domainName string) (*zts.JWSPolicyData, error)
+	GetRdlSchema() (*rdl.Schema, error)
+	ValidateTransportPolicy(request *msd.TransportPolicyValidationRequest) (*msd.TransportPolicyValidationResponse, error)
+	GetRaw(path string) ([]byte, error)
+	PutRaw(path string, auditRef string, body []byte) error
+	DeleteRaw(path string, auditRef string) error
+	WithContext(ctx context.Context) ZmsClient
 }
 
 type Client struct {
 	Url       string
-	Transport *http.Transport
+	Transport http.RoundTripper
+	// Principal is the common name of the client certificate, e.g. some_domain.some_service
+	Principal string
+	// SignedDomainReads serves the reads of roles, groups and policies from the signed domain,
+	// fetched once per domain until the domain is changed
+	SignedDomainReads bool
+	// SignedDomainCacheDir keeps the signed domains between runs, so the next run fetches only the
+	// domains that were changed since. it's not used when empty
+	SignedDomainCacheDir string
+	// ZtsUrl is the ZTS API URL of the signed policy data, e.g. https://zts.example.com:4443/zts/v1
+	ZtsUrl string
+	// MsdUrl is the MSD API URL of the transport policy validation, e.g. https://msd.example.com:4443/msd/v1
+	MsdUrl    string
+	snapshots *domainSnapshots
+	// ztsTransport sends the requests to ZTS with the cert of the client, without the caches, the locks
+	// and the snapshot invalidation of the requests to ZMS
+	ztsTransport http.RoundTripper
+	// msdTransport sends the requests to MSD, as ztsTransport
+	msdTransport http.RoundTripper
 }
 
 type ZmsConfig struct {
@@ -60,7 +104,33 @@ type ZmsConfig struct {
 	Cert   string
 	Key    string
 	CaCert string
-}
+	// MaxConnsPerHost is the number of connections to ZMS kept open for reuse, the parallel requests of
+	// an apply reuse them instead of a new TLS handshake for each request. the default is used when 0
+	MaxConnsPerHost int
+	// DisableHTTP2 uses HTTP/1.1 for a ZMS that doesn't support HTTP/2
+	DisableHTTP2 bool
+	// CacheTTL keeps the reads with a context of WithCachedReads in CacheDir for the duration, so the
+	// next runs within it don't repeat them. the reads aren't kept when it's 0
+	CacheTTL time.Duration
+	CacheDir string
+	// ZtsUrl is the ZTS API URL, the requests to ZTS fail when it's empty
+	ZtsUrl string
+	// MsdUrl is the MSD API URL, the requests to MSD fail when it's empty
+	MsdUrl string
+	// Telemetry exports the spans and the metrics of the requests to ZMS and ZTS, they aren't recorded
+	// when it's nil
+	Telemetry *Telemetry
+	// AuditRefSuffix is appended to the audit reference of every change sent to ZMS, e.g. the commit
+	// of the configuration
+	AuditRefSuffix string
+}
+
+// the default number of connections kept open, terraform runs 10 operations in parallel by default
+const defaultMaxConnsPerHost = 16
+
+// the number of TLS sessions kept for resumption, a new connection to ZMS resumes the session of a
+// previous one instead of a full handshake
+const tlsSessionCacheSize = 64
 
 func (c Client) GetPolicies(domainName string, assertions bool, includeNonActive bool) (*zms.Policies, error) {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
@@ -119,6 +189,36 @@ func (c Client) GetRoles(domainName string, members *bool, tagKey string, tagVal
 	return zmsClient.GetRoles(zms.DomainName(domainName), members, zms.CompoundName(tagKey), zms.CompoundName(tagValue))
 }
 
+func (c Client) GetStatus() (*zms.Status, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.GetStatus()
+}
+
+// GetRdlSchema returns the RDL schema of ZMS, with the types and the resources the server supports. the zms
+// client of this version doesn't have it
+func (c Client) GetRdlSchema() (*rdl.Schema, error) {
+	httpClient := http.Client{Transport: c.Transport}
+	resp, err := httpClient.Get(c.Url + "/schema")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, rdl.ResourceError{Code: resp.StatusCode, Message: string(body)}
+	}
+	var schema rdl.Schema
+	if err = json.NewDecoder(resp.Body).Decode(&schema); err != nil {
+		return nil, fmt.Errorf("can't decode the schema of ZMS: %s", err)
+	}
+	return &schema, nil
+}
+
+func (c Client) GetDomainList(prefix string, limit *int32, skip string) (*zms.DomainList, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.GetDomainList(limit, skip, prefix, nil, "", nil, "", "", "", "", "", "", "")
+}
+
 func (c Client) GetRoleList(domainName string, limit *int32, skip string) (*zms.RoleList, error) {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetRoleList(zms.DomainName(domainName), limit, skip)
@@ -127,6 +227,21 @@ func (c Client) PutDomainMeta(name string, auditRef string, detail *zms.DomainMe
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.PutDomainMeta(zms.DomainName(name), auditRef, detail)
 }
+func (c Client) PutDomainTemplate(domainName string, auditRef string, template *zms.DomainTemplate) error {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.PutDomainTemplate(zms.DomainName(domainName), auditRef, template)
+}
+
+func (c Client) GetDomainTemplateList(domainName string) (*zms.DomainTemplateList, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.GetDomainTemplateList(zms.DomainName(domainName))
+}
+
+func (c Client) DeleteDomainTemplate(domainName string, templateName string, auditRef string) error {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.DeleteDomainTemplate(zms.DomainName(domainName), zms.SimpleName(templateName), auditRef)
+}
+
 func (c Client) PostTopLevelDomain(auditRef string, detail *zms.TopLevelDomain) (*zms.Domain, error) {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.PostTopLevelDomain(auditRef, detail)
@@ -155,11 +270,74 @@ func (c Client) PostUserDomain(domainName string, auditRef string, detail *zms.U
 	return zmsClient.PostUserDomain(zms.SimpleName(domainName), auditRef, detail)
 }
 
+// GetUserList returns the users of the user domain known to ZMS, the ones that are members of a role or a group
+func (c Client) GetUserList(domainName string) (*zms.UserList, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.GetUserList(zms.DomainName(domainName))
+}
+
+// GetPrincipalRoles returns the roles the principal is a member of, of all the domains when domainName is empty
+func (c Client) GetPrincipalRoles(principal string, domainName string) (*zms.DomainRoleMember, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.GetPrincipalRoles(zms.ResourceName(principal), zms.DomainName(domainName))
+}
+
 func (c Client) GetDomain(domainName string) (*zms.Domain, error) {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetDomain(zms.DomainName(domainName))
 }
 
+// GetSignedDomain returns all the objects of the domain with a single request. the response is decoded as a
+// stream, as for the snapshot of bulk_refresh, but the services and the attributes of the domain are kept.
+// the entities aren't
+func (c Client) GetSignedDomain(domainName string) (*zms.DomainData, error) {
+	data, _, err := c.getSignedDomain(domainName, "", true)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("the signed domain %s isn't in the response", domainName)
+	}
+	return data, nil
+}
+
+// GetDomainSignedPolicyData returns the policies of the domain signed by ZTS, as ZPU fetches them for ZPE
+func (c Client) GetDomainSignedPolicyData(domainName string) (*zts.DomainSignedPolicyData, error) {
+	ztsClient, err := c.ztsClient()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := ztsClient.GetDomainSignedPolicyData(zts.DomainName(domainName), "")
+	return data, err
+}
+
+// GetJWSPolicyData returns the active policies of the domain signed by ZTS in the JWS format
+func (c Client) GetJWSPolicyData(domainName string) (*zts.JWSPolicyData, error) {
+	ztsClient, err := c.ztsClient()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := ztsClient.PostSignedPolicyRequest(zts.DomainName(domainName), &zts.SignedPolicyRequest{PolicyVersions: map[string]string{}}, "")
+	return data, err
+}
+
+func (c Client) ztsClient() (zts.ZTSClient, error) {
+	if c.ZtsUrl == "" {
+		return zts.ZTSClient{}, fmt.Errorf("the ZTS URL isn't configured")
+	}
+	return zts.NewClient(c.ZtsUrl, c.ztsTransport), nil
+}
+
+// ValidateTransportPolicy returns whether MSD accepts the transport policy, and why it doesn't, without
+// changing the policies of the domain
+func (c Client) ValidateTransportPolicy(request *msd.TransportPolicyValidationRequest) (*msd.TransportPolicyValidationResponse, error) {
+	if c.MsdUrl == "" {
+		return nil, fmt.Errorf("the MSD URL isn't configured")
+	}
+	msdClient := msd.NewClient(c.MsdUrl, c.msdTransport)
+	return msdClient.ValidateTransportPolicy(request)
+}
+
 func (c Client) PutServiceIdentity(domain string, serviceName string, auditRef string, detail *zms.ServiceIdentity) error {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.PutServiceIdentity(zms.DomainName(domain), zms.SimpleName(serviceName), auditRef, detail)
@@ -170,6 +348,16 @@ func (c Client) DeleteServiceIdentity(domain string, serviceName string, auditRe
 	return zmsClient.DeleteServiceIdentity(zms.DomainName(domain), zms.SimpleName(serviceName), auditRef)
 }
 
+func (c Client) PutPublicKeyEntry(domain string, serviceName string, auditRef string, publicKeyEntry *zms.PublicKeyEntry) error {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.PutPublicKeyEntry(zms.DomainName(domain), zms.SimpleName(serviceName), publicKeyEntry.Id, auditRef, publicKeyEntry)
+}
+
+func (c Client) DeletePublicKeyEntry(domain string, serviceName string, keyId string, auditRef string) error {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	return zmsClient.DeletePublicKeyEntry(zms.DomainName(domain), zms.SimpleName(serviceName), keyId, auditRef)
+}
+
 func (c Client) GetServiceIdentity(domain string, serviceName string) (*zms.ServiceIdentity, error) {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetServiceIdentity(zms.DomainName(domain), zms.SimpleName(serviceName))
@@ -195,11 +383,24 @@ func (c Client) DeleteGroup(domain string, groupName string, auditRef string) er
 }
 
 func (c Client) GetGroup(domain string, groupName string) (*zms.Group, error) {
+	if data := c.signedDomain(domain); data != nil {
+		return snapshotGroup(data, domain, groupName)
+	}
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetGroup(zms.DomainName(domain), zms.EntityName(groupName), nil, nil)
 }
 
+// GetGroupWithPendingMembers returns the group with the members that weren't approved yet
+func (c Client) GetGroupWithPendingMembers(domain string, groupName string) (*zms.Group, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	pending := true
+	return zmsClient.GetGroup(zms.DomainName(domain), zms.EntityName(groupName), nil, &pending)
+}
+
 func (c Client) GetPolicy(domain string, policy string) (*zms.Policy, error) {
+	if data := c.signedDomain(domain); data != nil {
+		return snapshotPolicy(data, domain, policy)
+	}
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetPolicy(zms.DomainName(domain), zms.EntityName(policy))
 }
@@ -215,10 +416,20 @@ func (c Client) DeletePolicy(domain string, policyName string, auditRef string)
 }
 
 func (c Client) GetRole(domain string, roleName string) (*zms.Role, error) {
+	if data := c.signedDomain(domain); data != nil {
+		return snapshotRole(data, domain, roleName)
+	}
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.GetRole(zms.DomainName(domain), zms.EntityName(roleName), nil, nil, nil)
 }
 
+// GetRoleWithPendingMembers returns the role with the members that weren't approved yet
+func (c Client) GetRoleWithPendingMembers(domain string, roleName string) (*zms.Role, error) {
+	zmsClient := zms.NewClient(c.Url, c.Transport)
+	pending := true
+	return zmsClient.GetRole(zms.DomainName(domain), zms.EntityName(roleName), nil, nil, &pending)
+}
+
 func (c Client) PutRole(domain string, roleName string, auditRef string, role *zms.Role) error {
 	zmsClient := zms.NewClient(c.Url, c.Transport)
 	return zmsClient.PutRole(zms.DomainName(domain), zms.EntityName(roleName), auditRef, role)
@@ -239,19 +450,75 @@ func (c Client) DeleteMembership(domain string, roleMember string, member zms.Me
 	return zmsClient.DeleteMembership(zms.DomainName(domain), zms.EntityName(roleMember), member, auditRef)
 }
 
+// WithContext returns a client whose requests are canceled with the given context,
+// e.g. when terraform is interrupted or the timeout of the operation is reached
+func (c Client) WithContext(ctx context.Context) ZmsClient {
+	c.Transport = &contextTransport{ctx: ctx, transport: c.Transport}
+	c.ztsTransport = &contextTransport{ctx: ctx, transport: c.ztsTransport}
+	c.msdTransport = &contextTransport{ctx: ctx, transport: c.msdTransport}
+	return c
+}
+
 func NewClient(url string, certFile string, keyFile string, caCert string) (*Client, error) {
-	tlsConfig, err := getTLSConfigFromFiles(certFile, keyFile, caCert)
+	return NewClientWithConfig(ZmsConfig{Url: url, Cert: certFile, Key: keyFile, CaCert: caCert})
+}
+
+func NewClientWithConfig(config ZmsConfig) (*Client, error) {
+	tlsConfig, err := getTLSConfigFromFiles(config.Cert, config.Key, config.CaCert)
 	if err != nil {
 		return nil, err
 	}
-	transport := http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
 	client := &Client{
-		Url:       url,
-		Transport: &transport,
+		Url:       config.Url,
+		ZtsUrl:    config.ZtsUrl,
+		MsdUrl:    config.MsdUrl,
+		snapshots: newDomainSnapshots(),
+	}
+	if leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0]); err == nil {
+		client.Principal = leaf.Subject.CommonName
+	}
+	httpTransport := newHTTPTransport(tlsConfig, config)
+	client.ztsTransport = instrument(newRetryTransport(httpTransport), "zts", config.Telemetry)
+	client.msdTransport = instrument(newRetryTransport(httpTransport), "msd", config.Telemetry)
+	// the requests served by the caches don't reach ZMS, they aren't recorded
+	var zmsTransport http.RoundTripper = httpTransport
+	if config.AuditRefSuffix != "" {
+		zmsTransport = &auditRefTransport{transport: httpTransport, suffix: config.AuditRefSuffix}
+	}
+	transport := instrument(newRetryTransport(client.snapshots.invalidatingTransport(newDomainLockTransport(zmsTransport))), "zms", config.Telemetry)
+	if config.CacheTTL > 0 {
+		transport = newTTLCacheTransport(transport, config.CacheDir, config.CacheTTL, client.Principal)
+	}
+	client.Transport = newReadCacheTransport(transport)
+	return client, nil
+}
+
+func instrument(transport http.RoundTripper, service string, telemetry *Telemetry) http.RoundTripper {
+	if telemetry == nil {
+		return transport
+	}
+	return newTelemetryTransport(transport, service, telemetry)
+}
+
+// newHTTPTransport returns a transport with the defaults of the http package (e.g. the proxy from the
+// environment and the timeouts), keeping enough idle connections for the parallel requests of an apply
+func newHTTPTransport(tlsConfig *tls.Config, config ZmsConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(tlsSessionCacheSize)
+	transport.TLSClientConfig = tlsConfig
+	transport.ForceAttemptHTTP2 = !config.DisableHTTP2
+	if config.DisableHTTP2 {
+		// a non-nil empty map disables HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	transport.MaxIdleConnsPerHost = defaultMaxConnsPerHost
+	if config.MaxConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxConnsPerHost
+	}
+	if transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
 	}
-	return client, err
+	return transport
 }
 
 func getTLSConfigFromFiles(certFile, keyFile string, caCert string) (*tls.Config, error) {
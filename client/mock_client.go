@@ -5,9 +5,13 @@
 package client
 
 import (
+	context "context"
 	reflect "reflect"
 
+	msd "github.com/AthenZ/athenz/clients/go/msd"
 	zms "github.com/AthenZ/athenz/clients/go/zms"
+	zts "github.com/AthenZ/athenz/clients/go/zts"
+	rdl "github.com/ardielle/ardielle-go/rdl"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,6 +38,34 @@ func (m *MockZmsClient) EXPECT() *MockZmsClientMockRecorder {
 	return m.recorder
 }
 
+// DeleteAssertionPolicyVersion mocks base method.
+func (m *MockZmsClient) DeleteAssertionPolicyVersion(domainName, policyName, version string, assertionId int64, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAssertionPolicyVersion", domainName, policyName, version, assertionId, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAssertionPolicyVersion indicates an expected call of DeleteAssertionPolicyVersion.
+func (mr *MockZmsClientMockRecorder) DeleteAssertionPolicyVersion(domainName, policyName, version, assertionId, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAssertionPolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).DeleteAssertionPolicyVersion), domainName, policyName, version, assertionId, auditRef)
+}
+
+// DeleteDomainTemplate mocks base method.
+func (m *MockZmsClient) DeleteDomainTemplate(domainName, templateName, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDomainTemplate", domainName, templateName, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDomainTemplate indicates an expected call of DeleteDomainTemplate.
+func (mr *MockZmsClientMockRecorder) DeleteDomainTemplate(domainName, templateName, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDomainTemplate", reflect.TypeOf((*MockZmsClient)(nil).DeleteDomainTemplate), domainName, templateName, auditRef)
+}
+
 // DeleteGroup mocks base method.
 func (m *MockZmsClient) DeleteGroup(domain, groupName, auditRef string) error {
 	m.ctrl.T.Helper()
@@ -90,6 +122,48 @@ func (mr *MockZmsClientMockRecorder) DeletePolicy(domain, policyName, auditRef i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicy", reflect.TypeOf((*MockZmsClient)(nil).DeletePolicy), domain, policyName, auditRef)
 }
 
+// DeletePolicyVersion mocks base method.
+func (m *MockZmsClient) DeletePolicyVersion(domainName, policyName, version, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePolicyVersion", domainName, policyName, version, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePolicyVersion indicates an expected call of DeletePolicyVersion.
+func (mr *MockZmsClientMockRecorder) DeletePolicyVersion(domainName, policyName, version, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).DeletePolicyVersion), domainName, policyName, version, auditRef)
+}
+
+// DeletePublicKeyEntry mocks base method.
+func (m *MockZmsClient) DeletePublicKeyEntry(domain, serviceName, keyId, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublicKeyEntry", domain, serviceName, keyId, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePublicKeyEntry indicates an expected call of DeletePublicKeyEntry.
+func (mr *MockZmsClientMockRecorder) DeletePublicKeyEntry(domain, serviceName, keyId, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublicKeyEntry", reflect.TypeOf((*MockZmsClient)(nil).DeletePublicKeyEntry), domain, serviceName, keyId, auditRef)
+}
+
+// DeleteRaw mocks base method.
+func (m *MockZmsClient) DeleteRaw(path, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRaw", path, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRaw indicates an expected call of DeleteRaw.
+func (mr *MockZmsClientMockRecorder) DeleteRaw(path, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRaw", reflect.TypeOf((*MockZmsClient)(nil).DeleteRaw), path, auditRef)
+}
+
 // DeleteRole mocks base method.
 func (m *MockZmsClient) DeleteRole(domain, roleName, auditRef string) error {
 	m.ctrl.T.Helper()
@@ -104,6 +178,122 @@ func (mr *MockZmsClientMockRecorder) DeleteRole(domain, roleName, auditRef inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockZmsClient)(nil).DeleteRole), domain, roleName, auditRef)
 }
 
+// DeleteServiceIdentity mocks base method.
+func (m *MockZmsClient) DeleteServiceIdentity(domain, serviceName, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServiceIdentity", domain, serviceName, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServiceIdentity indicates an expected call of DeleteServiceIdentity.
+func (mr *MockZmsClientMockRecorder) DeleteServiceIdentity(domain, serviceName, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceIdentity", reflect.TypeOf((*MockZmsClient)(nil).DeleteServiceIdentity), domain, serviceName, auditRef)
+}
+
+// DeleteSubDomain mocks base method.
+func (m *MockZmsClient) DeleteSubDomain(parentDomain, subDomainName, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubDomain", parentDomain, subDomainName, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubDomain indicates an expected call of DeleteSubDomain.
+func (mr *MockZmsClientMockRecorder) DeleteSubDomain(parentDomain, subDomainName, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubDomain", reflect.TypeOf((*MockZmsClient)(nil).DeleteSubDomain), parentDomain, subDomainName, auditRef)
+}
+
+// DeleteTopLevelDomain mocks base method.
+func (m *MockZmsClient) DeleteTopLevelDomain(name, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTopLevelDomain", name, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTopLevelDomain indicates an expected call of DeleteTopLevelDomain.
+func (mr *MockZmsClientMockRecorder) DeleteTopLevelDomain(name, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTopLevelDomain", reflect.TypeOf((*MockZmsClient)(nil).DeleteTopLevelDomain), name, auditRef)
+}
+
+// DeleteUserDomain mocks base method.
+func (m *MockZmsClient) DeleteUserDomain(domainName, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserDomain", domainName, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserDomain indicates an expected call of DeleteUserDomain.
+func (mr *MockZmsClientMockRecorder) DeleteUserDomain(domainName, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserDomain", reflect.TypeOf((*MockZmsClient)(nil).DeleteUserDomain), domainName, auditRef)
+}
+
+// GetDomain mocks base method.
+func (m *MockZmsClient) GetDomain(domainName string) (*zms.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomain", domainName)
+	ret0, _ := ret[0].(*zms.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomain indicates an expected call of GetDomain.
+func (mr *MockZmsClientMockRecorder) GetDomain(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomain", reflect.TypeOf((*MockZmsClient)(nil).GetDomain), domainName)
+}
+
+// GetDomainList mocks base method.
+func (m *MockZmsClient) GetDomainList(prefix string, limit *int32, skip string) (*zms.DomainList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainList", prefix, limit, skip)
+	ret0, _ := ret[0].(*zms.DomainList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainList indicates an expected call of GetDomainList.
+func (mr *MockZmsClientMockRecorder) GetDomainList(prefix, limit, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainList", reflect.TypeOf((*MockZmsClient)(nil).GetDomainList), prefix, limit, skip)
+}
+
+// GetDomainSignedPolicyData mocks base method.
+func (m *MockZmsClient) GetDomainSignedPolicyData(domainName string) (*zts.DomainSignedPolicyData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainSignedPolicyData", domainName)
+	ret0, _ := ret[0].(*zts.DomainSignedPolicyData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainSignedPolicyData indicates an expected call of GetDomainSignedPolicyData.
+func (mr *MockZmsClientMockRecorder) GetDomainSignedPolicyData(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainSignedPolicyData", reflect.TypeOf((*MockZmsClient)(nil).GetDomainSignedPolicyData), domainName)
+}
+
+// GetDomainTemplateList mocks base method.
+func (m *MockZmsClient) GetDomainTemplateList(domainName string) (*zms.DomainTemplateList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainTemplateList", domainName)
+	ret0, _ := ret[0].(*zms.DomainTemplateList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainTemplateList indicates an expected call of GetDomainTemplateList.
+func (mr *MockZmsClientMockRecorder) GetDomainTemplateList(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainTemplateList", reflect.TypeOf((*MockZmsClient)(nil).GetDomainTemplateList), domainName)
+}
+
 // GetGroup mocks base method.
 func (m *MockZmsClient) GetGroup(domain, groupName string) (*zms.Group, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +309,66 @@ func (mr *MockZmsClientMockRecorder) GetGroup(domain, groupName interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockZmsClient)(nil).GetGroup), domain, groupName)
 }
 
+// GetGroupWithPendingMembers mocks base method.
+func (m *MockZmsClient) GetGroupWithPendingMembers(domain, groupName string) (*zms.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupWithPendingMembers", domain, groupName)
+	ret0, _ := ret[0].(*zms.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupWithPendingMembers indicates an expected call of GetGroupWithPendingMembers.
+func (mr *MockZmsClientMockRecorder) GetGroupWithPendingMembers(domain, groupName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupWithPendingMembers", reflect.TypeOf((*MockZmsClient)(nil).GetGroupWithPendingMembers), domain, groupName)
+}
+
+// GetGroups mocks base method.
+func (m *MockZmsClient) GetGroups(domainName string, members *bool) (*zms.Groups, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroups", domainName, members)
+	ret0, _ := ret[0].(*zms.Groups)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroups indicates an expected call of GetGroups.
+func (mr *MockZmsClientMockRecorder) GetGroups(domainName, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroups", reflect.TypeOf((*MockZmsClient)(nil).GetGroups), domainName, members)
+}
+
+// GetJWSPolicyData mocks base method.
+func (m *MockZmsClient) GetJWSPolicyData(domainName string) (*zts.JWSPolicyData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWSPolicyData", domainName)
+	ret0, _ := ret[0].(*zts.JWSPolicyData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWSPolicyData indicates an expected call of GetJWSPolicyData.
+func (mr *MockZmsClientMockRecorder) GetJWSPolicyData(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWSPolicyData", reflect.TypeOf((*MockZmsClient)(nil).GetJWSPolicyData), domainName)
+}
+
+// GetPolicies mocks base method.
+func (m *MockZmsClient) GetPolicies(domainName string, assertions, includeNonActive bool) (*zms.Policies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicies", domainName, assertions, includeNonActive)
+	ret0, _ := ret[0].(*zms.Policies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicies indicates an expected call of GetPolicies.
+func (mr *MockZmsClientMockRecorder) GetPolicies(domainName, assertions, includeNonActive interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicies", reflect.TypeOf((*MockZmsClient)(nil).GetPolicies), domainName, assertions, includeNonActive)
+}
+
 // GetPolicy mocks base method.
 func (m *MockZmsClient) GetPolicy(domain, policy string) (*zms.Policy, error) {
 	m.ctrl.T.Helper()
@@ -134,6 +384,96 @@ func (mr *MockZmsClientMockRecorder) GetPolicy(domain, policy interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicy", reflect.TypeOf((*MockZmsClient)(nil).GetPolicy), domain, policy)
 }
 
+// GetPolicyList mocks base method.
+func (m *MockZmsClient) GetPolicyList(domainName string, limit *int32, skip string) (*zms.PolicyList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicyList", domainName, limit, skip)
+	ret0, _ := ret[0].(*zms.PolicyList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicyList indicates an expected call of GetPolicyList.
+func (mr *MockZmsClientMockRecorder) GetPolicyList(domainName, limit, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyList", reflect.TypeOf((*MockZmsClient)(nil).GetPolicyList), domainName, limit, skip)
+}
+
+// GetPolicyVersion mocks base method.
+func (m *MockZmsClient) GetPolicyVersion(domainName, policyName, version string) (*zms.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicyVersion", domainName, policyName, version)
+	ret0, _ := ret[0].(*zms.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicyVersion indicates an expected call of GetPolicyVersion.
+func (mr *MockZmsClientMockRecorder) GetPolicyVersion(domainName, policyName, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).GetPolicyVersion), domainName, policyName, version)
+}
+
+// GetPolicyVersionList mocks base method.
+func (m *MockZmsClient) GetPolicyVersionList(domainName, policyName string) (*zms.PolicyList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicyVersionList", domainName, policyName)
+	ret0, _ := ret[0].(*zms.PolicyList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicyVersionList indicates an expected call of GetPolicyVersionList.
+func (mr *MockZmsClientMockRecorder) GetPolicyVersionList(domainName, policyName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyVersionList", reflect.TypeOf((*MockZmsClient)(nil).GetPolicyVersionList), domainName, policyName)
+}
+
+// GetPrincipalRoles mocks base method.
+func (m *MockZmsClient) GetPrincipalRoles(principal, domainName string) (*zms.DomainRoleMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrincipalRoles", principal, domainName)
+	ret0, _ := ret[0].(*zms.DomainRoleMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrincipalRoles indicates an expected call of GetPrincipalRoles.
+func (mr *MockZmsClientMockRecorder) GetPrincipalRoles(principal, domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrincipalRoles", reflect.TypeOf((*MockZmsClient)(nil).GetPrincipalRoles), principal, domainName)
+}
+
+// GetRaw mocks base method.
+func (m *MockZmsClient) GetRaw(path string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRaw", path)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRaw indicates an expected call of GetRaw.
+func (mr *MockZmsClientMockRecorder) GetRaw(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRaw", reflect.TypeOf((*MockZmsClient)(nil).GetRaw), path)
+}
+
+// GetRdlSchema mocks base method.
+func (m *MockZmsClient) GetRdlSchema() (*rdl.Schema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRdlSchema")
+	ret0, _ := ret[0].(*rdl.Schema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRdlSchema indicates an expected call of GetRdlSchema.
+func (mr *MockZmsClientMockRecorder) GetRdlSchema() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRdlSchema", reflect.TypeOf((*MockZmsClient)(nil).GetRdlSchema))
+}
+
 // GetRole mocks base method.
 func (m *MockZmsClient) GetRole(domain, roleName string) (*zms.Role, error) {
 	m.ctrl.T.Helper()
@@ -149,6 +489,214 @@ func (mr *MockZmsClientMockRecorder) GetRole(domain, roleName interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockZmsClient)(nil).GetRole), domain, roleName)
 }
 
+// GetRoleList mocks base method.
+func (m *MockZmsClient) GetRoleList(domainName string, limit *int32, skip string) (*zms.RoleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleList", domainName, limit, skip)
+	ret0, _ := ret[0].(*zms.RoleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleList indicates an expected call of GetRoleList.
+func (mr *MockZmsClientMockRecorder) GetRoleList(domainName, limit, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleList", reflect.TypeOf((*MockZmsClient)(nil).GetRoleList), domainName, limit, skip)
+}
+
+// GetRoleWithPendingMembers mocks base method.
+func (m *MockZmsClient) GetRoleWithPendingMembers(domain, roleName string) (*zms.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleWithPendingMembers", domain, roleName)
+	ret0, _ := ret[0].(*zms.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleWithPendingMembers indicates an expected call of GetRoleWithPendingMembers.
+func (mr *MockZmsClientMockRecorder) GetRoleWithPendingMembers(domain, roleName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleWithPendingMembers", reflect.TypeOf((*MockZmsClient)(nil).GetRoleWithPendingMembers), domain, roleName)
+}
+
+// GetRoles mocks base method.
+func (m *MockZmsClient) GetRoles(domainName string, members *bool, tagKey, tagValue string) (*zms.Roles, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", domainName, members, tagKey, tagValue)
+	ret0, _ := ret[0].(*zms.Roles)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockZmsClientMockRecorder) GetRoles(domainName, members, tagKey, tagValue interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockZmsClient)(nil).GetRoles), domainName, members, tagKey, tagValue)
+}
+
+// GetServiceIdentity mocks base method.
+func (m *MockZmsClient) GetServiceIdentity(domain, serviceName string) (*zms.ServiceIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceIdentity", domain, serviceName)
+	ret0, _ := ret[0].(*zms.ServiceIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceIdentity indicates an expected call of GetServiceIdentity.
+func (mr *MockZmsClientMockRecorder) GetServiceIdentity(domain, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceIdentity", reflect.TypeOf((*MockZmsClient)(nil).GetServiceIdentity), domain, serviceName)
+}
+
+// GetServiceIdentityList mocks base method.
+func (m *MockZmsClient) GetServiceIdentityList(domainName string, limit *int32, skip string) (*zms.ServiceIdentityList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceIdentityList", domainName, limit, skip)
+	ret0, _ := ret[0].(*zms.ServiceIdentityList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceIdentityList indicates an expected call of GetServiceIdentityList.
+func (mr *MockZmsClientMockRecorder) GetServiceIdentityList(domainName, limit, skip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceIdentityList", reflect.TypeOf((*MockZmsClient)(nil).GetServiceIdentityList), domainName, limit, skip)
+}
+
+// GetSignedDomain mocks base method.
+func (m *MockZmsClient) GetSignedDomain(domainName string) (*zms.DomainData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSignedDomain", domainName)
+	ret0, _ := ret[0].(*zms.DomainData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSignedDomain indicates an expected call of GetSignedDomain.
+func (mr *MockZmsClientMockRecorder) GetSignedDomain(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSignedDomain", reflect.TypeOf((*MockZmsClient)(nil).GetSignedDomain), domainName)
+}
+
+// GetStatus mocks base method.
+func (m *MockZmsClient) GetStatus() (*zms.Status, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus")
+	ret0, _ := ret[0].(*zms.Status)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus.
+func (mr *MockZmsClientMockRecorder) GetStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockZmsClient)(nil).GetStatus))
+}
+
+// GetUserList mocks base method.
+func (m *MockZmsClient) GetUserList(domainName string) (*zms.UserList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserList", domainName)
+	ret0, _ := ret[0].(*zms.UserList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserList indicates an expected call of GetUserList.
+func (mr *MockZmsClientMockRecorder) GetUserList(domainName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserList", reflect.TypeOf((*MockZmsClient)(nil).GetUserList), domainName)
+}
+
+// PostSubDomain mocks base method.
+func (m *MockZmsClient) PostSubDomain(parentDomain, auditRef string, detail *zms.SubDomain) (*zms.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostSubDomain", parentDomain, auditRef, detail)
+	ret0, _ := ret[0].(*zms.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostSubDomain indicates an expected call of PostSubDomain.
+func (mr *MockZmsClientMockRecorder) PostSubDomain(parentDomain, auditRef, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostSubDomain", reflect.TypeOf((*MockZmsClient)(nil).PostSubDomain), parentDomain, auditRef, detail)
+}
+
+// PostTopLevelDomain mocks base method.
+func (m *MockZmsClient) PostTopLevelDomain(auditRef string, detail *zms.TopLevelDomain) (*zms.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostTopLevelDomain", auditRef, detail)
+	ret0, _ := ret[0].(*zms.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostTopLevelDomain indicates an expected call of PostTopLevelDomain.
+func (mr *MockZmsClientMockRecorder) PostTopLevelDomain(auditRef, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostTopLevelDomain", reflect.TypeOf((*MockZmsClient)(nil).PostTopLevelDomain), auditRef, detail)
+}
+
+// PostUserDomain mocks base method.
+func (m *MockZmsClient) PostUserDomain(domainName, auditRef string, detail *zms.UserDomain) (*zms.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostUserDomain", domainName, auditRef, detail)
+	ret0, _ := ret[0].(*zms.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostUserDomain indicates an expected call of PostUserDomain.
+func (mr *MockZmsClientMockRecorder) PostUserDomain(domainName, auditRef, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostUserDomain", reflect.TypeOf((*MockZmsClient)(nil).PostUserDomain), domainName, auditRef, detail)
+}
+
+// PutAssertionPolicyVersion mocks base method.
+func (m *MockZmsClient) PutAssertionPolicyVersion(domainName, policyName, version, auditRef string, assertion *zms.Assertion) (*zms.Assertion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutAssertionPolicyVersion", domainName, policyName, version, auditRef, assertion)
+	ret0, _ := ret[0].(*zms.Assertion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutAssertionPolicyVersion indicates an expected call of PutAssertionPolicyVersion.
+func (mr *MockZmsClientMockRecorder) PutAssertionPolicyVersion(domainName, policyName, version, auditRef, assertion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutAssertionPolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).PutAssertionPolicyVersion), domainName, policyName, version, auditRef, assertion)
+}
+
+// PutDomainMeta mocks base method.
+func (m *MockZmsClient) PutDomainMeta(name, auditRef string, detail *zms.DomainMeta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutDomainMeta", name, auditRef, detail)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutDomainMeta indicates an expected call of PutDomainMeta.
+func (mr *MockZmsClientMockRecorder) PutDomainMeta(name, auditRef, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutDomainMeta", reflect.TypeOf((*MockZmsClient)(nil).PutDomainMeta), name, auditRef, detail)
+}
+
+// PutDomainTemplate mocks base method.
+func (m *MockZmsClient) PutDomainTemplate(domainName, auditRef string, template *zms.DomainTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutDomainTemplate", domainName, auditRef, template)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutDomainTemplate indicates an expected call of PutDomainTemplate.
+func (mr *MockZmsClientMockRecorder) PutDomainTemplate(domainName, auditRef, template interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutDomainTemplate", reflect.TypeOf((*MockZmsClient)(nil).PutDomainTemplate), domainName, auditRef, template)
+}
+
 // PutGroup mocks base method.
 func (m *MockZmsClient) PutGroup(domain, groupName, auditRef string, group *zms.Group) error {
 	m.ctrl.T.Helper()
@@ -205,6 +753,48 @@ func (mr *MockZmsClientMockRecorder) PutPolicy(domain, policyName, auditRef, pol
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPolicy", reflect.TypeOf((*MockZmsClient)(nil).PutPolicy), domain, policyName, auditRef, policy)
 }
 
+// PutPolicyVersion mocks base method.
+func (m *MockZmsClient) PutPolicyVersion(domainName, policyName string, policyOptions *zms.PolicyOptions, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutPolicyVersion", domainName, policyName, policyOptions, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutPolicyVersion indicates an expected call of PutPolicyVersion.
+func (mr *MockZmsClientMockRecorder) PutPolicyVersion(domainName, policyName, policyOptions, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).PutPolicyVersion), domainName, policyName, policyOptions, auditRef)
+}
+
+// PutPublicKeyEntry mocks base method.
+func (m *MockZmsClient) PutPublicKeyEntry(domain, serviceName, auditRef string, publicKeyEntry *zms.PublicKeyEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutPublicKeyEntry", domain, serviceName, auditRef, publicKeyEntry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutPublicKeyEntry indicates an expected call of PutPublicKeyEntry.
+func (mr *MockZmsClientMockRecorder) PutPublicKeyEntry(domain, serviceName, auditRef, publicKeyEntry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPublicKeyEntry", reflect.TypeOf((*MockZmsClient)(nil).PutPublicKeyEntry), domain, serviceName, auditRef, publicKeyEntry)
+}
+
+// PutRaw mocks base method.
+func (m *MockZmsClient) PutRaw(path, auditRef string, body []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRaw", path, auditRef, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutRaw indicates an expected call of PutRaw.
+func (mr *MockZmsClientMockRecorder) PutRaw(path, auditRef, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRaw", reflect.TypeOf((*MockZmsClient)(nil).PutRaw), path, auditRef, body)
+}
+
 // PutRole mocks base method.
 func (m *MockZmsClient) PutRole(domain, roleName, auditRef string, role *zms.Role) error {
 	m.ctrl.T.Helper()
@@ -218,3 +808,60 @@ func (mr *MockZmsClientMockRecorder) PutRole(domain, roleName, auditRef, role in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRole", reflect.TypeOf((*MockZmsClient)(nil).PutRole), domain, roleName, auditRef, role)
 }
+
+// PutServiceIdentity mocks base method.
+func (m *MockZmsClient) PutServiceIdentity(domain, serviceName, auditRef string, detail *zms.ServiceIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutServiceIdentity", domain, serviceName, auditRef, detail)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutServiceIdentity indicates an expected call of PutServiceIdentity.
+func (mr *MockZmsClientMockRecorder) PutServiceIdentity(domain, serviceName, auditRef, detail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutServiceIdentity", reflect.TypeOf((*MockZmsClient)(nil).PutServiceIdentity), domain, serviceName, auditRef, detail)
+}
+
+// SetActivePolicyVersion mocks base method.
+func (m *MockZmsClient) SetActivePolicyVersion(domainName, policyName string, policyOptions *zms.PolicyOptions, auditRef string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetActivePolicyVersion", domainName, policyName, policyOptions, auditRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetActivePolicyVersion indicates an expected call of SetActivePolicyVersion.
+func (mr *MockZmsClientMockRecorder) SetActivePolicyVersion(domainName, policyName, policyOptions, auditRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetActivePolicyVersion", reflect.TypeOf((*MockZmsClient)(nil).SetActivePolicyVersion), domainName, policyName, policyOptions, auditRef)
+}
+
+// ValidateTransportPolicy mocks base method.
+func (m *MockZmsClient) ValidateTransportPolicy(request *msd.TransportPolicyValidationRequest) (*msd.TransportPolicyValidationResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTransportPolicy", request)
+	ret0, _ := ret[0].(*msd.TransportPolicyValidationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateTransportPolicy indicates an expected call of ValidateTransportPolicy.
+func (mr *MockZmsClientMockRecorder) ValidateTransportPolicy(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTransportPolicy", reflect.TypeOf((*MockZmsClient)(nil).ValidateTransportPolicy), request)
+}
+
+// WithContext mocks base method.
+func (m *MockZmsClient) WithContext(ctx context.Context) ZmsClient {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithContext", ctx)
+	ret0, _ := ret[0].(ZmsClient)
+	return ret0
+}
+
+// WithContext indicates an expected call of WithContext.
+func (mr *MockZmsClientMockRecorder) WithContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockZmsClient)(nil).WithContext), ctx)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// GetRaw returns the JSON of the object of the path of the ZMS API, e.g. /domain/some_domain/role/readers
+func (c Client) GetRaw(path string) ([]byte, error) {
+	return c.rawRequest(http.MethodGet, path, nil, nil)
+}
+
+// PutRaw sends the JSON body to the path of the ZMS API, for the objects without a dedicated request
+func (c Client) PutRaw(path string, auditRef string, body []byte) error {
+	_, err := c.rawRequest(http.MethodPut, path, &auditRef, body)
+	return err
+}
+
+// DeleteRaw deletes the object of the path of the ZMS API
+func (c Client) DeleteRaw(path string, auditRef string) error {
+	_, err := c.rawRequest(http.MethodDelete, path, &auditRef, nil)
+	return err
+}
+
+// rawRequest sends the request as the zms client does, through the same transport, and returns the error
+// of a response that isn't successful as the zms client does, an rdl.ResourceError
+func (c Client) rawRequest(method string, path string, auditRef *string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.Url, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auditRef != nil {
+		req.Header.Set(auditRefHeader, *auditRef)
+	}
+	httpClient := &http.Client{Transport: c.Transport}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return content, nil
+	}
+	var errobj rdl.ResourceError
+	_ = json.Unmarshal(content, &errobj)
+	if errobj.Code == 0 {
+		errobj.Code = resp.StatusCode
+	}
+	if errobj.Message == "" {
+		errobj.Message = string(content)
+	}
+	return nil, errobj
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	ast "gotest.tools/assert"
+)
+
+func Test_rawRequest(t *testing.T) {
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			ast.Equal(t, r.Header.Get("Content-Type"), "application/json")
+			ast.Equal(t, r.Header.Get(auditRefHeader), "TICKET-1234")
+			body, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code": 404, "message": "getRole: Role not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	zmsClient := Client{Url: server.URL + "/zms/v1/", Transport: http.DefaultTransport}
+	path := "/domain/some_domain/role/readers"
+
+	ast.NilError(t, zmsClient.PutRaw(path, "TICKET-1234", []byte(`{"name": "some_domain:role.readers"}`)))
+	ast.DeepEqual(t, objects, map[string]string{"/zms/v1" + path: `{"name": "some_domain:role.readers"}`})
+	body, err := zmsClient.GetRaw(path)
+	ast.NilError(t, err)
+	ast.Equal(t, string(body), `{"name": "some_domain:role.readers"}`)
+
+	ast.NilError(t, zmsClient.DeleteRaw(path, "TICKET-1234"))
+	_, err = zmsClient.GetRaw(path)
+	ast.DeepEqual(t, err, rdl.ResourceError{Code: 404, Message: "getRole: Role not found"})
+}
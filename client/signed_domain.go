@@ -0,0 +1,211 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// getSignedDomain returns the signed domain of the domain and its ETag, or a nil domain when it wasn't
+// changed since matchingTag. the signed domain of a large domain can be tens of MBs, so the response is
+// decoded as a stream, one object at a time. only the sections read from the snapshot (the roles, the
+// groups and the policies) are kept, unless withServices is set: the services and the attributes of the
+// domain are kept then. the entities are always skipped without being decoded
+func (c Client) getSignedDomain(domain string, matchingTag string, withServices bool) (*zms.DomainData, string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.Url+"/sys/modified_domains?domain="+url.QueryEscape(domain), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if matchingTag != "" {
+		req.Header.Set("If-None-Match", matchingTag)
+	}
+	resp, err := c.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	tag := resp.Header.Get("ETag")
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := decodeSignedDomain(resp.Body, withServices)
+		return data, tag, err
+	case http.StatusNotModified:
+		return nil, tag, nil
+	default:
+		content, _ := ioutil.ReadAll(resp.Body)
+		errobj := rdl.ResourceError{Code: resp.StatusCode, Message: string(content)}
+		_ = json.Unmarshal(content, &errobj)
+		return nil, "", errobj
+	}
+}
+
+// decodeSignedDomain decodes the single domain of a SignedDomains response
+func decodeSignedDomain(r io.Reader, withServices bool) (*zms.DomainData, error) {
+	dec := json.NewDecoder(r)
+	var data *zms.DomainData
+	err := decodeObject(dec, func(key string) error {
+		if key != "domains" {
+			return skipValue(dec)
+		}
+		return decodeArray(dec, func() error {
+			if data != nil {
+				return fmt.Errorf("the response has more than one domain")
+			}
+			return decodeObject(dec, func(key string) error {
+				if key != "domain" {
+					return skipValue(dec)
+				}
+				data = &zms.DomainData{}
+				return decodeDomainData(dec, data, withServices)
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("the response doesn't have the domain")
+	}
+	return data, nil
+}
+
+func decodeDomainData(dec *json.Decoder, data *zms.DomainData, withServices bool) error {
+	// the services and the attributes of the domain, decoded into the domain at the end
+	others := make(map[string]json.RawMessage)
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "name":
+			return dec.Decode(&data.Name)
+		case "modified":
+			return dec.Decode(&data.Modified)
+		case "roles":
+			return decodeArray(dec, func() error {
+				var role zms.Role
+				err := dec.Decode(&role)
+				data.Roles = append(data.Roles, &role)
+				return err
+			})
+		case "groups":
+			return decodeArray(dec, func() error {
+				var group zms.Group
+				err := dec.Decode(&group)
+				data.Groups = append(data.Groups, &group)
+				return err
+			})
+		case "policies":
+			// the policies are decoded without the validation of their signature fields, the
+			// signature isn't verified
+			data.Policies = &zms.SignedPolicies{}
+			return decodeObject(dec, func(key string) error {
+				switch key {
+				case "contents":
+					return dec.Decode(&data.Policies.Contents)
+				case "signature":
+					return dec.Decode(&data.Policies.Signature)
+				case "keyId":
+					return dec.Decode(&data.Policies.KeyId)
+				default:
+					return skipValue(dec)
+				}
+			})
+		case "entities":
+			return skipValue(dec)
+		default:
+			if !withServices {
+				return skipValue(dec)
+			}
+			var value json.RawMessage
+			err := dec.Decode(&value)
+			others[key] = value
+			return err
+		}
+	})
+	if err != nil || len(others) == 0 {
+		return err
+	}
+	content, err := json.Marshal(others)
+	if err != nil {
+		return err
+	}
+	// decoded without the validation of the domain, its required sections are decoded already
+	type attributes zms.DomainData
+	return json.Unmarshal(content, (*attributes)(data))
+}
+
+// decodeObject calls decodeValue with the key of each value of the next object, to decode or skip it.
+// a null object has no values
+func decodeObject(dec *json.Decoder, decodeValue func(key string) error) error {
+	if null, err := expectDelim(dec, '{'); err != nil || null {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v in an object", token)
+		}
+		if err = decodeValue(key); err != nil {
+			return err
+		}
+	}
+	_, err := expectDelim(dec, '}')
+	return err
+}
+
+// decodeArray calls decodeElement for each element of the next array, a null array has no elements
+func decodeArray(dec *json.Decoder, decodeElement func() error) error {
+	if null, err := expectDelim(dec, '['); err != nil || null {
+		return err
+	}
+	for dec.More() {
+		if err := decodeElement(); err != nil {
+			return err
+		}
+	}
+	_, err := expectDelim(dec, ']')
+	return err
+}
+
+// expectDelim consumes the delimiter, or a null instead of an opening one
+func expectDelim(dec *json.Decoder, delim json.Delim) (bool, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil && (delim == '{' || delim == '[') {
+		return true, nil
+	}
+	if token != delim {
+		return false, fmt.Errorf("unexpected %v, expected %v", token, delim)
+	}
+	return false, nil
+}
+
+// skipValue consumes the next value, without keeping it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
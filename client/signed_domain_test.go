@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	ast "gotest.tools/assert"
+)
+
+func Test_decodeSignedDomain(t *testing.T) {
+	response := `{"domains": [{"keyId": "0", "domain": {
+		"name": "home.someone",
+		"auditEnabled": true,
+		"services": [{"name": "home.someone.api", "publicKeys": [{"id": "0", "key": "a very long key"}]}],
+		"roles": [{"name": "home.someone:role.readers", "roleMembers": [{"memberName": "user.jane"}]}],
+		"entities": [{"name": "home.someone:entity.config", "value": {"a": "b"}}],
+		"groups": null,
+		"policies": {"contents": {"domain": "home.someone", "policies": [{"name": "home.someone:policy.readers", "assertions": []}]}},
+		"modified": "2021-06-01T10:00:00.000Z"
+	}, "signature": "sig"}]}`
+	data, err := decodeSignedDomain(strings.NewReader(response), false)
+	ast.NilError(t, err)
+	ast.Equal(t, data.Name, zms.DomainName("home.someone"))
+	ast.Equal(t, len(data.Roles), 1)
+	ast.Equal(t, data.Roles[0].RoleMembers[0].MemberName, zms.MemberName("user.jane"))
+	ast.Equal(t, len(data.Groups), 0)
+	ast.Equal(t, len(data.Services), 0)
+	ast.Equal(t, data.Policies.Contents.Policies[0].Name, zms.ResourceName("home.someone:policy.readers"))
+	ast.Equal(t, data.Modified.String(), "2021-06-01T10:00:00.000Z")
+	ast.Assert(t, data.AuditEnabled == nil)
+
+	// case: the services and the attributes of the domain are kept, the entities are still skipped
+	data, err = decodeSignedDomain(strings.NewReader(response), true)
+	ast.NilError(t, err)
+	ast.Equal(t, len(data.Roles), 1)
+	ast.Equal(t, data.Services[0].PublicKeys[0].Key, "a very long key")
+	ast.Assert(t, data.AuditEnabled != nil && *data.AuditEnabled)
+	ast.Equal(t, len(data.Entities), 0)
+
+	_, err = decodeSignedDomain(strings.NewReader(`{"domains": []}`), false)
+	ast.ErrorContains(t, err, "the response doesn't have the domain")
+	_, err = decodeSignedDomain(strings.NewReader(`{"domains": [{"domain": {"name": "a"}}, {"domain": {"name": "b"}}]}`), false)
+	ast.ErrorContains(t, err, "more than one domain")
+	_, err = decodeSignedDomain(strings.NewReader(`{"domains": [{"domain": {"name": "a"`), false)
+	ast.Assert(t, err != nil)
+}
+
+func Test_GetSignedDomainLarge(t *testing.T) {
+	// a domain with thousands of roles and large entities, the entities aren't decoded
+	var body strings.Builder
+	body.WriteString(`{"domains": [{"domain": {"name": "home.someone", "roles": [`)
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"name": "home.someone:role.r%d", "roleMembers": [{"memberName": "user.jane"}]}`, i)
+	}
+	body.WriteString(`], "entities": [{"name": "home.someone:entity.blob", "value": {"data": "`)
+	body.WriteString(strings.Repeat("x", 8<<20))
+	body.WriteString(`"}}], "services": [{"name": "home.someone.api"}]}}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ast.Equal(t, r.URL.Path, "/sys/modified_domains")
+		ast.Equal(t, r.URL.Query().Get("domain"), "home.someone")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer server.Close()
+
+	data, err := Client{Url: server.URL, Transport: http.DefaultTransport}.GetSignedDomain("home.someone")
+	ast.NilError(t, err)
+	ast.Equal(t, len(data.Roles), 20000)
+	ast.Equal(t, len(data.Services), 1)
+	ast.Equal(t, len(data.Entities), 0)
+}
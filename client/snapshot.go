@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// domainSnapshots keeps the signed domain of each domain whose objects are read, so the refresh of
+// the roles, groups and policies of a domain fetches the domain once instead of once per object.
+// the snapshot of a domain is dropped by any write to the domain, so the reads after a change see it
+type domainSnapshots struct {
+	mu      sync.Mutex
+	domains map[string]*domainSnapshot
+	// the last signed domain fetched of each domain with its ETag. it's kept after the snapshot is
+	// dropped, so the next fetch is a conditional request that doesn't transfer an unchanged domain
+	tagged map[string]taggedDomain
+}
+
+type domainSnapshot struct {
+	once sync.Once
+	// data is nil when the signed domain couldn't be fetched, the objects are read one by one then
+	data *zms.DomainData
+}
+
+// taggedDomain is also the content of the file of a domain in the cache directory
+type taggedDomain struct {
+	Tag    string          `json:"tag"`
+	Domain *zms.DomainData `json:"domain"`
+}
+
+func newDomainSnapshots() *domainSnapshots {
+	return &domainSnapshots{
+		domains: make(map[string]*domainSnapshot),
+		tagged:  make(map[string]taggedDomain),
+	}
+}
+
+// get returns the snapshot of the domain, fetched on the first call. the concurrent reads of the
+// same domain wait for a single fetch. fetch is called with the ETag of the last signed domain of
+// the domain, from this run or from the cache directory dir when it's set, and returns a nil domain
+// when the domain wasn't changed since
+func (s *domainSnapshots) get(domain string, dir string, fetch func(matchingTag string) (*zms.DomainData, string, error)) *zms.DomainData {
+	domain = strings.ToLower(domain)
+	s.mu.Lock()
+	snapshot, ok := s.domains[domain]
+	if !ok {
+		snapshot = &domainSnapshot{}
+		s.domains[domain] = snapshot
+	}
+	s.mu.Unlock()
+	snapshot.once.Do(func() {
+		last := s.lastTagged(domain, dir)
+		data, tag, err := fetch(last.Tag)
+		if err != nil {
+			log.Printf("[WARN] can't read the signed domain %s, its objects are read one by one: %s", domain, err)
+			return
+		}
+		if data == nil {
+			if last.Domain == nil {
+				log.Printf("[WARN] the response of the signed domain %s is empty, its objects are read one by one", domain)
+				return
+			}
+			log.Printf("[DEBUG] the signed domain %s isn't modified since %s", domain, last.Tag)
+			data, tag = last.Domain, last.Tag
+		}
+		snapshot.data = data
+		if tag != "" {
+			s.remember(domain, dir, taggedDomain{Tag: tag, Domain: data})
+		}
+	})
+	return snapshot.data
+}
+
+func (s *domainSnapshots) lastTagged(domain string, dir string) taggedDomain {
+	s.mu.Lock()
+	last, ok := s.tagged[domain]
+	s.mu.Unlock()
+	if ok || dir == "" {
+		return last
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, domain+".json"))
+	if err != nil {
+		return last
+	}
+	// the domain is decoded as the signed domain in a response
+	var cached struct {
+		Tag    string          `json:"tag"`
+		Domain json.RawMessage `json:"domain"`
+	}
+	data := &zms.DomainData{}
+	if err = json.Unmarshal(content, &cached); err == nil {
+		err = decodeDomainData(json.NewDecoder(bytes.NewReader(cached.Domain)), data, false)
+	}
+	if err != nil {
+		log.Printf("[WARN] ignoring the cached signed domain %s: %s", domain, err)
+		return taggedDomain{}
+	}
+	return taggedDomain{Tag: cached.Tag, Domain: data}
+}
+
+func (s *domainSnapshots) remember(domain string, dir string, tagged taggedDomain) {
+	s.mu.Lock()
+	s.tagged[domain] = tagged
+	s.mu.Unlock()
+	if dir == "" {
+		return
+	}
+	if err := writeFileAtomically(filepath.Join(dir, domain+".json"), tagged); err != nil {
+		log.Printf("[WARN] can't cache the signed domain %s in %s: %s", domain, dir, err)
+	}
+}
+
+// writeFileAtomically writes the file with a rename, so a run reading the file doesn't see it partially
+// written by another run
+func writeFileAtomically(path string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (s *domainSnapshots) invalidate(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.domains, strings.ToLower(domain))
+}
+
+// invalidatingTransport drops the snapshot of the domain of every write, before the request and
+// again after it, so a snapshot fetched during the request isn't kept
+func (s *domainSnapshots) invalidatingTransport(transport http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if isReadOnly(req.Method) {
+			return transport.RoundTrip(req)
+		}
+		domain := domainOf(req.URL.Path)
+		s.invalidate(domain)
+		defer s.invalidate(domain)
+		return transport.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// signedDomain returns the snapshot of the domain when the reads from the signed domain are enabled
+func (c Client) signedDomain(domain string) *zms.DomainData {
+	if !c.SignedDomainReads || c.snapshots == nil {
+		return nil
+	}
+	return c.snapshots.get(domain, c.SignedDomainCacheDir, func(matchingTag string) (*zms.DomainData, string, error) {
+		return c.getSignedDomain(domain, matchingTag, false)
+	})
+}
+
+func snapshotNotFound(kind string, name string) error {
+	return rdl.ResourceError{Code: http.StatusNotFound, Message: fmt.Sprintf("%s %s not found", kind, name)}
+}
+
+// the objects are deep copied, their members, assertions and tags included, so a change of the returned
+// object by the caller doesn't change the snapshot read by the next calls
+
+func snapshotRole(data *zms.DomainData, domain string, roleName string) (*zms.Role, error) {
+	name := domain + ":role." + roleName
+	for _, role := range data.Roles {
+		if strings.EqualFold(string(role.Name), name) {
+			r := &zms.Role{}
+			if err := copyObject(role, r); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+	}
+	return nil, snapshotNotFound("role", name)
+}
+
+func snapshotGroup(data *zms.DomainData, domain string, groupName string) (*zms.Group, error) {
+	name := domain + ":group." + groupName
+	for _, group := range data.Groups {
+		if strings.EqualFold(string(group.Name), name) {
+			g := &zms.Group{}
+			if err := copyObject(group, g); err != nil {
+				return nil, err
+			}
+			return g, nil
+		}
+	}
+	return nil, snapshotNotFound("group", name)
+}
+
+// snapshotPolicy returns the active version of the policy, as the signed domain has only the active versions
+func snapshotPolicy(data *zms.DomainData, domain string, policyName string) (*zms.Policy, error) {
+	name := domain + ":policy." + policyName
+	if data.Policies != nil && data.Policies.Contents != nil {
+		for _, policy := range data.Policies.Contents.Policies {
+			if strings.EqualFold(string(policy.Name), name) && (policy.Active == nil || *policy.Active) {
+				p := &zms.Policy{}
+				if err := copyObject(policy, p); err != nil {
+					return nil, err
+				}
+				return p, nil
+			}
+		}
+	}
+	return nil, snapshotNotFound("policy", name)
+}
+
+// copyObject deep copies an object of ZMS through its JSON, as it was decoded from the response. the objects
+// have many optional pointers, slices and maps, a copy by hand would miss the ones added by a new version
+func copyObject(src interface{}, dst interface{}) error {
+	content, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, dst)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	ast "gotest.tools/assert"
+)
+
+const signedDomainResponse = `{"domains": [{"domain": {
+	"name": "home.someone",
+	"modified": "2021-06-01T10:00:00.000Z",
+	"roles": [{"name": "home.someone:role.readers", "roleMembers": [{"memberName": "user.jane"}]}],
+	"groups": [{"name": "home.someone:group.devs", "groupMembers": [{"memberName": "user.john"}]}],
+	"policies": {"contents": {"domain": "home.someone", "policies": [
+		{"name": "home.someone:policy.readers", "version": "0", "active": false, "assertions": []},
+		{"name": "home.someone:policy.readers", "version": "1", "active": true, "assertions": []}
+	]}, "signature": "sig", "keyId": "0"}
+}, "signature": "sig", "keyId": "0"}]}`
+
+func Test_signedDomainReads(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.Method+" "+r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/sys/modified_domains"):
+			_, _ = w.Write([]byte(signedDomainResponse))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"name": "home.someone:role.writers"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	snapshots := newDomainSnapshots()
+	zmsClient := Client{Url: server.URL, Transport: snapshots.invalidatingTransport(http.DefaultTransport), SignedDomainReads: true, snapshots: snapshots}
+
+	role, err := zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, role.RoleMembers[0].MemberName, zms.MemberName("user.jane"))
+	group, err := zmsClient.GetGroup("home.someone", "devs")
+	ast.NilError(t, err)
+	ast.Equal(t, group.GroupMembers[0].MemberName, zms.GroupMemberName("user.john"))
+	policy, err := zmsClient.GetPolicy("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, policy.Version, zms.SimpleName("1"))
+	ast.Equal(t, requests["GET /sys/modified_domains"], 1)
+
+	// case: a change of a returned object doesn't change the snapshot
+	role.RoleMembers[0].MemberName = "user.joe"
+	group.GroupMembers = append(group.GroupMembers[:0], &zms.GroupMember{MemberName: "user.joe"})
+	role, err = zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, role.RoleMembers[0].MemberName, zms.MemberName("user.jane"))
+	group, err = zmsClient.GetGroup("home.someone", "devs")
+	ast.NilError(t, err)
+	ast.Equal(t, group.GroupMembers[0].MemberName, zms.GroupMemberName("user.john"))
+	ast.Equal(t, requests["GET /sys/modified_domains"], 1)
+
+	// case: the object isn't in the domain
+	_, err = zmsClient.GetRole("home.someone", "writers")
+	ast.ErrorContains(t, err, "404")
+
+	// case: a write to the domain drops the snapshot
+	ast.NilError(t, zmsClient.DeleteRole("home.someone", "readers", "test"))
+	_, err = zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, requests["GET /sys/modified_domains"], 2)
+
+	// case: the reads from the signed domain aren't enabled
+	zmsClient.SignedDomainReads = false
+	role, err = zmsClient.GetRole("home.someone", "writers")
+	ast.NilError(t, err)
+	ast.Equal(t, role.Name, zms.ResourceName("home.someone:role.writers"))
+	ast.Equal(t, requests["GET /sys/modified_domains"], 2)
+}
+
+func Test_signedDomainReadsFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/sys/modified_domains") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code": 403, "message": "Forbidden"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "home.someone:role.readers"}`))
+	}))
+	defer server.Close()
+	zmsClient := Client{Url: server.URL, Transport: http.DefaultTransport, SignedDomainReads: true, snapshots: newDomainSnapshots()}
+
+	// the role is read by itself when the signed domain can't be read
+	role, err := zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, role.Name, zms.ResourceName("home.someone:role.readers"))
+}
+
+func Test_signedDomainConditionalFetch(t *testing.T) {
+	transferred := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", "2021-06-01T10:00:00.000Z")
+		if r.Header.Get("If-None-Match") == "2021-06-01T10:00:00.000Z" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		transferred++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(signedDomainResponse))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	newClient := func() Client {
+		return Client{Url: server.URL, Transport: http.DefaultTransport, SignedDomainReads: true, SignedDomainCacheDir: dir, snapshots: newDomainSnapshots()}
+	}
+
+	zmsClient := newClient()
+	_, err := zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, transferred, 1)
+
+	// case: the snapshot is dropped, the unchanged domain isn't transferred again
+	zmsClient.snapshots.invalidate("home.someone")
+	_, err = zmsClient.GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, transferred, 1)
+
+	// case: the next run reads the unchanged domain from the cache directory
+	role, err := newClient().GetRole("home.someone", "readers")
+	ast.NilError(t, err)
+	ast.Equal(t, role.RoleMembers[0].MemberName, zms.MemberName("user.jane"))
+	ast.Equal(t, transferred, 1)
+}
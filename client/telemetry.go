@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/unit"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/AthenZ/terraform-provider-athenz/client"
+	// the spans are exported at least every second, so few are lost when terraform stops the provider
+	spanBatchTimeout     = time.Second
+	metricCollectPeriod  = 10 * time.Second
+	telemetryServiceName = "terraform-provider-athenz"
+)
+
+// Telemetry exports a span and the metrics of every request to ZMS and ZTS to an OpenTelemetry
+// collector with OTLP over HTTP
+type Telemetry struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	shutdown       func(ctx context.Context) error
+}
+
+var (
+	telemetryMu sync.Mutex
+	// the telemetry started by endpoint, the provider may be configured more than once in a run
+	telemetries = map[string]*Telemetry{}
+)
+
+// StartTelemetry starts the export to the collector at the endpoint, e.g. https://otel-collector:4318.
+// the export of an endpoint is started once, and stopped by ShutdownTelemetry
+func StartTelemetry(endpoint string) (*Telemetry, error) {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	if telemetry, ok := telemetries[endpoint]; ok {
+		return telemetry, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, expected http(s)://<host>:<port>, e.g. https://otel-collector:4318", endpoint)
+	}
+	basePath := strings.TrimSuffix(u.Path, "/")
+	traceOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host), otlptracehttp.WithURLPath(basePath + "/v1/traces")}
+	metricOptions := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(u.Host), otlpmetrichttp.WithURLPath(basePath + "/v1/metrics")}
+	if u.Scheme == "http" {
+		traceOptions = append(traceOptions, otlptracehttp.WithInsecure())
+		metricOptions = append(metricOptions, otlpmetrichttp.WithInsecure())
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(telemetryServiceName))
+
+	// the exporters outlive the configuration of the provider, they don't use its context
+	ctx := context.Background()
+	traceExporter, err := otlptracehttp.New(ctx, traceOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the OTLP trace exporter: %s", err)
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter, sdktrace.WithBatchTimeout(spanBatchTimeout)),
+		sdktrace.WithResource(res),
+	)
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOptions...)
+	if err != nil {
+		_ = tracerProvider.Shutdown(ctx)
+		return nil, fmt.Errorf("can't create the OTLP metric exporter: %s", err)
+	}
+	metricController := controller.New(
+		processor.NewFactory(simple.NewWithHistogramDistribution(), metricExporter),
+		controller.WithExporter(metricExporter),
+		controller.WithCollectPeriod(metricCollectPeriod),
+		controller.WithResource(res),
+	)
+	if err = metricController.Start(ctx); err != nil {
+		_ = tracerProvider.Shutdown(ctx)
+		return nil, fmt.Errorf("can't start the OTLP metric export: %s", err)
+	}
+
+	telemetry := &Telemetry{
+		tracerProvider: tracerProvider,
+		meterProvider:  metricController,
+		shutdown: func(ctx context.Context) error {
+			// the controller exports the last metrics when it's stopped
+			metricErr := metricController.Stop(ctx)
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				return err
+			}
+			return metricErr
+		},
+	}
+	telemetries[endpoint] = telemetry
+	return telemetry, nil
+}
+
+// ShutdownTelemetry exports the spans and the metrics that weren't exported yet, and stops the export
+func ShutdownTelemetry(ctx context.Context) error {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	var errs []string
+	for endpoint, telemetry := range telemetries {
+		if err := telemetry.shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", endpoint, err))
+		}
+		delete(telemetries, endpoint)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("can't export the telemetry to %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+var (
+	serviceKey  = attribute.Key("athenz.service")
+	endpointKey = attribute.Key("athenz.endpoint")
+	outcomeKey  = attribute.Key("athenz.outcome")
+	domainKey   = attribute.Key("athenz.domain")
+	retriesKey  = attribute.Key("athenz.retries")
+)
+
+// telemetryTransport records a span, the latency and the outcome of every request with the endpoint
+// template of the path, e.g. /domain/{domain}/role/{role}. the retries of retryTransport are counted
+// within the request, so a span covers all the attempts
+type telemetryTransport struct {
+	transport http.RoundTripper
+	service   string
+	tracer    trace.Tracer
+	requests  metric.Int64Counter
+	duration  metric.Float64Histogram
+	retries   metric.Int64Counter
+}
+
+func newTelemetryTransport(transport http.RoundTripper, service string, telemetry *Telemetry) *telemetryTransport {
+	meter := metric.Must(telemetry.meterProvider.Meter(instrumentationName))
+	return &telemetryTransport{
+		transport: transport,
+		service:   service,
+		tracer:    telemetry.tracerProvider.Tracer(instrumentationName),
+		requests:  meter.NewInt64Counter("athenz.client.requests", metric.WithDescription("The number of requests by endpoint and outcome")),
+		duration:  meter.NewFloat64Histogram("athenz.client.duration", metric.WithDescription("The latency of the requests by endpoint, with the retries"), metric.WithUnit(unit.Milliseconds)),
+		retries:   meter.NewInt64Counter("athenz.client.retries", metric.WithDescription("The number of retries of the requests that failed with a transient error")),
+	}
+}
+
+func (t *telemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointOf(req.URL.Path)
+	ctx, span := t.tracer.Start(req.Context(), strings.ToUpper(t.service)+" "+req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			serviceKey.String(t.service),
+			semconv.HTTPMethodKey.String(req.Method),
+			endpointKey.String(endpoint),
+			domainKey.String(domainOf(req.URL.Path)),
+		))
+	defer span.End()
+	retries := new(int)
+	ctx = context.WithValue(ctx, retryCountKey{}, retries)
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+
+	outcome := "error"
+	statusCode := 0
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		statusCode = resp.StatusCode
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
+		switch {
+		case statusCode >= http.StatusInternalServerError:
+			outcome = "server_error"
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		case statusCode >= http.StatusBadRequest:
+			// e.g. the 404 of the reads checking whether an object exists, it's not an error of the request
+			outcome = "client_error"
+		default:
+			outcome = "ok"
+		}
+	}
+	span.SetAttributes(retriesKey.Int(*retries))
+
+	labels := []attribute.KeyValue{serviceKey.String(t.service), semconv.HTTPMethodKey.String(req.Method), endpointKey.String(endpoint)}
+	t.duration.Record(ctx, elapsed, labels...)
+	t.requests.Add(ctx, 1, append(labels, outcomeKey.String(outcome), semconv.HTTPStatusCodeKey.Int(statusCode))...)
+	if *retries > 0 {
+		t.retries.Add(ctx, int64(*retries), labels...)
+	}
+	return resp, err
+}
+
+// retryCountKey is the context key of the number of retries of a request
+type retryCountKey struct{}
+
+// countRetry counts a retry of the request in its telemetry, if any
+func countRetry(ctx context.Context) {
+	if retries, ok := ctx.Value(retryCountKey{}).(*int); ok {
+		*retries++
+	}
+}
+
+// the segments of the paths that aren't names, e.g. signed in /domain/<domain>/policy/signed
+var pathKeywords = map[string]bool{
+	"signed":           true,
+	"system":           true,
+	"modified_domains": true,
+}
+
+// endpointOf returns the path of a request without the prefix of the API and with the names replaced by
+// the kind of the object, e.g. /domain/{domain}/role/{role} for /zms/v1/domain/some_domain/role/readers
+func endpointOf(path string) string {
+	if i := strings.Index(path, "/v1/"); i >= 0 {
+		path = path[i+len("/v1"):]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(segments); i += 2 {
+		if !pathKeywords[segments[i]] {
+			segments[i] = "{" + segments[i-1] + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
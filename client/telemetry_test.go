@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/metrictest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	ast "gotest.tools/assert"
+)
+
+func Test_endpointOf(t *testing.T) {
+	ast.Equal(t, endpointOf("/zms/v1/domain/some_domain/role/readers/member/user.jane"), "/domain/{domain}/role/{role}/member/{member}")
+	ast.Equal(t, endpointOf("/zms/v1/domain/some_domain/role"), "/domain/{domain}/role")
+	ast.Equal(t, endpointOf("/zms/v1/domain"), "/domain")
+	ast.Equal(t, endpointOf("/zts/v1/domain/some_domain/policy/signed"), "/domain/{domain}/policy/signed")
+	ast.Equal(t, endpointOf("/zms/v1/sys/modified_domains"), "/sys/modified_domains")
+}
+
+func Test_telemetryTransport(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusInternalServerError)
+		case calls == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	spans := tracetest.NewSpanRecorder()
+	meterProvider := metrictest.NewMeterProvider()
+	retry := newTestRetryTransport()
+	retry.maxRetries = 1
+	transport := newTelemetryTransport(retry, "zms", &Telemetry{
+		tracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		meterProvider:  meterProvider,
+	})
+
+	// case: a read retried once, then not found
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/zms/v1/domain/some_domain/role/readers", nil)
+	ast.NilError(t, err)
+	resp, err := transport.RoundTrip(req)
+	ast.NilError(t, err)
+	ast.Equal(t, resp.StatusCode, http.StatusNotFound)
+
+	// case: a write failed with a server error after the retry
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/zms/v1/domain/some_domain/role/readers", nil)
+	ast.NilError(t, err)
+	resp, err = transport.RoundTrip(req)
+	ast.NilError(t, err)
+	ast.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+
+	ended := spans.Ended()
+	ast.Equal(t, len(ended), 2)
+	ast.Equal(t, ended[0].Name(), "ZMS GET /domain/{domain}/role/{role}")
+	ast.Equal(t, ended[0].Status().Code, codes.Unset)
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range ended[0].Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	ast.Equal(t, attributes[domainKey].AsString(), "some_domain")
+	ast.Equal(t, attributes[retriesKey].AsInt64(), int64(1))
+	ast.Equal(t, attributes["http.status_code"].AsInt64(), int64(http.StatusNotFound))
+	ast.Equal(t, ended[1].Status().Code, codes.Error)
+
+	measured := map[string][]metrictest.Measured{}
+	for _, m := range metrictest.AsStructs(meterProvider.MeasurementBatches) {
+		measured[m.Name] = append(measured[m.Name], m)
+	}
+	ast.Equal(t, len(measured["athenz.client.duration"]), 2)
+	ast.Equal(t, len(measured["athenz.client.requests"]), 2)
+	ast.Equal(t, measured["athenz.client.requests"][0].Labels[outcomeKey].AsString(), "client_error")
+	ast.Equal(t, measured["athenz.client.requests"][1].Labels[outcomeKey].AsString(), "server_error")
+	// the domain isn't a label of the metrics, the number of series doesn't grow with the domains
+	_, ok := measured["athenz.client.requests"][0].Labels[domainKey]
+	ast.Assert(t, !ok)
+	// the delete is idempotent, it's retried too
+	ast.Equal(t, len(measured["athenz.client.retries"]), 2)
+	ast.Equal(t, measured["athenz.client.retries"][0].Number.AsInt64(), int64(1))
+}
+
+func Test_StartTelemetry(t *testing.T) {
+	_, err := StartTelemetry("otel-collector:4318")
+	ast.ErrorContains(t, err, "invalid OTLP endpoint")
+
+	telemetry, err := StartTelemetry("http://localhost:4318")
+	ast.NilError(t, err)
+	same, err := StartTelemetry("http://localhost:4318")
+	ast.NilError(t, err)
+	ast.Assert(t, telemetry == same)
+	ctx, cancel := context.WithCancel(context.Background())
+	// nothing was recorded, there's nothing to export
+	cancel()
+	_ = ShutdownTelemetry(ctx)
+	ast.Equal(t, len(telemetries), 0)
+}
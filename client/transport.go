@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contextTransport sets the context of every request, as the zms client creates its requests without one
+type contextTransport struct {
+	ctx       context.Context
+	transport http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(req.WithContext(t.ctx))
+}
+
+const (
+	defaultMaxRetries = 4
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// retryTransport retries the idempotent requests that failed with a transient error (429 or 5xx)
+// with an exponential backoff, or after the time the server asks for in the Retry-After header
+type retryTransport struct {
+	transport  http.RoundTripper
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func newRetryTransport(transport http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		transport:  transport,
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) {
+		return t.transport.RoundTrip(req)
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil || !isTransientStatus(resp.StatusCode) || attempt >= t.maxRetries {
+			return resp, err
+		}
+		wait := t.backoff(attempt, resp.Header.Get("Retry-After"))
+		log.Printf("[DEBUG] ZMS %s %s returned %d, retrying in %s", req.Method, req.URL.Path, resp.StatusCode, wait)
+		countRetry(req.Context())
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *retryTransport) backoff(attempt int, retryAfter string) time.Duration {
+	wait := t.minBackoff << uint(attempt)
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			wait = time.Until(date)
+		}
+	}
+	if wait > t.maxBackoff {
+		wait = t.maxBackoff
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || (statusCode >= http.StatusInternalServerError && statusCode != http.StatusNotImplemented)
+}
+
+// the domain of a request, e.g. /zms/v1/domain/<domain>/role/<role> or /zms/v1/subdomain/<parent>
+var domainPathRegex = regexp.MustCompile(`/(?:domain|subdomain|userdomain)/([^/?]+)`)
+
+// domainLockTransport serializes the writes to the same domain. terraform applies the resources in
+// parallel, and concurrent changes of the same domain occasionally fail with a conflict in ZMS.
+// the writes to different domains and all the reads aren't limited
+type domainLockTransport struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	locks     map[string]chan struct{}
+}
+
+func newDomainLockTransport(transport http.RoundTripper) *domainLockTransport {
+	return &domainLockTransport{
+		transport: transport,
+		locks:     make(map[string]chan struct{}),
+	}
+}
+
+func (t *domainLockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isReadOnly(req.Method) {
+		return t.transport.RoundTrip(req)
+	}
+	lock := t.lock(domainOf(req.URL.Path))
+	select {
+	case lock <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-lock }()
+	return t.transport.RoundTrip(req)
+}
+
+func (t *domainLockTransport) lock(domain string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lock, ok := t.locks[domain]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		t.locks[domain] = lock
+	}
+	return lock
+}
+
+// auditRefTransport appends the metadata of the run, e.g. the commit, to the audit reference of every
+// change, so the audit log of ZMS links to the change that caused it
+type auditRefTransport struct {
+	transport http.RoundTripper
+	suffix    string
+}
+
+// the header of the audit reference of a change
+const auditRefHeader = "Y-Audit-Ref"
+
+func (t *auditRefTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Header[auditRefHeader]; !ok || isReadOnly(req.Method) {
+		return t.transport.RoundTrip(req)
+	}
+	// a round tripper doesn't modify the request, it's the one of the next retry as well
+	req = req.Clone(req.Context())
+	req.Header.Set(auditRefHeader, strings.TrimSpace(req.Header.Get(auditRefHeader)+" "+t.suffix))
+	return t.transport.RoundTrip(req)
+}
+
+// domainOf returns the domain in the path of a request, or an empty string for a request that isn't
+// of a specific domain (e.g. the creation of a top level domain)
+func domainOf(path string) string {
+	if match := domainPathRegex.FindStringSubmatch(path); match != nil {
+		return strings.ToLower(match[1])
+	}
+	return ""
+}
+
+func isReadOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	ast "gotest.tools/assert"
+)
+
+func Test_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "home.someone"}`))
+	}))
+	defer server.Close()
+	zmsClient := Client{Url: server.URL, Transport: http.DefaultTransport}
+
+	domain, err := zmsClient.WithContext(context.Background()).GetDomain("home.someone")
+	ast.NilError(t, err)
+	ast.Equal(t, domain.Name, zms.DomainName("home.someone"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = zmsClient.WithContext(ctx).GetDomain("home.someone")
+	ast.ErrorContains(t, err, "context canceled")
+}
+
+func newTestRetryTransport() *retryTransport {
+	transport := newRetryTransport(http.DefaultTransport)
+	transport.minBackoff = time.Millisecond
+	return transport
+}
+
+func Test_retryTransport(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	// case: an idempotent request is retried, with its body
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("some body"))
+	ast.NilError(t, err)
+	resp, err := newTestRetryTransport().RoundTrip(req)
+	ast.NilError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	ast.Equal(t, resp.StatusCode, http.StatusOK)
+	ast.Equal(t, string(body), "some body")
+	ast.Equal(t, calls, 3)
+
+	// case: a post isn't retried
+	calls = 0
+	req, err = http.NewRequest(http.MethodPost, server.URL, strings.NewReader("some body"))
+	ast.NilError(t, err)
+	resp, err = newTestRetryTransport().RoundTrip(req)
+	ast.NilError(t, err)
+	ast.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+	ast.Equal(t, calls, 1)
+}
+
+func Test_retryTransportMaxRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ast.NilError(t, err)
+	resp, err := newTestRetryTransport().RoundTrip(req)
+	ast.NilError(t, err)
+	ast.Equal(t, resp.StatusCode, http.StatusTooManyRequests)
+	ast.Equal(t, calls, defaultMaxRetries+1)
+}
+
+func Test_retryTransportBackoff(t *testing.T) {
+	transport := newRetryTransport(http.DefaultTransport)
+	ast.Equal(t, transport.backoff(0, ""), defaultMinBackoff)
+	ast.Equal(t, transport.backoff(2, ""), 4*defaultMinBackoff)
+	ast.Equal(t, transport.backoff(20, ""), defaultMaxBackoff)
+	ast.Equal(t, transport.backoff(0, "3"), 3*time.Second)
+	ast.Equal(t, transport.backoff(0, "3600"), defaultMaxBackoff)
+	ast.Equal(t, transport.backoff(0, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)), time.Duration(0))
+	ast.Equal(t, transport.backoff(0, "not a delay"), defaultMinBackoff)
+}
+
+func Test_domainOf(t *testing.T) {
+	ast.Equal(t, domainOf("/zms/v1/domain/Some_Domain/role/readers/member/user.john"), "some_domain")
+	ast.Equal(t, domainOf("/zms/v1/subdomain/some_domain/sub"), "some_domain")
+	ast.Equal(t, domainOf("/zms/v1/userdomain/john"), "john")
+	ast.Equal(t, domainOf("/zms/v1/domain"), "")
+}
+
+func Test_domainLockTransport(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	zmsClient := Client{Url: server.URL, Transport: newDomainLockTransport(http.DefaultTransport)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := zmsClient.PutRole("some_domain", fmt.Sprintf("role%d", i), "", &zms.Role{})
+			ast.NilError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	ast.Equal(t, maxInFlight, 1)
+
+	// case: a canceled request stops waiting for the lock
+	transport := newDomainLockTransport(http.DefaultTransport)
+	transport.lock("some_domain") <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, server.URL+"/domain/some_domain/role/readers", nil)
+	_, err := transport.RoundTrip(req)
+	ast.ErrorContains(t, err, "context canceled")
+}
+
+func Test_newHTTPTransport(t *testing.T) {
+	transport := newHTTPTransport(&tls.Config{}, ZmsConfig{})
+	ast.Equal(t, transport.MaxIdleConnsPerHost, defaultMaxConnsPerHost)
+	ast.Assert(t, transport.ForceAttemptHTTP2)
+	ast.Assert(t, transport.TLSClientConfig.ClientSessionCache != nil)
+	ast.Assert(t, transport.Proxy != nil)
+
+	transport = newHTTPTransport(&tls.Config{}, ZmsConfig{MaxConnsPerHost: 200, DisableHTTP2: true})
+	ast.Equal(t, transport.MaxIdleConnsPerHost, 200)
+	ast.Equal(t, transport.MaxIdleConns, 200)
+	ast.Assert(t, !transport.ForceAttemptHTTP2)
+	ast.Equal(t, len(transport.TLSNextProto), 0)
+	ast.Assert(t, transport.TLSNextProto != nil)
+}
+
+func Test_auditRefTransport(t *testing.T) {
+	var auditRefs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditRefs = append(auditRefs, r.Header.Get(auditRefHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	transport := &auditRefTransport{transport: http.DefaultTransport, suffix: "[commit=0a1b2c3]"}
+	send := func(method string, auditRef *string) {
+		req, err := http.NewRequest(method, server.URL+"/zms/v1/domain/some_domain/role/readers", nil)
+		ast.NilError(t, err)
+		if auditRef != nil {
+			req.Header.Add(auditRefHeader, *auditRef)
+		}
+		resp, err := transport.RoundTrip(req)
+		ast.NilError(t, err)
+		resp.Body.Close()
+		// the request of the next retry doesn't have the suffix
+		if auditRef != nil {
+			ast.Equal(t, req.Header.Get(auditRefHeader), *auditRef)
+		}
+	}
+	auditRef, empty := "TICKET-1234", ""
+	send(http.MethodPut, &auditRef)
+	send(http.MethodDelete, &empty)
+	send(http.MethodPost, nil)
+	send(http.MethodGet, &auditRef)
+	ast.DeepEqual(t, auditRefs, []string{"TICKET-1234 [commit=0a1b2c3]", "[commit=0a1b2c3]", "", "TICKET-1234"})
+}
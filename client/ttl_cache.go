@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// listsBucket is the directory of the reads that aren't of a domain, e.g. the domain list. it isn't a valid
+// domain name, so it's never the directory of a domain
+const listsBucket = ".lists"
+
+var (
+	// the lists of the server that aren't of a domain: the domains, and the server templates
+	listPathRegex = regexp.MustCompile(`/(?:domain|template|templatedetails|template/[^/]+)$`)
+	// the creation and the deletion of a domain, a top level, a sub or a user domain
+	domainLifecyclePathRegex = regexp.MustCompile(`/(?:domain|subdomain/[^/]+|userdomain)(?:/[^/]+)?$`)
+)
+
+type cachedReadsKey struct{}
+
+// WithCachedReads returns a context whose reads may be served from the cache of the data sources, when
+// the cache is enabled in the client
+func WithCachedReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cachedReadsKey{}, true)
+}
+
+func cachedReads(ctx context.Context) bool {
+	v, _ := ctx.Value(cachedReadsKey{}).(bool)
+	return v
+}
+
+// ttlCacheTransport keeps the successful responses of the reads of the data sources in files, so the
+// plans that run within the ttl read the same objects from the files. the files of a domain are in a
+// directory of the domain, which is removed by a write to the domain. the lists that aren't of a domain are
+// in their own directory, removed by the creation or the deletion of a domain
+type ttlCacheTransport struct {
+	transport http.RoundTripper
+	dir       string
+	ttl       time.Duration
+	// the responses of different principals aren't shared
+	principal string
+}
+
+type cachedResponse struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func newTTLCacheTransport(transport http.RoundTripper, dir string, ttl time.Duration, principal string) *ttlCacheTransport {
+	return &ttlCacheTransport{transport: transport, dir: dir, ttl: ttl, principal: principal}
+}
+
+func (t *ttlCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	domain := domainOf(req.URL.Path)
+	if !isReadOnly(req.Method) {
+		if domain != "" {
+			if err := os.RemoveAll(filepath.Join(t.dir, domain)); err != nil {
+				log.Printf("[WARN] can't remove the cached reads of the domain %s: %s", domain, err)
+			}
+		}
+		if (req.Method == http.MethodPost || req.Method == http.MethodDelete) && domainLifecyclePathRegex.MatchString(req.URL.Path) {
+			if err := os.RemoveAll(filepath.Join(t.dir, listsBucket)); err != nil {
+				log.Printf("[WARN] can't remove the cached reads of the domain lists: %s", err)
+			}
+		}
+		return t.transport.RoundTrip(req)
+	}
+	if domain == "" && listPathRegex.MatchString(req.URL.Path) {
+		domain = listsBucket
+	}
+	if req.Method != http.MethodGet || domain == "" || !cachedReads(req.Context()) {
+		return t.transport.RoundTrip(req)
+	}
+
+	path := t.path(domain, req)
+	if cached, ok := t.read(path); ok {
+		log.Printf("[DEBUG] %s is read from the cache", req.URL.Path)
+		return &http.Response{
+			Status:        http.StatusText(http.StatusOK),
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        cached.Header,
+			Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+		err = writeFileAtomically(path, cachedResponse{Header: resp.Header, Body: body})
+	}
+	if err != nil {
+		log.Printf("[WARN] can't cache the read of %s: %s", req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (t *ttlCacheTransport) path(domain string, req *http.Request) string {
+	hash := sha256.Sum256([]byte(t.principal + " " + req.URL.String()))
+	return filepath.Join(t.dir, domain, hex.EncodeToString(hash[:])+".json")
+}
+
+func (t *ttlCacheTransport) read(path string) (cachedResponse, bool) {
+	var cached cachedResponse
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > t.ttl {
+		return cached, false
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || json.Unmarshal(content, &cached) != nil {
+		return cached, false
+	}
+	return cached, true
+}
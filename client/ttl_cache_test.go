@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ast "gotest.tools/assert"
+)
+
+func Test_ttlCacheTransport(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte("some body"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	request := func(ctx context.Context, transport http.RoundTripper, method string) string {
+		req, err := http.NewRequestWithContext(ctx, method, server.URL+"/zms/v1/domain/some_domain/role/readers", nil)
+		ast.NilError(t, err)
+		resp, err := transport.RoundTrip(req)
+		ast.NilError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+	cached := WithCachedReads(context.Background())
+
+	// case: a read of a data source is kept for the next runs
+	ast.Equal(t, request(cached, newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal"), http.MethodGet), "some body")
+	ast.Equal(t, request(cached, newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal"), http.MethodGet), "some body")
+	ast.Equal(t, calls, 1)
+
+	// case: the reads of the resources and of other principals aren't served from the cache
+	request(context.Background(), newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal"), http.MethodGet)
+	request(cached, newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "other.principal"), http.MethodGet)
+	ast.Equal(t, calls, 3)
+
+	// case: an expired read isn't served
+	for _, principal := range []string{"some.principal", "other.principal"} {
+		file := newTTLCacheTransport(nil, dir, time.Hour, principal).path("some_domain", mustRequest(t, server.URL+"/zms/v1/domain/some_domain/role/readers"))
+		old := time.Now().Add(-2 * time.Hour)
+		ast.NilError(t, os.Chtimes(file, old, old))
+	}
+	request(cached, newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal"), http.MethodGet)
+	ast.Equal(t, calls, 4)
+
+	// case: a write to the domain removes its reads
+	request(context.Background(), newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal"), http.MethodPut)
+	_, err := os.Stat(filepath.Join(dir, "some_domain"))
+	ast.Assert(t, os.IsNotExist(err))
+}
+
+func Test_ttlCacheTransportLists(t *testing.T) {
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method+" "+r.URL.Path]++
+		_, _ = w.Write([]byte(`{"names": ["some_domain"]}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	request := func(method string, path string) {
+		req, err := http.NewRequestWithContext(WithCachedReads(context.Background()), method, server.URL+"/zms/v1"+path, nil)
+		ast.NilError(t, err)
+		resp, err := newTTLCacheTransport(http.DefaultTransport, dir, time.Hour, "some.principal").RoundTrip(req)
+		ast.NilError(t, err)
+		resp.Body.Close()
+	}
+
+	// case: the domain list and the server templates are kept, by query
+	request(http.MethodGet, "/domain?prefix=some")
+	request(http.MethodGet, "/domain?prefix=some")
+	request(http.MethodGet, "/domain?prefix=other")
+	request(http.MethodGet, "/template")
+	request(http.MethodGet, "/template")
+	ast.Equal(t, calls["GET /zms/v1/domain"], 2)
+	ast.Equal(t, calls["GET /zms/v1/template"], 1)
+
+	// case: a change of a domain that doesn't create or delete it keeps the lists
+	request(http.MethodPut, "/domain/some_domain/role/readers")
+	request(http.MethodGet, "/domain?prefix=some")
+	ast.Equal(t, calls["GET /zms/v1/domain"], 2)
+
+	// case: the creation of a domain removes the lists
+	request(http.MethodPost, "/subdomain/some_domain")
+	request(http.MethodGet, "/domain?prefix=some")
+	ast.Equal(t, calls["GET /zms/v1/domain"], 3)
+	request(http.MethodDelete, "/domain/other_domain")
+	_, err := os.Stat(filepath.Join(dir, listsBucket))
+	ast.Assert(t, os.IsNotExist(err))
+}
+
+func mustRequest(t *testing.T, url string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	ast.NilError(t, err)
+	return req
+}
@@ -4,18 +4,38 @@ import (
 	"context"
 	"flag"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/AthenZ/terraform-provider-athenz/athenz"
+	"github.com/AthenZ/terraform-provider-athenz/client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
+// the time to export the telemetry that wasn't exported yet when the provider stops
+const telemetryShutdownTimeout = 1500 * time.Millisecond
+
 func main() {
 
 	var debugMode bool
+	var generateDomains string
+	var generateSubDomains bool
+	var generateDir string
 
 	flag.BoolVar(&debugMode, "debug", false, "set to true to run the provider with support for debuggers like delve")
+	flag.StringVar(&generateDomains, "generate-config", "", "write the import blocks and the resources of the domains (comma separated) and their objects to the output, instead of running the provider")
+	flag.BoolVar(&generateSubDomains, "generate-sub-domains", false, "with -generate-config, write the sub domains of the domains too")
+	flag.StringVar(&generateDir, "generate-config-dir", "", "with -generate-config, write the configuration of each domain to <dir>/<domain>.tf instead of the output")
 	flag.Parse()
 
+	if generateDomains != "" {
+		if err := athenz.GenerateConfig(os.Stdout, strings.Split(generateDomains, ","), generateSubDomains, generateDir); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
 	opts := &plugin.ServeOpts{ProviderFunc: athenz.Provider}
 
 	if debugMode {
@@ -27,4 +47,11 @@ func main() {
 	}
 
 	plugin.Serve(opts)
+
+	// terraform waits for the provider to exit for a short time after the run
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+	defer cancel()
+	if err := client.ShutdownTelemetry(ctx); err != nil {
+		log.Printf("[WARN] %s", err)
+	}
 }